@@ -5,15 +5,17 @@ import (
 	"fmt"
 	"forjj/creds"
 	"forjj/drivers"
+	"forjj/explain"
+	"forjj/promote"
 	"forjj/flow"
 	"forjj/forjfile"
+	"forjj/git"
 	"forjj/repo"
 	"forjj/secrets"
 	forjjWorkspace "forjj/workspace"
 	"log"
 	"net/url"
 	"os"
-	"os/exec"
 	"regexp"
 	"strings"
 	"text/template"
@@ -56,6 +58,8 @@ type Forj struct {
 	// cli commands modules
 	secrets   secrets.Secrets
 	workspace forjjWorkspace.Workspace
+	explain   explain.Explain
+	promote   promote.Promote
 
 	contextAction string // Context action defined in ParseContext.
 	// Can be create/update or maintain. But it can be any others, like secrets...
@@ -70,9 +74,14 @@ type Forj struct {
 	InternalForjData     map[string]string
 	creds_file           *string // Credential file
 	forjfile_tmpl_path   string
-	Branch               string     // Update feature branch name
-	ContribRepoURIs      []*url.URL // URL to github raw files for plugin files.
-	RepotemplateRepo_uri *url.URL   // URL to github raw files for RepoTemplates.
+	Branch               string                     // Update feature branch name
+	fixBranchFrom        string                     // Infra branch used before moving to the feature branch.
+	fixBranchCreated     bool                       // true if the feature branch has been created by MoveToFixBranch.
+	runID                string                     // Identify the current forjj run. See RunID()
+	infraSynced          string                     // Infra upstream branch reconciled before loading the Forjfile. Pushed once validated.
+	commitData           forjfile.CommitMessageData // Run information given to the commit message template.
+	ContribRepoURIs      []*url.URL                 // URL to github raw files for plugin files.
+	RepotemplateRepo_uri *url.URL                   // URL to github raw files for RepoTemplates.
 	appMapEntries        map[string]AppMapEntry
 	no_maintain          *bool    // At create time. true to not start maintain task at the end of create.
 	debug_instances      []string // List of instances in debug mode
@@ -136,6 +145,8 @@ const (
 	ssh_dir_f     = "ssh-dir"
 	no_maintain_f = "no-maintain"
 	message_f     = "message"
+	fix_f         = "fix"
+	deployReset_f = "deploy-reset"
 )
 
 const (
@@ -208,6 +219,8 @@ func (a *Forj) init() {
 	opts_infra_path := cli.Opts().Envar("FORJJ_INFRA").Short('W')
 	opts_forjfile := cli.Opts().Short('F').Default(".")
 	opts_message := cli.Opts().Short('m')
+	opts_publishMode := cli.Opts().Default(publishModePush)
+	opts_syncStrategy := cli.Opts().Default(git.SyncFail)
 
 	a.app = kingpin.New(os.Args[0], forjj_help).UsageTemplate(DefaultUsageTemplate)
 
@@ -249,6 +262,30 @@ func (a *Forj) init() {
 
 	})
 
+	a.explain.Init(a.app, &a.f, &a.s, a.cli.IsParsePhase, a.buildExplainForjfile, func(context *explain.Context, cmd *kingpin.CmdClause) {
+		// Define Common flags required by ParseContext
+		context.Flag("contribs-repo",
+			cmd.Flag("contribs-repo", contribs_repo_help).Envar("CONTRIBS_REPO").Default(defaultContribsRepo)).String()
+		context.Flag("flows-repo",
+			cmd.Flag("flows-repo", flows_repo_help).Envar("FLOWS_REPO").Default(defaultFlowRepo)).String()
+		context.Flag("repotemplates-repo",
+			cmd.Flag("repotemplates-repo", repotemplates_repo_help).Envar("REPOTEMPLATES_REPO").Default(defaultRepoTemplate)).String()
+		context.Flag(infra_path_f,
+			cmd.Flag(infra_path_f, infra_path_help)).Envar("FORJJ_INFRA").Short('W').String()
+	})
+
+	a.promote.Init(a.app, &a.f, a.cli.IsParsePhase, func(context *promote.Context, cmd *kingpin.CmdClause) {
+		// Define Common flags required by ParseContext
+		context.Flag("contribs-repo",
+			cmd.Flag("contribs-repo", contribs_repo_help).Envar("CONTRIBS_REPO").Default(defaultContribsRepo)).String()
+		context.Flag("flows-repo",
+			cmd.Flag("flows-repo", flows_repo_help).Envar("FLOWS_REPO").Default(defaultFlowRepo)).String()
+		context.Flag("repotemplates-repo",
+			cmd.Flag("repotemplates-repo", repotemplates_repo_help).Envar("REPOTEMPLATES_REPO").Default(defaultRepoTemplate)).String()
+		context.Flag(infra_path_f,
+			cmd.Flag(infra_path_f, infra_path_help)).Envar("FORJJ_INFRA").Short('W').String()
+	})
+
 	var version string
 	if PRERELEASE {
 		version = "forjj pre-release V" + VERSION
@@ -287,6 +324,8 @@ func (a *Forj) init() {
 	a.actionDispatch[val_act] = a.validateAction
 	a.actionDispatch["secrets"] = a.secrets.Action
 	a.actionDispatch["workspace"] = a.workspace.Action
+	a.actionDispatch["explain"] = a.explain.Action
+	a.actionDispatch["promote"] = a.promote.Action
 
 	a.plugins = goforjj.NewPlugins()
 	//a.Actions = make(map[string]*ActionOpts)
@@ -456,7 +495,8 @@ func (a *Forj) init() {
 		// Add Update workspace flags to Create action, not prefixed.
 		// ex: forjj create --docker-exe-path ...
 		AddActionFlagsFromObjectAction(workspace, chg_act).
-		AddFlag(cli.String, forjfile_path_f, create_forjfile_help, opts_forjfile) == nil {
+		AddFlag(cli.String, forjfile_path_f, create_forjfile_help, opts_forjfile).
+		AddFlag(cli.Bool, fix_f, val_fix_help, nil) == nil {
 		log.Printf("action create: %s", a.cli.Error())
 	}
 
@@ -467,6 +507,8 @@ func (a *Forj) init() {
 		AddActionFlagsFromObjectAction(workspace, chg_act).
 		AddArg(cli.String, deployToArg, updateDeployToHelp, nil).
 		AddFlag(cli.Bool, "deploy-publish", updateDeployPublishHelp, nil).
+		AddFlag(cli.String, "publish-mode", updatePublishModeHelp, opts_publishMode).
+		AddFlag(cli.String, "branch", updateBranchHelp, nil).
 		AddFlag(cli.String, "ssh-dir", create_ssh_dir_help, nil) == nil {
 		log.Printf("action update: %s", a.cli.Error())
 	}
@@ -476,35 +518,43 @@ func (a *Forj) init() {
 		AddActionFlagsFromObjectAction(workspace, chg_act).
 		AddActionFlagFromObjectAction(infra, chg_act, infra_path_f).
 		AddArg(cli.String, deployToArg, maintainDeployToHelp, opts_required).
+		AddFlag(cli.String, "sync-strategy", maintainSyncStrategyHelp, opts_syncStrategy).
+		AddFlag(cli.Bool, deployReset_f, maintainDeployResetHelp, nil).
 		AddFlag(cli.String, "file", maintain_option_file, nil) == nil {
 		log.Printf("action maintain: %s", a.cli.Error())
 	}
 
-	_, err := exec.LookPath("git")
-	kingpin.FatalIfError(err, "Unable to find 'git' command. Ensure it available in your PATH and retry.\n")
-
 	// Add Forjfile/cli mapping for simple forj data getter
 	a.AddMap(orga_f, workspace, "", orga_f, "settings", "", orga_f)
 	a.AddMap(infra_name_f, infra, "", infra_name_f, infra, "", "name")
 	a.AddMap(infra_upstream_f, infra, "", infra_upstream_f, infra, "", "apps:upstream")
 	a.AddMap(deployToArg, "_app", "forjj", deployToArg, "settings", "default", "dev-deploy")
 	a.AddMapFunc("secrets", deployToArg, a.secrets.Context.GetStringValue)
+	a.AddMapFunc("explain", deployToArg, a.explain.GetStringValue)
 
 	a.AddMap(infra_path_f, workspace, "", infra_path_f, workspace, "", infra_path_f)
 	a.AddMapFunc("secrets", infra_path_f, a.secrets.GetStringValue)
 	a.AddMapFunc("workspace", infra_path_f, a.workspace.GetStringValue)
+	a.AddMapFunc("explain", infra_path_f, a.explain.GetStringValue)
+	a.AddMapFunc("promote", infra_path_f, a.promote.GetStringValue)
 
 	a.AddMap("contribs-repo", workspace, "", "contribs-repo", "", "", forjfile.ContribRepoPathField)
 	a.AddMapFunc("secrets", "contribs-repo", a.secrets.GetStringValue)
 	a.AddMapFunc("workspace", "contribs-repo", a.workspace.GetStringValue)
+	a.AddMapFunc("explain", "contribs-repo", a.explain.GetStringValue)
+	a.AddMapFunc("promote", "contribs-repo", a.promote.GetStringValue)
 
 	a.AddMap("flows-repo", workspace, "", "flows-repo", "", "", "flow-repo-path")
 	a.AddMapFunc("secrets", "flows-repo", a.secrets.GetStringValue)
 	a.AddMapFunc("workspace", "flows-repo", a.workspace.GetStringValue)
+	a.AddMapFunc("explain", "flows-repo", a.explain.GetStringValue)
+	a.AddMapFunc("promote", "flows-repo", a.promote.GetStringValue)
 
 	a.AddMap("repotemplates-repo", workspace, "", "repotemplates-repo", "", "", "repotemplate-repo-path")
 	a.AddMapFunc("secrets", "repotemplates-repo", a.secrets.GetStringValue)
 	a.AddMapFunc("workspace", "repotemplates-repo", a.workspace.GetStringValue)
+	a.AddMapFunc("explain", "repotemplates-repo", a.explain.GetStringValue)
+	a.AddMapFunc("promote", "repotemplates-repo", a.promote.GetStringValue)
 	// TODO: Add git-remote cli mapping
 }
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"forjj/creds"
 	"forjj/forjfile"
+	"forjj/git"
 	"forjj/utils"
 	"log"
 	"net/url"
@@ -32,7 +33,7 @@ const Workspace_Name = ".forj-workspace"
 //
 // This behavior is required to let cli execute the --help if needed.
 // if an error is returned, the help if selected will never been displayed...
-func (a *Forj) ParseContext(c *cli.ForjCli, _ interface{}) (error, bool) {
+func (a *Forj) ParseContext(c *cli.ForjCli, _ interface{}) (_ error, ok bool) {
 	gotrace.Trace("Setting FORJJ Context...")
 
 	// Load Forjfile models in case of 'create' task.
@@ -47,6 +48,8 @@ func (a *Forj) ParseContext(c *cli.ForjCli, _ interface{}) (error, bool) {
 	// have to define, like FORJJ_INFRA (--infra-path)
 	a.secrets.DefineContext(c.GetParseContext())
 	a.workspace.DefineContext(c.GetParseContext())
+	a.explain.DefineContext(c.GetParseContext())
+	a.promote.DefineContext(c.GetParseContext())
 
 	if a.contextAction == cr_act || a.contextAction == val_act {
 		// Detect and load a Forjfile model given.
@@ -65,6 +68,19 @@ func (a *Forj) ParseContext(c *cli.ForjCli, _ interface{}) (error, bool) {
 	// Load Workspace information if found
 	a.w.Load()
 
+	// Select the git backend configured in the workspace.
+	if err := git.SetBackend(a.w.GetString(forjfile.GitBackendField)); err != nil {
+		a.w.SetError(err)
+		return nil, false
+	}
+
+	// Sign commits as configured in the workspace.
+	if err := git.SetSigning(a.w.GetString(forjfile.GitSigningFormatField), a.w.GetString(forjfile.GitSigningKeyField),
+		a.w.GetString(forjfile.GitSigningProgramField)); err != nil {
+		a.w.SetError(err)
+		return nil, false
+	}
+
 	// Read definition file from repo.
 	is_valid_action := (utils.InStringList(a.contextAction, val_act, cr_act, upd_act, maint_act, add_act, rem_act, ren_act, chg_act, list_act) != "")
 	need_to_create := (a.contextAction == cr_act)
@@ -75,6 +91,29 @@ func (a *Forj) ParseContext(c *cli.ForjCli, _ interface{}) (error, bool) {
 		return nil, false
 	}
 
+	// Move to the update feature branch, so the Forjfile is loaded from this branch.
+	if need_to_update {
+		branch, _, _, _ := a.cli.GetStringValue("_app", "forjj", "branch")
+		if err := a.MoveToFixBranch(branch); err != nil {
+			a.w.SetError(fmt.Errorf("Unable to move to your feature branch. %s", err))
+			return nil, false
+		}
+		// Do not leave the user on the feature branch if the context can't be loaded.
+		defer func() {
+			if !ok || a.w.Error() != nil {
+				a.restoreFromFixBranch()
+			}
+		}()
+	}
+
+	// Pull the infra repository on maintain, so the Forjfile loaded is the one to maintain.
+	if a.contextAction == maint_act {
+		if err := a.syncInfraRepo(); err != nil {
+			a.w.SetError(fmt.Errorf("Unable to synchronize the infra repository. %s", err))
+			return nil, false
+		}
+	}
+
 	// Load Forjfile from infra repo, if found.
 	if err := a.LoadForge(); err != nil {
 		if utils.InStringList(a.contextAction, upd_act, maint_act, add_act, rem_act, ren_act, chg_act, list_act) != "" {
@@ -104,7 +143,7 @@ func (a *Forj) ParseContext(c *cli.ForjCli, _ interface{}) (error, bool) {
 	for name, deploy := range a.f.GetDeployments() {
 		deploy.DeploymentCoreStruct.GitSetRepo(deployPath, "")
 
-		if deploy.Type == "DEV" && !deployPublish && need_to_update {
+		if deployType, _ := a.f.GetDeploymentTypePolicy(deploy.Type); deployType.CanBeDevDeploy() && !deployPublish && need_to_update {
 			devRepoWS := deploy.GetRepoPath()
 			devRepoAside := path.Join(path.Dir(a.f.InfraPath()), name)
 			os.Remove(devRepoAside)
@@ -114,7 +114,8 @@ func (a *Forj) Create() error {
 		log.Print("CREATE: Automatic git push and forjj maintain enabled.")
 	}
 
-	if err := a.ValidateForjfile(); err != nil {
+	// Secure flags values of the Forjfile model are moved to secrets by scanCreds.
+	if err := a.validateForjfile(false); err != nil {
 		return fmt.Errorf("Your Forjfile is having issues. %s. Fix it and retry", err)
 	}
 
@@ -162,7 +163,11 @@ func (a *Forj) Create() error {
 	// Then it commit initial files to the Infra repo.
 	// TODO: Add force option. Currently, forced to false.
 	// NOTE: Forjfiles are saved at this time. (a.initial_commit)
-	if err := a.i.Create(a.f.InfraPath(), a.initial_commit, false); err != nil {
+	commitMsg, err := a.commitMessage("create", "Initial commit")
+	if err != nil {
+		return err
+	}
+	if err := a.i.Create(a.f.InfraPath(), a.initial_commit, commitMsg, false); err != nil {
 		return fmt.Errorf("Failed to create your infra repository. %s", err)
 	}
 
@@ -243,7 +248,10 @@ func (a *Forj) createDeployment(deploy string) error {
 		}
 	}
 
-	commitMsg := fmt.Sprintf("Forge '%s' created.", a.w.GetString("organization"))
+	commitMsg, err := a.commitMessage("create", fmt.Sprintf("Forge '%s' created.", a.w.GetString("organization")))
+	if err != nil {
+		return err
+	}
 	if err := git.Commit(commitMsg, true); err != nil {
 		return fmt.Errorf("Failed to commit source files. %s", err)
 	}
@@ -0,0 +1,110 @@
+package creds
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+)
+
+// atomicFile is a file content to write with writeFilesAtomic.
+type atomicFile struct {
+	data []byte
+	perm os.FileMode
+}
+
+// writeFileAtomic write a file through a temporary file renamed at the end.
+//
+// The file is never partially written.
+func writeFileAtomic(file string, data []byte, perm os.FileMode) error {
+	return writeFilesAtomic(map[string]atomicFile{file: {data: data, perm: perm}})
+}
+
+// writeFilesAtomic write a collection of files, all or nothing.
+//
+// All files are written in temporary files first. Then existing files are moved to backups, and temporary
+// files renamed. On any error, backups are restored and temporary files removed.
+func writeFilesAtomic(files map[string]atomicFile) (err error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	temps := make(map[string]string)
+	backups := make(map[string]string)
+	renamed := []string{}
+	defer func() {
+		for _, temp := range temps {
+			os.Remove(temp)
+		}
+		if err == nil {
+			for _, backup := range backups {
+				os.Remove(backup)
+			}
+			return
+		}
+		// Restore files already replaced.
+		for _, name := range renamed {
+			if backup, found := backups[name]; found {
+				os.Rename(backup, name)
+			} else {
+				os.Remove(name)
+			}
+		}
+		for name, backup := range backups {
+			if _, err := os.Stat(name); os.IsNotExist(err) {
+				os.Rename(backup, name)
+			}
+		}
+	}()
+
+	for _, name := range names {
+		var temp string
+		if temp, err = writeTempFile(name, files[name]); err != nil {
+			return fmt.Errorf("Unable to write '%s'. %s", name, err)
+		}
+		temps[name] = temp
+	}
+
+	for _, name := range names {
+		if _, err = os.Stat(name); err == nil {
+			backup := name + ".bak"
+			if err = os.Rename(name, backup); err != nil {
+				return fmt.Errorf("Unable to backup '%s'. %s", name, err)
+			}
+			backups[name] = backup
+		}
+		if err = os.Rename(temps[name], name); err != nil {
+			return fmt.Errorf("Unable to save '%s'. %s", name, err)
+		}
+		delete(temps, name)
+		renamed = append(renamed, name)
+	}
+	return nil
+}
+
+// writeTempFile write data in a temporary file created in the file directory and return its name.
+func writeTempFile(file string, content atomicFile) (_ string, err error) {
+	fd, err := ioutil.TempFile(path.Dir(file), "."+path.Base(file)+".")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			fd.Close()
+			os.Remove(fd.Name())
+		}
+	}()
+	if err = fd.Chmod(content.perm); err != nil {
+		return
+	}
+	if _, err = fd.Write(content.data); err != nil {
+		return
+	}
+	if err = fd.Sync(); err != nil {
+		return
+	}
+	return fd.Name(), fd.Close()
+}
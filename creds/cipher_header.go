@@ -0,0 +1,110 @@
+package creds
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Encrypted data starts with a header line authenticated as AEAD additional data:
+//
+//   forjj-secrets:<version>;key=<key ID>;env=<environment>\n<nonce><ciphertext>
+//
+// The environment name binds the encrypted data to its environment file. Data encrypted before the header
+// existed is decrypted without additional data only to be upgraded by Secure.Upgrade, as long as no secret file
+// has been upgraded. Otherwise, it is refused.
+// Data encrypted with a key derived from a passphrase adds the KDF parameters to the header. See passphrase.go
+
+const (
+	// CipherVersion is the latest encrypted data format version.
+	CipherVersion = 1
+
+	cipherHeaderPrefix = "forjj-secrets:"
+)
+
+// cipherHeader is the header of encrypted data.
+type cipherHeader struct {
+	version int
+	keyID   string
+	env     string
+
+	// kdf is set if the key is derived from a passphrase. See passphrase.go
+	kdf *kdfParams
+}
+
+// keyID return a short identifier of a key. It does not reveal the key.
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// bytes return the header line.
+func (h *cipherHeader) bytes() []byte {
+	if h.kdf != nil {
+		return []byte(fmt.Sprintf("%s%d;key=%s;env=%s;%s\n", cipherHeaderPrefix, h.version, h.keyID, h.env, h.kdf))
+	}
+	return []byte(fmt.Sprintf("%s%d;key=%s;env=%s\n", cipherHeaderPrefix, h.version, h.keyID, h.env))
+}
+
+// parseCipherHeader split encrypted data in header and ciphertext.
+// found is false if the data has no header (data encrypted before the header existed).
+func parseCipherHeader(data []byte) (header *cipherHeader, headerData, ciphertext []byte, found bool, _ error) {
+	if !bytes.HasPrefix(data, []byte(cipherHeaderPrefix)) {
+		return nil, nil, data, false, nil
+	}
+	end := bytes.IndexByte(data, '\n')
+	if end < 0 {
+		return nil, nil, nil, true, fmt.Errorf("Invalid encrypted data header")
+	}
+	headerData, ciphertext = data[:end+1], data[end+1:]
+
+	fields := strings.Split(string(data[len(cipherHeaderPrefix):end]), ";")
+	header = new(cipherHeader)
+	version, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, nil, nil, true, fmt.Errorf("Invalid encrypted data version '%s'", fields[0])
+	}
+	header.version = version
+	for _, field := range fields[1:] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, nil, nil, true, fmt.Errorf("Invalid encrypted data header field '%s'", field)
+		}
+		switch kv[0] {
+		case "key":
+			header.keyID = kv[1]
+		case "env":
+			header.env = kv[1]
+		case "kdf", "salt", "t", "m", "p":
+			if header.kdf == nil {
+				header.kdf = new(kdfParams)
+			}
+			if err := header.kdf.setField(kv[0], kv[1]); err != nil {
+				return nil, nil, nil, true, err
+			}
+		}
+	}
+	if header.kdf != nil {
+		if err := header.kdf.check(); err != nil {
+			return nil, nil, nil, true, err
+		}
+	}
+	return header, headerData, ciphertext, true, nil
+}
+
+// check verify the header can be decrypted by the key for the environment given.
+func (h *cipherHeader) check(env string, key []byte) error {
+	if h.version > CipherVersion {
+		return fmt.Errorf("Encrypted data format version %d is not supported. Upgrade forjj", h.version)
+	}
+	if h.env != env {
+		return fmt.Errorf("Data encrypted for environment '%s' can't be used for environment '%s'", h.env, env)
+	}
+	if id := keyID(key); h.keyID != id {
+		return fmt.Errorf("Data encrypted with key '%s'. The current key is '%s'", h.keyID, id)
+	}
+	return nil
+}
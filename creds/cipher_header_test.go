@@ -0,0 +1,179 @@
+package creds
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// legacyEncrypt encrypt data without header, as done before the header was introduced.
+func legacyEncrypt(key, secretData []byte) []byte {
+	c, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(c)
+	nonce := make([]byte, gcm.NonceSize())
+	rand.Read(nonce)
+	return gcm.Seal(nonce, nonce, secretData, nil)
+}
+
+func TestCipherHeader(t *testing.T) {
+	assert := assert.New(t)
+
+	s := NewSecrets()
+	s.GenerateKey()
+	other := NewSecrets()
+	other.GenerateKey()
+	data := []byte("secret data")
+
+	/*********************************/
+	testCase := "when data is encrypted for an environment"
+
+	// ------------ Run function to test
+	encrypted, err := s.encrypt("prod", data)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Truef(bytes.HasPrefix(encrypted, []byte("forjj-secrets:1;key="+keyID(s.key)+";env=prod\n")),
+		"Expect the header %s", testCase)
+	decrypted, legacy, err := s.decrypt("prod", encrypted)
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Falsef(legacy, "Expect no legacy data %s", testCase)
+	assert.Equalf(data, decrypted, "Expect data to be decrypted %s", testCase)
+
+	/*********************************/
+	testCase = "when data is decrypted for another environment"
+
+	// ------------ Run function to test
+	_, _, err = s.decrypt("test", encrypted)
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when the header environment is updated"
+
+	// ------------ Run function to test
+	_, _, err = s.decrypt("test", bytes.Replace(encrypted, []byte("env=prod"), []byte("env=test"), 1))
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect the header to be authenticated %s", testCase)
+
+	/*********************************/
+	testCase = "when data is decrypted with another key"
+
+	// ------------ Run function to test
+	_, _, err = other.decrypt("prod", encrypted)
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when data has a newer format version"
+
+	// ------------ Run function to test
+	_, _, err = s.decrypt("prod", bytes.Replace(encrypted, []byte("forjj-secrets:1;"), []byte("forjj-secrets:2;"), 1))
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when data has no header"
+
+	// ------------ Run function to test
+	_, _, err = s.decrypt("prod", legacyEncrypt(s.key, data))
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect data without header to be refused %s", testCase)
+
+	/*********************************/
+	testCase = "when data has no header and can be upgraded"
+	s.cipherUpgrade = true
+
+	// ------------ Run function to test
+	decrypted, legacy, err = s.decrypt("prod", legacyEncrypt(s.key, data))
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Truef(legacy, "Expect legacy data %s", testCase)
+	assert.Equalf(data, decrypted, "Expect data to be decrypted %s", testCase)
+}
+
+func TestUpgradeCipher(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "forjj-creds")
+	defer os.RemoveAll(dir)
+	d := newTestSecure(t, dir)
+	noUpgrade := func(*Secure, string) error { return nil }
+	prodFile := d.DefineDefaultSecretFileName(dir, "prod")
+	testFile := d.DefineDefaultSecretFileName(dir, "test")
+
+	/*********************************/
+	testCase := "when an environment file is copied to another environment"
+	prodData, _ := ioutil.ReadFile(prodFile)
+	testData, _ := ioutil.ReadFile(testFile)
+	ioutil.WriteFile(testFile, prodData, 0644)
+
+	// ------------ Run function to test
+	_, err := loadTestSecure(dir, "test")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+	ioutil.WriteFile(testFile, testData, 0644)
+
+	/*********************************/
+	testCase = "when an environment file has no header beside files with header"
+	plain := make(map[string][]byte)
+	files := map[string]string{Global: d.DefineDefaultSecretFileName(dir, Global), "prod": prodFile, "test": testFile}
+	for env, file := range files {
+		data, _ := ioutil.ReadFile(file)
+		plain[env], _, _ = d.secrets.decrypt(env, data)
+	}
+	ioutil.WriteFile(prodFile, legacyEncrypt(d.secrets.key, plain["prod"]), 0644)
+
+	// ------------ Run function to test
+	_, err = loadTestSecure(dir, "prod")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect the file to be refused %s", testCase)
+
+	/*********************************/
+	testCase = "when environment files have no header"
+	for env, file := range files {
+		ioutil.WriteFile(file, legacyEncrypt(d.secrets.key, plain[env]), 0644)
+	}
+
+	// ------------ Run function to test
+	loaded, err := loadTestSecure(dir, "prod")
+	if err == nil {
+		err = loaded.Upgrade(noUpgrade)
+	}
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	data, _ := ioutil.ReadFile(prodFile)
+	assert.Truef(bytes.HasPrefix(data, []byte("forjj-secrets:1;")), "Expect the file to be upgraded %s", testCase)
+	loaded, err = loadTestSecure(dir, "prod")
+	if assert.NoErrorf(err, "Expect the upgraded file to be loaded %s", testCase) {
+		value, _, _, _ := loaded.GetString("app", "github", "token")
+		assert.Equalf("prod-secret", value, "Expect the secret to be kept %s", testCase)
+	}
+
+	/*********************************/
+	testCase = "when files without header are restored after the upgrade"
+	for env, file := range files {
+		ioutil.WriteFile(file, legacyEncrypt(d.secrets.key, plain[env]), 0644)
+	}
+	ioutil.WriteFile(prodFile, legacyEncrypt(d.secrets.key, plain["test"]), 0644)
+
+	// ------------ Run function to test
+	_, err = loadTestSecure(dir, "prod")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect the file to be refused %s", testCase)
+}
@@ -0,0 +1,63 @@
+package creds
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// LoadEnv load the secrets file of an environment, if not already defined by InitEnvDefaults.
+func (d *Secure) LoadEnv(env string) error {
+	if d == nil {
+		return fmt.Errorf("Secure object is nil")
+	}
+	if _, found := d.secrets.Envs[env]; found {
+		return nil
+	}
+	d.SetDefaultFile(env)
+	return d.secrets.Envs[env].load(env, true)
+}
+
+// CopyObjectValue copy a secret from an environment to another, with its source, resources and metadata.
+//
+// Unlike SetObjectValue, the source setter is not called, as the value is already set.
+func (d *Secure) CopyObjectValue(fromEnv, toEnv, objName, instanceName, keyName string) (updated bool, _ error) {
+	if d == nil {
+		return false, fmt.Errorf("Secure object is nil")
+	}
+	from, found := d.secrets.Envs[fromEnv]
+	if !found {
+		return false, fmt.Errorf("Credential env '%s' not found", fromEnv)
+	}
+	to, found := d.secrets.Envs[toEnv]
+	if !found {
+		return false, fmt.Errorf("Credential env '%s' not found", toEnv)
+	}
+	value, found, _ := from.get(objName, instanceName, keyName)
+	if !found || value == nil {
+		return false, fmt.Errorf("Secret '%s/%s/%s' not found in '%s'", objName, instanceName, keyName, fromEnv)
+	}
+
+	dup := value.clone(to.s)
+	dup.resource = make(map[string]string)
+	for key, resource := range value.resource {
+		dup.resource[key] = resource
+	}
+
+	if to.Objects == nil {
+		to.Objects = make(map[string]map[string]map[string]*Value)
+	}
+	if to.Objects[objName] == nil {
+		to.Objects[objName] = make(map[string]map[string]*Value)
+	}
+	if to.Objects[objName][instanceName] == nil {
+		to.Objects[objName][instanceName] = make(map[string]*Value)
+	}
+	if old, found := to.Objects[objName][instanceName][keyName]; found && old.source == dup.source &&
+		old.value.Equal(dup.value) && reflect.DeepEqual(old.resource, dup.resource) {
+		return false, nil
+	}
+	to.Objects[objName][instanceName][keyName] = dup
+	to.sources = to.sources.Set("forjj", objName+"/"+instanceName+"/"+keyName, dup.value.GetString())
+	d.updated = true
+	return true, nil
+}
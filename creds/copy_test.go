@@ -0,0 +1,67 @@
+package creds
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyObjectValue(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "forjj-creds")
+	defer os.RemoveAll(dir)
+	newTestSecure(t, dir)
+	d, err := loadTestSecure(dir, "prod")
+	if err != nil {
+		t.Fatalf("Unable to load secrets. %s", err)
+	}
+
+	/*********************************/
+	testCase := "when an environment not loaded is loaded"
+
+	// ------------ Run function to test
+	err = d.LoadEnv("test")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf([]string{Global, "prod", "test"}, d.Envs(), "Expect the environment to be added %s", testCase)
+
+	/*********************************/
+	testCase = "when a secret is copied to another environment"
+
+	// ------------ Run function to test
+	updated, err := d.CopyObjectValue("test", "prod", "app", "github", "token")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Truef(updated, "Expect the secret to be updated %s", testCase)
+	secret, _, _, _ := d.GetString("app", "github", "token")
+	assert.Equalf("test-secret", secret, "Expect the copied value %s", testCase)
+	source, _ := d.EnvValues("test")["app/github/token"].GetResource(SetOnResource)
+	copied, _ := d.EnvValues("prod")["app/github/token"].GetResource(SetOnResource)
+	assert.Equalf(source, copied, "Expect metadata to be copied %s", testCase)
+
+	/*********************************/
+	testCase = "when the secret is copied again"
+
+	// ------------ Run function to test
+	updated, err = d.CopyObjectValue("test", "prod", "app", "github", "token")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Falsef(updated, "Expect no update %s", testCase)
+
+	/*********************************/
+	testCase = "when the secret or the environment does not exist"
+
+	// ------------ Run function to test
+	_, err = d.CopyObjectValue("test", "prod", "app", "github", "unknown")
+	_, err2 := d.CopyObjectValue("test", "staging", "app", "github", "token")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an unknown secret %s", testCase)
+	assert.Errorf(err2, "Expect an unknown environment %s", testCase)
+}
@@ -0,0 +1,140 @@
+package creds
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Secret metadata stored as Value resources.
+const (
+	SetByResource       = "set-by"
+	SetOnResource       = "set-on"
+	ExpiresOnResource   = "expires-on"
+	RotateEveryResource = "rotate-every"
+)
+
+// legacyTimeResources are resources which stored the time a secret was set before 'set-on' existed.
+var legacyTimeResources = []string{"created-on", "copied-on", "generated-on"}
+
+// Metadata describes when and by who a secret was set, and when it should be replaced.
+type Metadata struct {
+	SetBy       string
+	SetOn       time.Time     // Zero if unknown.
+	ExpiresOn   time.Time     // Zero if the secret has no expiry.
+	RotateEvery time.Duration // 0 if the secret has no rotation interval.
+}
+
+// Metadata return the secret metadata from the value resources.
+func (v *Value) Metadata() (m Metadata, err error) {
+	if v == nil {
+		return
+	}
+	m.SetBy = v.resource[SetByResource]
+	if setOn, found := v.resource[SetOnResource]; found {
+		if m.SetOn, err = time.Parse(time.RFC3339, setOn); err != nil {
+			return m, fmt.Errorf("Invalid '%s' metadata. %s", SetOnResource, err)
+		}
+	} else {
+		for _, resource := range legacyTimeResources {
+			if setOn, found := v.resource[resource]; found {
+				// Set with time.Now().String()
+				setOn = strings.SplitN(setOn, " m=", 2)[0]
+				if t, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", setOn); err == nil {
+					m.SetOn = t
+					break
+				}
+			}
+		}
+	}
+	if expiresOn, found := v.resource[ExpiresOnResource]; found {
+		if m.ExpiresOn, err = ParseExpiresOn(expiresOn); err != nil {
+			return
+		}
+	}
+	if rotateEvery, found := v.resource[RotateEveryResource]; found {
+		if m.RotateEvery, err = ParseInterval(rotateEvery); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// SetExpiry validate and store the expiry date and the rotation interval. Empty values are ignored.
+func (v *Value) SetExpiry(expiresOn, rotateEvery string) error {
+	if v == nil {
+		return nil
+	}
+	if expiresOn != "" {
+		t, err := ParseExpiresOn(expiresOn)
+		if err != nil {
+			return err
+		}
+		v.AddResource(ExpiresOnResource, t.Format(time.RFC3339))
+	}
+	if rotateEvery != "" {
+		if _, err := ParseInterval(rotateEvery); err != nil {
+			return err
+		}
+		v.AddResource(RotateEveryResource, rotateEvery)
+	}
+	return nil
+}
+
+// stamp set the 'set-by' and 'set-on' metadata, and keep expiry metadata given by the value set.
+func (v *Value) stamp(from *Value) {
+	for _, resource := range []string{ExpiresOnResource, RotateEveryResource} {
+		if value, found := from.resource[resource]; found {
+			v.AddResource(resource, value)
+		}
+	}
+	v.AddResource(SetOnResource, time.Now().UTC().Format(time.RFC3339))
+	v.AddResource(SetByResource, currentUser())
+}
+
+// ParseExpiresOn read an expiry date. Format is YYYY-MM-DD or RFC3339.
+func ParseExpiresOn(expiresOn string) (t time.Time, err error) {
+	if t, err = time.Parse("2006-01-02", expiresOn); err == nil {
+		return
+	}
+	if t, err = time.Parse(time.RFC3339, expiresOn); err != nil {
+		err = fmt.Errorf("Invalid expiry date '%s'. Format is YYYY-MM-DD or RFC3339", expiresOn)
+	}
+	return
+}
+
+// ParseInterval read an interval, like a rotation interval. Days (d) and weeks (w) are supported in addition to go
+// durations.
+func ParseInterval(interval string) (time.Duration, error) {
+	unit := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	var (
+		d   time.Duration
+		err error
+	)
+	if last := len(interval) - 1; last > 0 && unit[interval[last]] != 0 {
+		var n int
+		if n, err = strconv.Atoi(interval[:last]); err == nil {
+			d = time.Duration(n) * unit[interval[last]]
+		}
+	} else {
+		d, err = time.ParseDuration(interval)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("Invalid interval '%s'. Use a duration like 90d, 2w or 12h", interval)
+	}
+	return d, nil
+}
+
+// currentUser return the user name setting secrets. FORJJ_USER can overwrite the system user.
+func currentUser() string {
+	if name := os.Getenv("FORJJ_USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
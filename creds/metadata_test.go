@@ -0,0 +1,104 @@
+package creds
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterval(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := map[string]time.Duration{
+		"90d": 90 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+		"d":   0,
+		"-1d": 0,
+		"0s":  0,
+		"1y":  0,
+	}
+
+	for interval, expected := range tests {
+		testCase := "when the interval is '" + interval + "'"
+
+		// ------------ Run function to test
+		d, err := ParseInterval(interval)
+
+		// ------------ Test result
+		if expected == 0 {
+			assert.Errorf(err, "Expect an error %s", testCase)
+			continue
+		}
+		assert.NoErrorf(err, "Expect no error %s", testCase)
+		assert.Equalf(expected, d, "Expect the duration %s", testCase)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "forjj-creds")
+	defer os.RemoveAll(dir)
+	d := newTestSecure(t, dir)
+	os.Setenv("FORJJ_USER", "alice")
+	defer os.Unsetenv("FORJJ_USER")
+
+	/*********************************/
+	testCase := "when a secret is set with expiry metadata"
+	value := NewValue(Internal, goforjj.NewValueStruct("secret"))
+	err := value.SetExpiry("2030-01-31", "90d")
+	before := time.Now().Add(-time.Second)
+
+	// ------------ Run function to test
+	d.SetObjectValue("prod", "forjj", "app", "github", "token", value)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	stored, _, _, _ := d.Get("app", "github", "token")
+	meta, err := stored.Metadata()
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf("alice", meta.SetBy, "Expect the user who set the secret %s", testCase)
+	assert.Truef(meta.SetOn.After(before), "Expect the date the secret was set %s", testCase)
+	assert.Equalf(time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC), meta.ExpiresOn, "Expect the expiry date %s", testCase)
+	assert.Equalf(90*24*time.Hour, meta.RotateEvery, "Expect the rotation interval %s", testCase)
+
+	/*********************************/
+	testCase = "when the same secret is set again"
+	stored.AddResource(SetOnResource, "2020-01-01T00:00:00Z")
+
+	// ------------ Run function to test
+	updated := d.SetObjectValue("prod", "forjj", "app", "github", "token", NewValue(Internal, goforjj.NewValueStruct("secret")))
+
+	// ------------ Test result
+	assert.Falsef(updated, "Expect no update %s", testCase)
+	setOn, _ := stored.GetResource(SetOnResource)
+	assert.Equalf("2020-01-01T00:00:00Z", setOn, "Expect the date to be kept %s", testCase)
+
+	/*********************************/
+	testCase = "when the secret was set before metadata existed"
+	legacy := NewValue(Link, goforjj.NewValueStruct(""))
+	legacy.AddResource("created-on", "2018-03-04 10:11:12.123456 +0100 CET m=+0.001")
+
+	// ------------ Run function to test
+	meta, err = legacy.Metadata()
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf(2018, meta.SetOn.Year(), "Expect the legacy date %s", testCase)
+
+	/*********************************/
+	testCase = "when expiry metadata are invalid"
+
+	// ------------ Run function to test
+	err = NewValue(Internal, goforjj.NewValueStruct("")).SetExpiry("31/01/2030", "")
+	err2 := NewValue(Internal, goforjj.NewValueStruct("")).SetExpiry("", "monthly")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an invalid date %s", testCase)
+	assert.Errorf(err2, "Expect an invalid interval %s", testCase)
+}
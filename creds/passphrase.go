@@ -0,0 +1,283 @@
+package creds
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Secrets can be encrypted with a key derived from a passphrase, instead of the key file. The KDF and its
+// parameters are stored in the encrypted data header:
+//
+//   forjj-secrets:1;key=<key ID>;env=<environment>;kdf=argon2id;salt=<salt>;t=<time>;m=<memory KiB>;p=<threads>
+
+const (
+	// PassphraseEnvVar is the environment variable providing the secrets passphrase.
+	PassphraseEnvVar = "FORJJ_SECRETS_PASSPHRASE"
+
+	kdfArgon2id = "argon2id"
+	kdfSaltSize = 16
+
+	// Default argon2id parameters, as recommended by RFC 9106 for memory constrained environments.
+	kdfTime    = 3
+	kdfMemory  = 64 * 1024
+	kdfThreads = 4
+
+	// kdfMaxMemory limits the memory (KiB) a header can request.
+	kdfMaxMemory = 4 * 1024 * 1024
+)
+
+// kdfParams are the parameters to derive the secrets key from a passphrase.
+type kdfParams struct {
+	name    string
+	salt    []byte
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+// newKDFParams return default KDF parameters with a new random salt.
+func newKDFParams() (*kdfParams, error) {
+	p := &kdfParams{name: kdfArgon2id, salt: make([]byte, kdfSaltSize), time: kdfTime, memory: kdfMemory, threads: kdfThreads}
+	if _, err := rand.Read(p.salt); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// setField set a KDF parameter read from the encrypted data header.
+func (p *kdfParams) setField(key, value string) (err error) {
+	var n uint64
+	switch key {
+	case "kdf":
+		p.name = value
+	case "salt":
+		p.salt, err = base64.RawStdEncoding.DecodeString(value)
+	case "t":
+		n, err = strconv.ParseUint(value, 10, 32)
+		p.time = uint32(n)
+	case "m":
+		n, err = strconv.ParseUint(value, 10, 32)
+		p.memory = uint32(n)
+	case "p":
+		n, err = strconv.ParseUint(value, 10, 8)
+		p.threads = uint8(n)
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid KDF parameter '%s'. %s", key, err)
+	}
+	return
+}
+
+// check verify the KDF parameters are supported.
+func (p *kdfParams) check() error {
+	if p.name != kdfArgon2id {
+		return fmt.Errorf("'%s' KDF is not supported", p.name)
+	}
+	if len(p.salt) == 0 || p.time == 0 || p.memory == 0 || p.threads == 0 {
+		return fmt.Errorf("Incomplete '%s' KDF parameters", p.name)
+	}
+	if p.memory > kdfMaxMemory {
+		return fmt.Errorf("'%s' KDF memory %d KiB exceeds the %d KiB limit", p.name, p.memory, kdfMaxMemory)
+	}
+	return nil
+}
+
+// String return the header fields of the KDF parameters.
+func (p *kdfParams) String() string {
+	return fmt.Sprintf("kdf=%s;salt=%s;t=%d;m=%d;p=%d", p.name, base64.RawStdEncoding.EncodeToString(p.salt), p.time, p.memory, p.threads)
+}
+
+// equal return true if both parameters derive the same key from a passphrase.
+func (p *kdfParams) equal(other *kdfParams) bool {
+	return p != nil && other != nil && p.String() == other.String()
+}
+
+// deriveKey return the secrets key derived from the passphrase.
+func (p *kdfParams) deriveKey(passphrase []byte) ([]byte, error) {
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	return argon2.IDKey(passphrase, p.salt, p.time, p.memory, p.threads, KeySize), nil
+}
+
+// ReadPassphrase return the secrets passphrase from FORJJ_SECRETS_PASSPHRASE, or prompt for it.
+// When confirm is true, the passphrase is prompted twice.
+func ReadPassphrase(confirm bool) ([]byte, error) {
+	if passphrase := os.Getenv(PassphraseEnvVar); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, fmt.Errorf("Secrets passphrase is required. Set %s", PassphraseEnvVar)
+	}
+	return PromptPassphrase("Secrets passphrase", confirm)
+}
+
+// PromptPassphrase prompt for a passphrase on the terminal, without reading FORJJ_SECRETS_PASSPHRASE.
+// When confirm is true, the passphrase is prompted twice.
+func PromptPassphrase(prompt string, confirm bool) ([]byte, error) {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, fmt.Errorf("%s must be typed in a terminal", prompt)
+	}
+	fmt.Print(prompt + ": ")
+	passphrase, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return nil, fmt.Errorf("Passphrase read issue. %s", err)
+	}
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("Secrets passphrase can't be empty")
+	}
+	if !confirm {
+		return passphrase, nil
+	}
+	fmt.Print("Confirm " + strings.ToLower(prompt[:1]) + prompt[1:] + ": ")
+	confirmed, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return nil, fmt.Errorf("Passphrase read issue. %s", err)
+	}
+	if !bytes.Equal(passphrase, confirmed) {
+		return nil, fmt.Errorf("Passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// setPassphrase derive the key from a passphrase. If kdf is nil, new KDF parameters are generated.
+func (s *Secrets) setPassphrase(passphrase []byte, kdf *kdfParams) (err error) {
+	if kdf == nil {
+		if kdf, err = newKDFParams(); err != nil {
+			return
+		}
+	}
+	key, err := kdf.deriveKey(passphrase)
+	if err != nil {
+		return
+	}
+	s.setKey(key)
+	s.passphrase = passphrase
+	s.kdf = kdf
+	s.identity = nil
+	s.keyLoaded = true
+	return
+}
+
+// headerKey return the key to decrypt data with the header given.
+//
+// Data encrypted with a passphrase is decrypted with a key derived from the header KDF parameters, or with the
+// current key if it is the same, like a key unwrapped by a recipient.
+func (s *Secrets) headerKey(h *cipherHeader) ([]byte, error) {
+	if h.kdf == nil {
+		if s.passphrase != nil {
+			return nil, fmt.Errorf("Data encrypted with the secrets key file, not with a passphrase")
+		}
+		return s.key, nil
+	}
+	if h.kdf.equal(s.kdf) || keyID(s.key) == h.keyID {
+		return s.key, nil
+	}
+	if s.passphrase == nil {
+		return nil, fmt.Errorf("Data encrypted with a passphrase. Set %s", PassphraseEnvVar)
+	}
+	return h.kdf.deriveKey(s.passphrase)
+}
+
+// passphraseHeader return the header of the first environment secret file encrypted with a passphrase.
+// It returns nil if secrets are not encrypted with a passphrase.
+func (d *Secure) passphraseHeader() (*cipherHeader, error) {
+	envFiles, err := d.secretFileEnvs()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to find secret files. %s", err)
+	}
+	for _, file := range envFiles {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read '%s'. %s", file, err)
+		}
+		header, _, _, found, err := parseCipherHeader(data)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read '%s' header. %s", file, err)
+		}
+		if found && header.kdf != nil {
+			return header, nil
+		}
+	}
+	return nil, nil
+}
+
+// loadPassphrase derive the secrets key from the passphrase, with the KDF parameters of the header given, if any.
+func (d *Secure) loadPassphrase(header *cipherHeader) error {
+	var kdf *kdfParams
+	if header != nil {
+		kdf = header.kdf
+	}
+	passphrase, err := ReadPassphrase(kdf == nil)
+	if err != nil {
+		return err
+	}
+	if err := d.secrets.setPassphrase(passphrase, kdf); err != nil {
+		return fmt.Errorf("Unable to derive the secrets key. %s", err)
+	}
+	if header != nil && keyID(d.secrets.key) != header.keyID {
+		return fmt.Errorf("Invalid secrets passphrase")
+	}
+	return nil
+}
+
+// KeyFromPassphrase return true if the secrets key is derived from a passphrase.
+func (d *Secure) KeyFromPassphrase() bool {
+	return d != nil && d.secrets.passphrase != nil
+}
+
+// UsePassphrase re-encrypt all environments with a key derived from the passphrase, instead of the key file.
+//
+// The key file is removed, unless it is a recipient private key.
+func (d *Secure) UsePassphrase(passphrase []byte) error {
+	if d == nil {
+		return fmt.Errorf("Secure object is nil")
+	}
+	if d.secrets.passphrase != nil {
+		return fmt.Errorf("Secrets are already encrypted with a passphrase")
+	}
+	newSecrets := NewSecrets()
+	if err := newSecrets.setPassphrase(passphrase, nil); err != nil {
+		return fmt.Errorf("Unable to derive the secrets key. %s", err)
+	}
+	if err := d.replaceKey(newSecrets, false); err != nil {
+		return err
+	}
+	if d.secrets.identity != nil {
+		return nil
+	}
+	if err := os.Remove(d.key); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Secrets encrypted with the passphrase, but unable to remove the key file. Remove it. %s", err)
+	}
+	return nil
+}
+
+// UseKeyFile re-encrypt all environments with a new generated key saved in the key file, instead of a key derived
+// from a passphrase. It returns the new key as base64.
+func (d *Secure) UseKeyFile() (key64 string, _ error) {
+	if d == nil {
+		return "", fmt.Errorf("Secure object is nil")
+	}
+	if d.secrets.passphrase == nil {
+		return "", fmt.Errorf("Secrets are not encrypted with a passphrase")
+	}
+	newSecrets := NewSecrets()
+	if err := newSecrets.GenerateKey(); err != nil {
+		return "", fmt.Errorf("Unable to generate a new secrets key. %s", err)
+	}
+	if err := d.replaceKey(newSecrets, true); err != nil {
+		return "", err
+	}
+	return newSecrets.key64, nil
+}
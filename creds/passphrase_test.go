@@ -0,0 +1,154 @@
+package creds
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPassphraseHeader(t *testing.T) {
+	assert := assert.New(t)
+
+	s := NewSecrets()
+	if err := s.setPassphrase([]byte("my passphrase"), nil); err != nil {
+		t.Fatalf("Unable to derive the key. %s", err)
+	}
+	data := []byte("secret data")
+
+	/*********************************/
+	testCase := "when data is encrypted with a passphrase"
+
+	// ------------ Run function to test
+	encrypted, err := s.encrypt("prod", data)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Truef(bytes.HasPrefix(encrypted, []byte("forjj-secrets:1;key="+keyID(s.key)+";env=prod;"+s.kdf.String()+"\n")),
+		"Expect the KDF parameters in the header %s", testCase)
+
+	/*********************************/
+	testCase = "when data is decrypted with the passphrase and other KDF parameters"
+	other := NewSecrets()
+	other.setPassphrase([]byte("my passphrase"), nil)
+
+	// ------------ Run function to test
+	decrypted, _, err := other.decrypt("prod", encrypted)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf(data, decrypted, "Expect data to be decrypted %s", testCase)
+
+	/*********************************/
+	testCase = "when data is decrypted with the key file"
+	keyFile := NewSecrets()
+	keyFile.GenerateKey()
+
+	// ------------ Run function to test
+	_, _, err = keyFile.decrypt("prod", encrypted)
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when the header KDF parameters are invalid"
+
+	// ------------ Run function to test
+	_, _, err = s.decrypt("prod", bytes.Replace(encrypted, []byte(";m=65536;"), []byte(";m=999999999;"), 1))
+	_, _, err2 := s.decrypt("prod", bytes.Replace(encrypted, []byte("kdf=argon2id"), []byte("kdf=md5"), 1))
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect the memory to be limited %s", testCase)
+	assert.Errorf(err2, "Expect an unsupported KDF %s", testCase)
+}
+
+func TestPassphraseMode(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "forjj-creds")
+	defer os.RemoveAll(dir)
+	d := newTestSecure(t, dir)
+	keyFile := path.Join(dir, DefaultSecretKeyFile)
+	defer os.Unsetenv(PassphraseEnvVar)
+
+	checkLoaded := func(testCase string) {
+		loaded, err := loadTestSecure(dir, "prod")
+		if assert.NoErrorf(err, "Expect secrets to be loaded %s", testCase) {
+			value, _, _, _ := loaded.GetString("app", "github", "token")
+			assert.Equalf("prod-secret", value, "Expect the secret to be kept %s", testCase)
+		}
+	}
+
+	/*********************************/
+	testCase := "when secrets are migrated to a passphrase"
+
+	// ------------ Run function to test
+	err := d.UsePassphrase([]byte("my passphrase"))
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Truef(d.KeyFromPassphrase(), "Expect the passphrase mode %s", testCase)
+	_, err = os.Stat(keyFile)
+	assert.Truef(os.IsNotExist(err), "Expect the key file to be removed %s", testCase)
+	os.Setenv(PassphraseEnvVar, "my passphrase")
+	checkLoaded(testCase)
+
+	/*********************************/
+	testCase = "when the passphrase is wrong or missing"
+
+	// ------------ Run function to test
+	os.Setenv(PassphraseEnvVar, "wrong passphrase")
+	_, err = loadTestSecure(dir, "prod")
+	os.Unsetenv(PassphraseEnvVar)
+	_, err2 := loadTestSecure(dir, "prod")
+
+	// ------------ Test result
+	assert.EqualErrorf(err, "Invalid secrets passphrase", "Expect an error %s", testCase)
+	assert.Errorf(err2, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when the key derived from a passphrase is rotated without a new passphrase"
+	oldKey := d.secrets.Key64()
+
+	// ------------ Run function to test
+	_, err = d.RotateKey(nil)
+	_, err2 = d.RotateKey([]byte("my passphrase"))
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error without passphrase %s", testCase)
+	assert.Errorf(err2, "Expect an error with the same passphrase %s", testCase)
+	assert.Equalf(oldKey, d.secrets.Key64(), "Expect the key to be kept %s", testCase)
+
+	/*********************************/
+	testCase = "when the key derived from a passphrase is rotated"
+
+	// ------------ Run function to test
+	key64, err := d.RotateKey([]byte("my new passphrase"))
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Emptyf(key64, "Expect no key to distribute %s", testCase)
+	assert.NotEqualf(oldKey, d.secrets.Key64(), "Expect a new key %s", testCase)
+	os.Setenv(PassphraseEnvVar, "my passphrase")
+	_, err = loadTestSecure(dir, "prod")
+	assert.Errorf(err, "Expect the old passphrase to be refused %s", testCase)
+	os.Setenv(PassphraseEnvVar, "my new passphrase")
+	checkLoaded(testCase)
+
+	/*********************************/
+	testCase = "when secrets are migrated back to a key file"
+
+	// ------------ Run function to test
+	key64, err = d.UseKeyFile()
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Falsef(d.KeyFromPassphrase(), "Expect the key file mode %s", testCase)
+	data, _ := ioutil.ReadFile(keyFile)
+	assert.Equalf(key64, string(data), "Expect the key to be saved %s", testCase)
+	os.Unsetenv(PassphraseEnvVar)
+	checkLoaded(testCase)
+}
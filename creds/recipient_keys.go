@@ -0,0 +1,324 @@
+package creds
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/edwards25519"
+	"github.com/btcsuite/btcutil/bech32"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/ssh"
+)
+
+// Recipient keys wrap the secrets data key for one user.
+//
+// Supported public keys are:
+//   - age X25519 recipients ('age1...')
+//   - SSH ed25519 keys ('ssh-ed25519 ...'), converted to X25519
+//   - SSH RSA keys ('ssh-rsa ...'), with RSA-OAEP
+//
+// The matching private keys are age secret keys ('AGE-SECRET-KEY-1...') and OpenSSH or PEM private keys.
+// Passphrase protected SSH private keys are not supported, as forjj reads them without prompting.
+
+const (
+	ageRecipientHRP = "age"
+	ageIdentityHRP  = "age-secret-key-"
+	wrapLabel       = "forjj-secrets-key"
+)
+
+// recipientKey is a public key which wraps the secrets data key.
+type recipientKey interface {
+	wrap(dataKey []byte) (string, error)
+	String() string // Canonical public key text, used to identify the recipient.
+}
+
+// identityKey is a private key which unwraps the secrets data key.
+type identityKey interface {
+	unwrap(wrapped string) ([]byte, error)
+	recipient() recipientKey
+}
+
+// parseRecipientKey read a public key text.
+func parseRecipientKey(text string) (recipientKey, error) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, ageRecipientHRP+"1") {
+		text = strings.Fields(text)[0]
+		hrp, data, err := bech32Decode(text)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid age recipient. %s", err)
+		}
+		if hrp != ageRecipientHRP || len(data) != curve25519.PointSize {
+			return nil, fmt.Errorf("Invalid age recipient")
+		}
+		return newX25519Recipient(data, text), nil
+	}
+
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("Unsupported public key. Use an age recipient or a SSH ed25519 or RSA public key. %s", err)
+	}
+	return sshRecipientKey(publicKey)
+}
+
+// sshRecipientKey return the recipient key of a SSH public key.
+func sshRecipientKey(publicKey ssh.PublicKey) (recipientKey, error) {
+	text := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+	cryptoKey, ok := publicKey.(ssh.CryptoPublicKey)
+	if !ok {
+		return nil, fmt.Errorf("Unsupported SSH key type '%s'", publicKey.Type())
+	}
+	switch key := cryptoKey.CryptoPublicKey().(type) {
+	case ed25519.PublicKey:
+		point, err := ed25519PublicToX25519(key)
+		if err != nil {
+			return nil, err
+		}
+		return newX25519Recipient(point, text), nil
+	case *rsa.PublicKey:
+		return &rsaRecipient{key: key, text: text}, nil
+	}
+	return nil, fmt.Errorf("Unsupported SSH key type '%s'. Use ssh-ed25519 or ssh-rsa", publicKey.Type())
+}
+
+// parseIdentityKey read a private key. isIdentity is false if data is not a private key.
+func parseIdentityKey(data []byte) (_ identityKey, isIdentity bool, _ error) {
+	text := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(text, strings.ToUpper(ageIdentityHRP)+"1"):
+		hrp, scalar, err := bech32Decode(text)
+		if err != nil {
+			return nil, true, fmt.Errorf("Invalid age secret key. %s", err)
+		}
+		if hrp != ageIdentityHRP || len(scalar) != curve25519.ScalarSize {
+			return nil, true, fmt.Errorf("Invalid age secret key")
+		}
+		point, err := curve25519.X25519(scalar, curve25519.Basepoint)
+		if err != nil {
+			return nil, true, err
+		}
+		text, err := bech32Encode(ageRecipientHRP, point)
+		if err != nil {
+			return nil, true, err
+		}
+		return &x25519Identity{scalar: scalar, public: newX25519Recipient(point, text)}, true, nil
+	case strings.HasPrefix(text, "-----BEGIN"):
+		key, err := ssh.ParseRawPrivateKey(data)
+		if _, isProtected := err.(*ssh.PassphraseMissingError); isProtected {
+			return nil, true, fmt.Errorf("Passphrase protected SSH private keys are not supported. " +
+				"Use a SSH private key without passphrase or an age secret key")
+		}
+		if err != nil {
+			return nil, true, fmt.Errorf("Invalid SSH private key. %s", err)
+		}
+		identity, err := sshIdentityKey(key)
+		return identity, true, err
+	}
+	return nil, false, nil
+}
+
+// sshIdentityKey return the identity of a SSH private key.
+func sshIdentityKey(key interface{}) (identityKey, error) {
+	switch privateKey := key.(type) {
+	case *ed25519.PrivateKey:
+		return sshIdentityKey(*privateKey)
+	case ed25519.PrivateKey:
+		publicKey, err := ssh.NewPublicKey(privateKey.Public())
+		if err != nil {
+			return nil, err
+		}
+		public, err := sshRecipientKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		return &x25519Identity{scalar: ed25519PrivateToX25519(privateKey), public: public.(*x25519Recipient)}, nil
+	case *rsa.PrivateKey:
+		publicKey, err := ssh.NewPublicKey(&privateKey.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		public, err := sshRecipientKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		return &rsaIdentity{key: privateKey, public: public.(*rsaRecipient)}, nil
+	}
+	return nil, fmt.Errorf("Unsupported SSH private key type %T. Use ed25519 or RSA keys", key)
+}
+
+// x25519Recipient wraps the data key with a key agreed from an ephemeral X25519 key.
+//
+// The wrapped key is base64(ephemeral public key | nonce | AES-GCM(data key))
+type x25519Recipient struct {
+	point []byte
+	text  string
+}
+
+func newX25519Recipient(point []byte, text string) *x25519Recipient {
+	return &x25519Recipient{point: point, text: text}
+}
+
+func (r *x25519Recipient) String() string {
+	return r.text
+}
+
+func (r *x25519Recipient) wrap(dataKey []byte) (string, error) {
+	ephemeral := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(ephemeral); err != nil {
+		return "", err
+	}
+	ephemeralPoint, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		return "", err
+	}
+	shared, err := curve25519.X25519(ephemeral, r.point)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := x25519WrapCipher(shared, ephemeralPoint, r.point)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	wrapped := append(ephemeralPoint, nonce...)
+	wrapped = gcm.Seal(wrapped, nonce, dataKey, nil)
+	return base64.StdEncoding.EncodeToString(wrapped), nil
+}
+
+// x25519Identity is a X25519 private key, from an age secret key or a SSH ed25519 private key.
+type x25519Identity struct {
+	scalar []byte
+	public *x25519Recipient
+}
+
+func (i *x25519Identity) recipient() recipientKey {
+	return i.public
+}
+
+func (i *x25519Identity) unwrap(wrapped string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < curve25519.PointSize {
+		return nil, errors.New("wrapped key too short")
+	}
+	ephemeralPoint, data := data[:curve25519.PointSize], data[curve25519.PointSize:]
+	shared, err := curve25519.X25519(i.scalar, ephemeralPoint)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := x25519WrapCipher(shared, ephemeralPoint, i.public.point)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+// x25519WrapCipher return the cipher used to wrap the data key, derived from the shared secret.
+func x25519WrapCipher(shared, ephemeralPoint, recipientPoint []byte) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ephemeralPoint...), recipientPoint...)
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(wrapLabel)), key); err != nil {
+		return nil, err
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
+
+// rsaRecipient wraps the data key with RSA-OAEP.
+type rsaRecipient struct {
+	key  *rsa.PublicKey
+	text string
+}
+
+func (r *rsaRecipient) String() string {
+	return r.text
+}
+
+func (r *rsaRecipient) wrap(dataKey []byte) (string, error) {
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, r.key, dataKey, []byte(wrapLabel))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(wrapped), nil
+}
+
+// rsaIdentity is a SSH RSA private key.
+type rsaIdentity struct {
+	key    *rsa.PrivateKey
+	public *rsaRecipient
+}
+
+func (i *rsaIdentity) recipient() recipientKey {
+	return i.public
+}
+
+func (i *rsaIdentity) unwrap(wrapped string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return rsa.DecryptOAEP(sha256.New(), rand.Reader, i.key, data, []byte(wrapLabel))
+}
+
+// ed25519PublicToX25519 convert an ed25519 public key to the X25519 public key, as age does.
+func ed25519PublicToX25519(publicKey ed25519.PublicKey) ([]byte, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("Invalid ed25519 public key size")
+	}
+	point, err := new(edwards25519.Point).SetBytes(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid ed25519 public key. %s", err)
+	}
+	u := point.BytesMontgomery()
+	if bytes.Equal(u, make([]byte, curve25519.PointSize)) {
+		return nil, fmt.Errorf("Invalid ed25519 public key")
+	}
+	return u, nil
+}
+
+// ed25519PrivateToX25519 return the X25519 scalar of an ed25519 private key.
+func ed25519PrivateToX25519(privateKey ed25519.PrivateKey) []byte {
+	hash := sha512.Sum512(privateKey.Seed())
+	return hash[:curve25519.ScalarSize]
+}
+
+// bech32Encode encode data with the human readable part given, in lower case. See BIP 173.
+func bech32Encode(hrp string, data []byte) (string, error) {
+	values, err := bech32.ConvertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32.Encode(hrp, values)
+}
+
+// bech32Decode decode a bech32 string and return the human readable part in lower case and data.
+func bech32Decode(text string) (hrp string, data []byte, err error) {
+	hrp, values, err := bech32.Decode(text)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err = bech32.ConvertBits(values, 5, 8, false)
+	return hrp, data, err
+}
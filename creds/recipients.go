@@ -0,0 +1,176 @@
+package creds
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// DefaultRecipientsFile is the file, beside encrypted secret files, which stores the secrets key wrapped for each
+// recipient.
+const DefaultRecipientsFile = "forjj-recipients.yml"
+
+// recipientsYaml is the recipients file structure.
+type recipientsYaml struct {
+	Recipients map[string]recipientYaml `yaml:",omitempty"`
+}
+
+// recipientYaml is the secrets key wrapped with the public key of a recipient.
+type recipientYaml struct {
+	PublicKey  string `yaml:"public-key"`
+	WrappedKey string `yaml:"wrapped-key"`
+}
+
+// loadRecipients read the recipients file if not already loaded.
+func (s *Secrets) loadRecipients() error {
+	if s.recipients != nil {
+		return nil
+	}
+	s.recipients = new(recipientsYaml)
+	if s.recipientsFile == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(s.recipientsFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, s.recipients); err != nil {
+		return fmt.Errorf("Unable to read '%s'. %s", s.recipientsFile, err)
+	}
+	return nil
+}
+
+// wrapRecipients return the recipients file content with the key given wrapped for each recipient.
+func (s *Secrets) wrapRecipients(key []byte) (_ []byte, err error) {
+	if err = s.loadRecipients(); err != nil {
+		return
+	}
+	wrapped := recipientsYaml{Recipients: make(map[string]recipientYaml)}
+	for name, recipient := range s.recipients.Recipients {
+		var publicKey recipientKey
+		if publicKey, err = parseRecipientKey(recipient.PublicKey); err != nil {
+			return nil, fmt.Errorf("Recipient '%s': %s", name, err)
+		}
+		if recipient.WrappedKey, err = publicKey.wrap(key); err != nil {
+			return nil, fmt.Errorf("Unable to wrap the secrets key for '%s'. %s", name, err)
+		}
+		wrapped.Recipients[name] = recipient
+	}
+	return yaml.Marshal(wrapped)
+}
+
+// unwrapKey set the secrets key from the recipient entry of the identity given.
+func (s *Secrets) unwrapKey(identity identityKey) error {
+	if err := s.loadRecipients(); err != nil {
+		return err
+	}
+	publicKey := identity.recipient().String()
+	for name, recipient := range s.recipients.Recipients {
+		recipientKey, err := parseRecipientKey(recipient.PublicKey)
+		if err != nil || recipientKey.String() != publicKey {
+			continue
+		}
+		key, err := identity.unwrap(recipient.WrappedKey)
+		if err != nil {
+			return fmt.Errorf("Unable to unwrap the secrets key of recipient '%s'. %s", name, err)
+		}
+		if v := len(key); v != KeySize {
+			return fmt.Errorf("Invalid key of recipient '%s'. Size incorrect. must be %d. Got %d", name, KeySize, v)
+		}
+		s.setKey(key)
+		s.identity = identity
+		return nil
+	}
+	return fmt.Errorf("The private key is not a secrets recipient. Ask a recipient to add '%s' with "+
+		"'forjj secrets recipients add'", publicKey)
+}
+
+// AddRecipient wrap the secrets key for the user public key given and save the recipients file.
+//
+// An existing recipient is replaced.
+func (d *Secure) AddRecipient(user, publicKey string) error {
+	if d == nil {
+		return fmt.Errorf("Secure object is nil")
+	}
+	s := &d.secrets
+	if s.key == nil || len(s.key) != KeySize {
+		return fmt.Errorf("Unable to add a recipient. The secrets key is missing")
+	}
+	recipientKey, err := parseRecipientKey(publicKey)
+	if err != nil {
+		return err
+	}
+	wrapped, err := recipientKey.wrap(s.key)
+	if err != nil {
+		return fmt.Errorf("Unable to wrap the secrets key for '%s'. %s", user, err)
+	}
+	if err = s.loadRecipients(); err != nil {
+		return err
+	}
+	return d.saveRecipients(user, &recipientYaml{PublicKey: recipientKey.String(), WrappedKey: wrapped})
+}
+
+// RemoveRecipient remove the user from recipients and save the recipients file.
+//
+// The removed user still knows the current secrets key. The key should be rotated after.
+func (d *Secure) RemoveRecipient(user string) (found bool, _ error) {
+	if d == nil {
+		return false, fmt.Errorf("Secure object is nil")
+	}
+	if err := d.secrets.loadRecipients(); err != nil {
+		return false, err
+	}
+	if _, found = d.secrets.recipients.Recipients[user]; !found {
+		return
+	}
+	return true, d.saveRecipients(user, nil)
+}
+
+// saveRecipients set or remove (nil) a recipient and save the recipients file.
+func (d *Secure) saveRecipients(user string, recipient *recipientYaml) error {
+	recipients := recipientsYaml{Recipients: make(map[string]recipientYaml)}
+	for name, value := range d.secrets.recipients.Recipients {
+		recipients.Recipients[name] = value
+	}
+	if recipient == nil {
+		delete(recipients.Recipients, user)
+	} else {
+		recipients.Recipients[user] = *recipient
+	}
+	data, err := yaml.Marshal(recipients)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(d.secrets.recipientsFile, data, 0644); err != nil {
+		return fmt.Errorf("Unable to save recipients. %s", err)
+	}
+	d.secrets.recipients = &recipients
+	return nil
+}
+
+// Recipients return the sorted list of users the secrets key is wrapped for.
+func (d *Secure) Recipients() (users []string, _ error) {
+	if d == nil {
+		return nil, fmt.Errorf("Secure object is nil")
+	}
+	if err := d.secrets.loadRecipients(); err != nil {
+		return nil, err
+	}
+	users = make([]string, 0, len(d.secrets.recipients.Recipients))
+	for name := range d.secrets.recipients.Recipients {
+		users = append(users, name)
+	}
+	sort.Strings(users)
+	return
+}
+
+// KeyFromIdentity return true if the secrets key was unwrapped with a recipient private key.
+func (d *Secure) KeyFromIdentity() bool {
+	return d != nil && d.secrets.identity != nil
+}
@@ -0,0 +1,245 @@
+package creds
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/curve25519"
+)
+
+// newTestAgeIdentity return an age secret key and its recipient.
+func newTestAgeIdentity(t *testing.T) (secretKey, recipient string) {
+	scalar := make([]byte, curve25519.ScalarSize)
+	rand.Read(scalar)
+	point, _ := curve25519.X25519(scalar, curve25519.Basepoint)
+	secretKey, err := bech32Encode(ageIdentityHRP, scalar)
+	if err != nil {
+		t.Fatalf("Unable to encode the age secret key. %s", err)
+	}
+	recipient, _ = bech32Encode(ageRecipientHRP, point)
+	return strings.ToUpper(secretKey), recipient
+}
+
+func TestBech32(t *testing.T) {
+	assert := assert.New(t)
+
+	/*********************************/
+	testCase := "when valid bech32 strings are decoded"
+
+	for _, text := range []string{"A12UEL5L", "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw"} {
+		// ------------ Run function to test
+		_, _, err := bech32Decode(text)
+
+		// ------------ Test result
+		assert.NoErrorf(err, "Expect '%s' to be decoded %s", text, testCase)
+	}
+
+	/*********************************/
+	testCase = "when invalid bech32 strings are decoded"
+
+	for _, text := range []string{"A12UEL5l", "a12uel5m", "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxb", "1qzzfhee"} {
+		// ------------ Run function to test
+		_, _, err := bech32Decode(text)
+
+		// ------------ Test result
+		assert.Errorf(err, "Expect '%s' to fail %s", text, testCase)
+	}
+
+	/*********************************/
+	testCase = "when data is encoded and decoded"
+	data := []byte("forjj secrets key")
+
+	// ------------ Run function to test
+	text, err := bech32Encode("Test", data)
+	hrp, decoded, err2 := bech32Decode(text)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no encode error %s", testCase)
+	assert.NoErrorf(err2, "Expect no decode error %s", testCase)
+	assert.Equalf("test", hrp, "Expect the human readable part %s", testCase)
+	assert.Equalf(data, decoded, "Expect the data %s", testCase)
+}
+
+func TestEd25519ToX25519(t *testing.T) {
+	assert := assert.New(t)
+
+	/*********************************/
+	testCase := "when an ed25519 key pair is converted"
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	// ------------ Run function to test
+	point, err := ed25519PublicToX25519(publicKey)
+	scalar := ed25519PrivateToX25519(privateKey)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	expected, _ := curve25519.X25519(scalar, curve25519.Basepoint)
+	assert.Equalf(expected, point, "Expect the X25519 public key to match the private key %s", testCase)
+}
+
+func TestRecipientKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	ageSecretKey, _ := newTestAgeIdentity(t)
+	ageIdentity, _, _ := parseIdentityKey([]byte(ageSecretKey + "\n"))
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	ed25519Identity, _ := sshIdentityKey(ed25519Key)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rsaPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	rsaIdentity, _, _ := parseIdentityKey(rsaPem)
+
+	identities := map[string]identityKey{"age": ageIdentity, "ssh-ed25519": ed25519Identity, "ssh-rsa": rsaIdentity}
+	dataKey := make([]byte, KeySize)
+	rand.Read(dataKey)
+
+	for name, identity := range identities {
+		/*********************************/
+		testCase := "when the key is wrapped for a " + name + " recipient"
+		if !assert.NotNilf(identity, "Expect the identity to be parsed %s", testCase) {
+			continue
+		}
+		assert.Truef(strings.HasPrefix(identity.recipient().String(), strings.Split(name, "-")[0]),
+			"Expect the recipient format %s", testCase)
+
+		// ------------ Run function to test
+		recipient, err := parseRecipientKey(identity.recipient().String() + " user@host")
+		var wrapped string
+		if err == nil {
+			wrapped, err = recipient.wrap(dataKey)
+		}
+
+		// ------------ Test result
+		if !assert.NoErrorf(err, "Expect no error %s", testCase) {
+			continue
+		}
+		assert.Equalf(identity.recipient().String(), recipient.String(), "Expect the canonical public key %s", testCase)
+		key, err := identity.unwrap(wrapped)
+		assert.NoErrorf(err, "Expect the key to be unwrapped %s", testCase)
+		assert.Equalf(dataKey, key, "Expect the data key %s", testCase)
+		for otherName, other := range identities {
+			if otherName != name {
+				_, err = other.unwrap(wrapped)
+				assert.Errorf(err, "Expect %s identity to fail %s", otherName, testCase)
+			}
+		}
+	}
+
+	/*********************************/
+	testCase := "when keys are not supported"
+
+	// ------------ Run function to test
+	_, err := parseRecipientKey("ssh-dss AAAA")
+	_, isIdentity, _ := parseIdentityKey([]byte("bm90IGEga2V5"))
+	_, isIdentity2, err2 := parseIdentityKey([]byte("AGE-SECRET-KEY-1INVALID"))
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an unsupported public key %s", testCase)
+	assert.Falsef(isIdentity, "Expect a base64 key to not be a private key %s", testCase)
+	assert.Truef(isIdentity2, "Expect an age secret key to be detected %s", testCase)
+	assert.Errorf(err2, "Expect an invalid age secret key %s", testCase)
+
+	/*********************************/
+	testCase = "when the SSH private key is protected by a passphrase"
+	block, _ := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), []byte("passphrase"), x509.PEMCipherAES256)
+
+	// ------------ Run function to test
+	_, isIdentity, err = parseIdentityKey(pem.EncodeToMemory(block))
+
+	// ------------ Test result
+	assert.Truef(isIdentity, "Expect a private key to be detected %s", testCase)
+	assert.EqualErrorf(err, "Passphrase protected SSH private keys are not supported. "+
+		"Use a SSH private key without passphrase or an age secret key", "Expect a passphrase protected key to be rejected %s", testCase)
+}
+
+func TestRecipients(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "forjj-creds")
+	defer os.RemoveAll(dir)
+	d := newTestSecure(t, dir)
+	aliceKey, aliceRecipient := newTestAgeIdentity(t)
+	bobKey, bobRecipient := newTestAgeIdentity(t)
+	keyFile := path.Join(dir, DefaultSecretKeyFile)
+
+	/*********************************/
+	testCase := "when recipients are added"
+
+	// ------------ Run function to test
+	err := d.AddRecipient("alice", aliceRecipient)
+	err2 := d.AddRecipient("bob", bobRecipient)
+	err3 := d.AddRecipient("carol", "not a key")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.NoErrorf(err2, "Expect no error %s", testCase)
+	assert.Errorf(err3, "Expect an invalid key to fail %s", testCase)
+	users, _ := d.Recipients()
+	assert.Equalf([]string{"alice", "bob"}, users, "Expect recipients %s", testCase)
+
+	/*********************************/
+	testCase = "when the key file is a recipient private key"
+	ioutil.WriteFile(keyFile, []byte(aliceKey+"\n"), 0600)
+
+	// ------------ Run function to test
+	loaded, err := loadTestSecure(dir, "prod")
+
+	// ------------ Test result
+	if assert.NoErrorf(err, "Expect no error %s", testCase) {
+		value, _, _, _ := loaded.GetString("app", "github", "token")
+		assert.Equalf("prod-secret", value, "Expect the secret to be decrypted %s", testCase)
+		assert.Truef(loaded.KeyFromIdentity(), "Expect the key to be unwrapped %s", testCase)
+	}
+
+	/*********************************/
+	testCase = "when the key is rotated with a recipient private key"
+
+	// ------------ Run function to test
+	_, err = loaded.RotateKey(nil)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	data, _ := ioutil.ReadFile(keyFile)
+	assert.Equalf(aliceKey+"\n", string(data), "Expect the private key file to be kept %s", testCase)
+	ioutil.WriteFile(keyFile, []byte(bobKey), 0600)
+	loaded, err = loadTestSecure(dir, "test")
+	if assert.NoErrorf(err, "Expect other recipients to get the new key %s", testCase) {
+		value, _, _, _ := loaded.GetString("app", "github", "token")
+		assert.Equalf("test-secret", value, "Expect the secret to be decrypted %s", testCase)
+	}
+
+	/*********************************/
+	testCase = "when a recipient is removed"
+
+	// ------------ Run function to test
+	found, err := loaded.RemoveRecipient("alice")
+	notFound, _ := loaded.RemoveRecipient("alice")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Truef(found, "Expect the recipient to be found %s", testCase)
+	assert.Falsef(notFound, "Expect the recipient to be removed %s", testCase)
+	ioutil.WriteFile(keyFile, []byte(aliceKey), 0600)
+	_, err = loadTestSecure(dir, "prod")
+	assert.Errorf(err, "Expect the removed recipient to fail %s", testCase)
+
+	/*********************************/
+	testCase = "when the key file is missing and recipients exist"
+	os.Remove(keyFile)
+
+	// ------------ Run function to test
+	_, err = loadTestSecure(dir, "prod")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+	_, statErr := os.Stat(keyFile)
+	assert.Truef(os.IsNotExist(statErr), "Expect no new key to be generated %s", testCase)
+}
@@ -0,0 +1,118 @@
+package creds
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Every secure value read from Secure is registered in a redactor, so forjj can mask it in messages it displays:
+// log output, traces and plugins status or error messages. Redaction is enabled by default.
+//
+// gotrace messages (Trace, Info, Warning and Error), which carry resolvers and plugins errors, are written with
+// the standard log package. So, `log.SetOutput(creds.RedactWriter(os.Stderr))` masks them as well.
+// TestRedactGotrace checks it.
+
+const (
+	// RedactEnvVar is the environment variable to disable redaction, when set to 'false'.
+	RedactEnvVar = "FORJJ_REDACT"
+
+	redactMask = "*****"
+
+	// redactMinLength is the minimum length of a secure value to redact. Shorter values would mask too much output.
+	redactMinLength = 4
+)
+
+// redactor masks secure values in messages.
+type redactor struct {
+	mutex    sync.RWMutex
+	disabled bool
+	values   map[string]bool
+	replacer *strings.Replacer
+}
+
+var secureRedactor = newRedactor()
+
+func newRedactor() *redactor {
+	return &redactor{values: make(map[string]bool)}
+}
+
+// register add a secure value to redact. Each line of a multi-line value is redacted as well, as messages are
+// often displayed line by line.
+func (r *redactor) register(value string) {
+	values := append([]string{value}, strings.Split(value, "\n")...)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	updated := false
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) < redactMinLength || r.values[v] {
+			continue
+		}
+		r.values[v] = true
+		updated = true
+	}
+	if !updated {
+		return
+	}
+
+	// Longest values first, so a value containing another one is fully masked.
+	sorted := make([]string, 0, len(r.values))
+	for v := range r.values {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	oldNew := make([]string, 0, 2*len(sorted))
+	for _, v := range sorted {
+		oldNew = append(oldNew, v, redactMask)
+	}
+	r.replacer = strings.NewReplacer(oldNew...)
+}
+
+// redact return the message with secure values masked.
+func (r *redactor) redact(message string) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if r.disabled || r.replacer == nil {
+		return message
+	}
+	return r.replacer.Replace(message)
+}
+
+// SetRedaction enable or disable secure values redaction.
+func SetRedaction(enabled bool) {
+	secureRedactor.mutex.Lock()
+	defer secureRedactor.mutex.Unlock()
+	secureRedactor.disabled = !enabled
+}
+
+// Redact return the message with all secure values read from Secure masked.
+func Redact(message string) string {
+	return secureRedactor.redact(message)
+}
+
+// redactWriter is an io.Writer masking secure values.
+type redactWriter struct {
+	w io.Writer
+}
+
+// RedactWriter return an io.Writer masking secure values written to w, like `log.SetOutput(creds.RedactWriter(os.Stderr))`.
+//
+// Secure values are masked in each Write call, so a message must be written at once, as log does.
+func RedactWriter(w io.Writer) io.Writer {
+	return &redactWriter{w: w}
+}
+
+func (rw *redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,103 @@
+package creds
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/forj-oss/forjj-modules/trace"
+	"github.com/forj-oss/goforjj"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor(t *testing.T) {
+	assert := assert.New(t)
+
+	r := newRedactor()
+
+	/*********************************/
+	testCase := "when no secure value is registered"
+
+	// ------------ Run function to test
+	ret := r.redact("token=my-token")
+
+	// ------------ Test result
+	assert.Equalf("token=my-token", ret, "Expect message unchanged %s", testCase)
+
+	/*********************************/
+	testCase = "when secure values are registered"
+
+	// ------------ Run function to test
+	r.register("my-token")
+	r.register("my-token-long")
+	r.register("abc")
+	ret = r.redact("token=my-token, long=my-token-long, short=abc")
+
+	// ------------ Test result
+	assert.Equalf("token=*****, long=*****, short=abc", ret, "Expect secure values masked, except short ones %s", testCase)
+
+	/*********************************/
+	testCase = "when a multi-line secure value is registered"
+
+	// ------------ Run function to test
+	r.register("-----BEGIN KEY-----\nc2VjcmV0LWtleQ==\n-----END KEY-----")
+	ret = r.redact("Unable to use key c2VjcmV0LWtleQ==")
+
+	// ------------ Test result
+	assert.Equalf("Unable to use key *****", ret, "Expect each line masked %s", testCase)
+
+	/*********************************/
+	testCase = "when redaction is disabled"
+	r.disabled = true
+
+	// ------------ Run function to test
+	ret = r.redact("token=my-token")
+
+	// ------------ Test result
+	assert.Equalf("token=my-token", ret, "Expect message unchanged %s", testCase)
+}
+
+func TestRedactWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	/*********************************/
+	testCase := "when a secure value read is logged"
+	buf := new(bytes.Buffer)
+	logger := log.New(RedactWriter(buf), "", 0)
+	v := NewValue(Internal, goforjj.NewValueStruct("redact-writer-secret"))
+
+	// ------------ Run function to test
+	secret, err := v.GetString()
+	logger.Printf("plugin error: invalid password '%s'", secret)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf("plugin error: invalid password '*****'\n", buf.String(), "Expect the secure value masked %s", testCase)
+}
+
+func TestRedactGotrace(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := new(bytes.Buffer)
+	defer log.SetOutput(os.Stderr)
+	defer log.SetFlags(log.Flags())
+	log.SetOutput(RedactWriter(buf))
+	log.SetFlags(0)
+	v := NewValue(Internal, goforjj.NewValueStruct("redact-gotrace-secret"))
+
+	/*********************************/
+	testCase := "when a secure value read is traced by gotrace"
+
+	// ------------ Run function to test
+	secret, err := v.GetString()
+	gotrace.Error("resolver error: '%s' refused", secret)
+	gotrace.Warning("plugin warning: '%s' refused", secret)
+	gotrace.Info("plugin info: '%s' refused", secret)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.NotContainsf(buf.String(), secret, "Expect the secure value not displayed %s", testCase)
+	assert.Equalf(3, strings.Count(buf.String(), "'*****' refused"), "Expect the secure value masked in each message %s", testCase)
+}
@@ -0,0 +1,132 @@
+package creds
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// secretFileEnvs return the encrypted secret files found in the secure path, per environment.
+func (d *Secure) secretFileEnvs() (files map[string]string, err error) {
+	found, err := filepath.Glob(path.Join(d.defaultPath, "*"+DefaultSecretFile))
+	if err != nil {
+		return
+	}
+	files = make(map[string]string)
+	for _, file := range found {
+		name := path.Base(file)
+		switch {
+		case name == DefaultSecretFile:
+			files[Global] = file
+		case strings.HasSuffix(name, "-"+DefaultSecretFile):
+			files[strings.TrimSuffix(name, "-"+DefaultSecretFile)] = file
+		}
+	}
+	return
+}
+
+// RotateKey replace the secrets key by a new generated one, and return it as base64.
+//
+// Every environment secret file is decrypted with the current key, then encrypted with the new key.
+// Encrypted files, the key file and the recipients file are saved all together or not at all.
+// If the key was unwrapped with a recipient private key, the key file is kept and the new key is only wrapped for
+// recipients.
+// If the key is derived from a passphrase, the new key is derived from newPassphrase, which must be different from
+// the current one, and nothing is returned. Otherwise, newPassphrase is ignored.
+func (d *Secure) RotateKey(newPassphrase []byte) (key64 string, _ error) {
+	if d == nil {
+		return "", fmt.Errorf("Secure object is nil")
+	}
+	if d.secrets.key == nil || len(d.secrets.key) != KeySize {
+		return "", fmt.Errorf("Unable to rotate the secrets key. The current key is missing")
+	}
+
+	if d.secrets.kdf != nil && d.secrets.passphrase == nil {
+		return "", fmt.Errorf("Unable to rotate the secrets key derived from a passphrase without the passphrase. Set %s", PassphraseEnvVar)
+	}
+
+	newSecrets := NewSecrets()
+	if d.secrets.passphrase != nil {
+		if len(newPassphrase) == 0 {
+			return "", fmt.Errorf("Unable to rotate the secrets key derived from a passphrase without a new passphrase")
+		}
+		if bytes.Equal(newPassphrase, d.secrets.passphrase) {
+			return "", fmt.Errorf("Unable to rotate the secrets key. The new passphrase must be different from the current one")
+		}
+		if err := newSecrets.setPassphrase(newPassphrase, nil); err != nil {
+			return "", fmt.Errorf("Unable to derive a new secrets key. %s", err)
+		}
+	} else if err := newSecrets.GenerateKey(); err != nil {
+		return "", fmt.Errorf("Unable to generate a new secrets key. %s", err)
+	}
+
+	saveKey := d.secrets.identity == nil && d.secrets.passphrase == nil
+	if err := d.replaceKey(newSecrets, saveKey); err != nil {
+		return "", err
+	}
+	if !saveKey {
+		return "", nil
+	}
+	return newSecrets.key64, nil
+}
+
+// replaceKey re-encrypt every environment secret file with the newSecrets key, and wrap it for recipients.
+// If saveKey is true, the new key is saved in the key file.
+//
+// Files are saved all together or not at all. On success, the new key is used.
+func (d *Secure) replaceKey(newSecrets *Secrets, saveKey bool) error {
+	envFiles, err := d.secretFileEnvs()
+	if err != nil {
+		return fmt.Errorf("Unable to find secret files. %s", err)
+	}
+	envs := make([]string, 0, len(envFiles))
+	for env := range envFiles {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+
+	files := make(map[string]atomicFile)
+	for _, env := range envs {
+		file := envFiles[env]
+		ciphertext, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("Unable to read '%s'. %s", file, err)
+		}
+		secretData, _, err := d.secrets.decrypt(env, ciphertext)
+		if err != nil {
+			return fmt.Errorf("Unable to decrypt '%s' environment secrets with the current key. %s", env, err)
+		}
+		if ciphertext, err = newSecrets.encrypt(env, secretData); err != nil {
+			return fmt.Errorf("Unable to encrypt '%s' environment secrets with the new key. %s", env, err)
+		}
+		files[file] = atomicFile{data: ciphertext, perm: 0644}
+	}
+	if saveKey {
+		files[d.key] = atomicFile{data: []byte(newSecrets.key64), perm: 0600}
+	}
+	if err := d.secrets.loadRecipients(); err != nil {
+		return err
+	}
+	if len(d.secrets.recipients.Recipients) > 0 {
+		data, err := d.secrets.wrapRecipients(newSecrets.key)
+		if err != nil {
+			return err
+		}
+		files[d.secrets.recipientsFile] = atomicFile{data: data, perm: 0644}
+	}
+
+	if err := writeFilesAtomic(files); err != nil {
+		return fmt.Errorf("Secrets key not replaced. %s", err)
+	}
+
+	d.secrets.key = newSecrets.key
+	d.secrets.key64 = newSecrets.key64
+	d.secrets.passphrase = newSecrets.passphrase
+	d.secrets.kdf = newSecrets.kdf
+	d.secrets.recipients = nil
+	return nil
+}
@@ -0,0 +1,137 @@
+package creds
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestSecure creates secrets files for global, prod and test environments in a directory.
+func newTestSecure(t *testing.T, dir string) *Secure {
+	d := new(Secure)
+	d.InitEnvDefaults(dir, "prod")
+	d.SetDefaultFile("test")
+	if err := d.EncryptAll(true); err != nil {
+		t.Fatalf("Unable to initialize secrets. %s", err)
+	}
+	for _, env := range []string{Global, "prod", "test"} {
+		d.SetObjectValue(env, "forjj", "app", "github", "token", NewValue("forjj", goforjj.NewValueStruct(env+"-secret")))
+	}
+	if err := d.Save(); err != nil {
+		t.Fatalf("Unable to save secrets. %s", err)
+	}
+	return d
+}
+
+// loadTestSecure load secrets files of an environment from a directory.
+func loadTestSecure(dir, env string) (*Secure, error) {
+	d := new(Secure)
+	d.InitEnvDefaults(dir, env)
+	if err := d.EncryptAll(true); err != nil {
+		return nil, err
+	}
+	return d, d.Load()
+}
+
+func TestRotateKey(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "forjj-creds")
+	defer os.RemoveAll(dir)
+	d := newTestSecure(t, dir)
+	oldKey := d.secrets.Key64()
+
+	/*********************************/
+	testCase := "when the key is rotated"
+
+	// ------------ Run function to test
+	newKey, err := d.RotateKey(nil)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.NotEqualf(oldKey, newKey, "Expect a new key %s", testCase)
+	assert.Equalf(newKey, d.secrets.Key64(), "Expect the new key to be used %s", testCase)
+	data, _ := ioutil.ReadFile(path.Join(dir, DefaultSecretKeyFile))
+	assert.Equalf(newKey, string(data), "Expect the new key to be saved %s", testCase)
+	for _, env := range []string{"prod", "test"} {
+		loaded, err := loadTestSecure(dir, env)
+		if assert.NoErrorf(err, "Expect '%s' to be loaded with the new key %s", env, testCase) {
+			value, found, _, _ := loaded.GetString("app", "github", "token")
+			assert.Truef(found, "Expect '%s' secret to be found %s", env, testCase)
+			assert.Equalf(env+"-secret", value, "Expect '%s' secret to be kept %s", env, testCase)
+			value, _, _, _ = loaded.GetGlobalString("app", "github", "token")
+			assert.Equalf("global-secret", value, "Expect global secret to be kept %s", testCase)
+		}
+	}
+
+	/*********************************/
+	testCase = "when an environment can't be decrypted"
+
+	testFile := d.DefineDefaultSecretFileName(dir, "test")
+	ioutil.WriteFile(testFile, []byte("corrupted data encrypted with another key"), 0644)
+	prodFile := d.DefineDefaultSecretFileName(dir, "prod")
+	prodData, _ := ioutil.ReadFile(prodFile)
+	currentKey := d.secrets.Key64()
+
+	// ------------ Run function to test
+	_, err = d.RotateKey(nil)
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+	assert.Equalf(currentKey, d.secrets.Key64(), "Expect the key to be kept %s", testCase)
+	data, _ = ioutil.ReadFile(path.Join(dir, DefaultSecretKeyFile))
+	assert.Equalf(currentKey, string(data), "Expect the key file to be kept %s", testCase)
+	data, _ = ioutil.ReadFile(prodFile)
+	assert.Equalf(prodData, data, "Expect other environments to be kept %s", testCase)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Lenf(files, 4, "Expect no temporary files left %s", testCase)
+}
+
+func TestWriteFilesAtomic(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "forjj-creds")
+	defer os.RemoveAll(dir)
+	first := path.Join(dir, "first")
+	ioutil.WriteFile(first, []byte("old"), 0644)
+
+	/*********************************/
+	testCase := "when a file can't be written"
+
+	// ------------ Run function to test
+	err := writeFilesAtomic(map[string]atomicFile{
+		first:                               {data: []byte("new"), perm: 0644},
+		path.Join(dir, "missing", "second"): {data: []byte("new"), perm: 0644},
+	})
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+	data, _ := ioutil.ReadFile(first)
+	assert.Equalf("old", string(data), "Expect the file to be kept %s", testCase)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Lenf(files, 1, "Expect no temporary files left %s", testCase)
+
+	/*********************************/
+	testCase = "when all files are written"
+
+	// ------------ Run function to test
+	err = writeFilesAtomic(map[string]atomicFile{
+		first:                    {data: []byte("new"), perm: 0644},
+		path.Join(dir, "second"): {data: []byte("new"), perm: 0600},
+	})
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	data, _ = ioutil.ReadFile(first)
+	assert.Equalf("new", string(data), "Expect the file to be updated %s", testCase)
+	info, _ := os.Stat(path.Join(dir, "second"))
+	if assert.NotNilf(info, "Expect the file to be created %s", testCase) {
+		assert.Equalf(os.FileMode(0600), info.Mode().Perm(), "Expect the file permissions %s", testCase)
+	}
+	files, _ = ioutil.ReadDir(dir)
+	assert.Lenf(files, 2, "Expect no backup files left %s", testCase)
+}
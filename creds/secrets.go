@@ -23,6 +23,18 @@ type Secrets struct {
 	key64     string
 	Envs      map[string]*yamlSecure `yaml:";inline"`
 
+	// Secrets key wrapped for each recipient, and the recipient private key used to unwrap it, if any.
+	recipientsFile string
+	recipients     *recipientsYaml
+	identity       identityKey
+
+	// Passphrase and KDF parameters the key is derived from, if any.
+	passphrase []byte
+	kdf        *kdfParams
+
+	// cipherUpgrade is true when data without header can be decrypted, to be upgraded. See Secure.Load
+	cipherUpgrade bool
+
 	// handlers to set or get
 	setter map[string]func(v *Value, value *goforjj.ValueStruct) error
 	getter map[string]func(v *YamlValue) (string, error)
@@ -30,6 +42,9 @@ type Secrets struct {
 
 const KeySize = 32
 
+// KeyEnvVar is the environment variable providing the base64 secrets key.
+const KeyEnvVar = "FORJJ_SECRETS_KEY"
+
 // NewSecrets creates the internal secret object to shared with CI running infra/deploy repositories.
 func NewSecrets() (ret *Secrets) {
 	ret = new(Secrets)
@@ -61,12 +76,20 @@ func (s *Secrets) GenerateKey() error {
 	if _, err := rand.Read(key); err != nil {
 		return err
 	}
-	s.key = key
-	s.key64 = base64.StdEncoding.EncodeToString(key)
+	s.setKey(key)
+	s.identity = nil
+	s.passphrase = nil
+	s.kdf = nil
 
 	return nil
 }
 
+// setKey set the key and its base64 representation.
+func (s *Secrets) setKey(key []byte) {
+	s.key = key
+	s.key64 = base64.StdEncoding.EncodeToString(key)
+}
+
 func (s *Secrets) SetKey64(key64 string) (err error) {
 	s.key64 = string(key64)
 	s.key, err = base64.StdEncoding.DecodeString(s.key64)
@@ -88,17 +111,13 @@ func (s *Secrets) SaveKey(file string) error {
 		return fmt.Errorf("Key is missing")
 	}
 
-	fd, err := os.Create(file)
-	if err != nil {
-		return err
-	}
-	defer fd.Close()
-
-	_, err = fd.WriteString(s.key64)
-	return err
+	return writeFileAtomic(file, []byte(s.key64), 0600)
 }
 
 // ReadKey read a file containing the key
+//
+// The file can contain the key in base64, or the private key of a recipient. In that case, the key is unwrapped
+// from the recipients file. A passphrase protected SSH private key is rejected.
 func (s *Secrets) ReadKey(file string) error {
 	if s == nil {
 		return fmt.Errorf("Secret object is nil")
@@ -115,6 +134,12 @@ func (s *Secrets) ReadKey(file string) error {
 		return err
 	}
 	s.keyLoaded = true
+	if identity, isIdentity, err := parseIdentityKey(key64); isIdentity {
+		if err != nil {
+			return fmt.Errorf("Invalid private key '%s'. %s", file, err)
+		}
+		return s.unwrapKey(identity)
+	}
 	return s.SetKey64(string(key64))
 }
 
@@ -138,7 +163,7 @@ func (s *Secrets) Export() (_ []byte, err error) {
 	// To show the content of the secret yaml data. Do not uncomment for a final production code.
 	//gotrace.Test("%s\n", string(secretData))
 
-	return s.encrypt(secretData)
+	return s.encrypt("", secretData)
 }
 
 // ExportEnv provides an extraction of an Env given encrypted.
@@ -154,12 +179,12 @@ func (s *Secrets) ExportEnv(env *yamlSecure) (_ []byte, err error) {
 		return
 	}
 
-	return s.encrypt(secretData)
+	return s.encrypt(env.env, secretData)
 }
 
 // Import read an encrypted data, decrypt it and save it in Secrets
 func (s *Secrets) Import(ciphertext []byte) error {
-	secretData, err := s.decrypt(ciphertext)
+	secretData, _, err := s.decrypt("", ciphertext)
 	if err != nil {
 		return err
 	}
@@ -168,15 +193,18 @@ func (s *Secrets) Import(ciphertext []byte) error {
 }
 
 // ImportToEnv read an encrypted data, decrypt it and save it in the given Env.
+//
+// Data encrypted without header is flagged to be upgraded.
 func (s *Secrets) ImportToEnv(ciphertext []byte, env *yamlSecure) error {
 	if env == nil {
 		return fmt.Errorf("Env object given is nil")
 	}
-	secretData, err := s.decrypt(ciphertext)
+	secretData, legacy, err := s.decrypt(env.env, ciphertext)
 
 	if err != nil {
 		return err
 	}
+	env.legacyCipher = legacy
 
 	// To show the content of the secret yaml data. Do not uncomment for a final production code.
 	//gotrace.Test("%s\n", string(secretData))
@@ -184,7 +212,8 @@ func (s *Secrets) ImportToEnv(ciphertext []byte, env *yamlSecure) error {
 	return yaml.Unmarshal(secretData, env)
 }
 
-func (s *Secrets) encrypt(secretData []byte) ([]byte, error) {
+// encrypt secretData for the environment given. The header is authenticated as additional data.
+func (s *Secrets) encrypt(env string, secretData []byte) ([]byte, error) {
 	if s == nil {
 		return nil, fmt.Errorf("Secret object is nil")
 	}
@@ -207,37 +236,59 @@ func (s *Secrets) encrypt(secretData []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	return gcm.Seal(nonce, nonce, secretData, nil), nil
+	header := (&cipherHeader{version: CipherVersion, keyID: keyID(s.key), env: env, kdf: s.kdf}).bytes()
+	return gcm.Seal(append(header, nonce...), nonce, secretData, header), nil
 }
 
-func (s *Secrets) decrypt(ciphertext []byte) (secretData []byte, _ error) {
+// decrypt data encrypted for the environment given.
+// legacy is true if the data has no header, ie encrypted before the header was introduced. This data is
+// refused, except to be upgraded.
+func (s *Secrets) decrypt(env string, data []byte) (secretData []byte, legacy bool, _ error) {
 	if s == nil {
 		err := fmt.Errorf("Secret object is nil")
-		return nil, err
+		return nil, false, err
 	}
 	if s.key == nil || len(s.key) != KeySize {
 		err := fmt.Errorf("Key is missing")
-		return nil, err
+		return nil, false, err
 	}
 
-	c, err := aes.NewCipher(s.key)
+	header, additionalData, ciphertext, found, err := parseCipherHeader(data)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	key := s.key
+	if found {
+		if key, err = s.headerKey(header); err != nil {
+			return nil, false, err
+		}
+		if err = header.check(env, key); err != nil {
+			return nil, false, err
+		}
+	} else if !s.cipherUpgrade {
+		err = fmt.Errorf("Encrypted data without header refused. It is not bound to an environment")
+		return nil, false, err
+	}
+
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, false, err
 	}
 
 	gcm, err := cipher.NewGCM(c)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	nonceSize := gcm.NonceSize()
 	if len(ciphertext) < nonceSize {
-		return nil, errors.New("ciphertext too short")
+		return nil, false, errors.New("ciphertext too short")
 	}
 
 	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
 
-	return gcm.Open(nil, nonce, ciphertext, nil)
+	secretData, err = gcm.Open(nil, nonce, ciphertext, additionalData)
+	return secretData, !found, err
 }
 
 // SetSetterHandler set setter types like 'link'
@@ -2,8 +2,10 @@ package creds
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"sort"
 
 	"github.com/forj-oss/forjj-modules/trace"
 	"github.com/forj-oss/goforjj"
@@ -28,6 +30,7 @@ const (
 	DefaultCredsFile     = "forjj-creds.yml"
 	DefaultSecretFile    = "forjj.enc"
 	DefaultSecretKeyFile = ".forjj.key"
+	DefaultCipherFile    = ".forjj.cipher"
 	Global               = "global"
 )
 
@@ -43,9 +46,61 @@ func (d *Secure) Upgrade(v0Func func(*Secure, string) error) (_ error) {
 	if err := v0Func(d, "V0.1"); err != nil { // Upgrade from V0 to V0.1
 		return fmt.Errorf("Unable top upgrade credential data. %s", err)
 	}
+	// Encrypted files without ciphertext header are encrypted again with the environment bound.
+	upgraded := false
+	for _, env := range d.secrets.Envs {
+		if !env.legacyCipher {
+			continue
+		}
+		if err := env.save(true); err != nil {
+			return fmt.Errorf("Unable to upgrade '%s' encrypted data format. %s", env.credFile, err)
+		}
+		env.legacyCipher = false
+		upgraded = true
+		gotrace.Info("Secret file '%s' upgraded to encrypted data format version %d.", env.credFile, CipherVersion)
+	}
+	if upgraded {
+		// From now, data without header is refused.
+		cipherFile := path.Join(d.defaultPath, DefaultCipherFile)
+		if err := writeFileAtomic(cipherFile, []byte(fmt.Sprintf("%s%d\n", cipherHeaderPrefix, CipherVersion)), 0644); err != nil {
+			return fmt.Errorf("Unable to save '%s'. %s", cipherFile, err)
+		}
+	}
 	return
 }
 
+// cipherUpgradable return true if secret files encrypted without header can be loaded to be upgraded.
+//
+// Once a secret file has been upgraded, or if a secret file has a header, data without header is refused. So an
+// old file of another environment can't replace an environment file.
+func (d *Secure) cipherUpgradable() bool {
+	if _, err := os.Stat(path.Join(d.defaultPath, DefaultCipherFile)); err == nil || !os.IsNotExist(err) {
+		return false
+	}
+	files, err := d.secretFileEnvs()
+	if err != nil {
+		return false
+	}
+	for _, env := range d.secrets.Envs {
+		files[env.env] = env.credFile
+	}
+	for _, file := range files {
+		fd, err := os.Open(file)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return false
+		}
+		prefix := make([]byte, len(cipherHeaderPrefix))
+		n, _ := io.ReadFull(fd, prefix)
+		fd.Close()
+		if string(prefix[:n]) == cipherHeaderPrefix {
+			return false
+		}
+	}
+	return true
+}
+
 // IsLoaded return true if the env file were loaded. successfully.
 func (d *Secure) IsLoaded(env string) (_ bool) {
 	if d == nil {
@@ -94,11 +149,29 @@ func (d *Secure) DirName(env string) (_ string) {
 //
 // If error is found, the function exit.
 func (d *Secure) EncryptAll(encrypt bool) error {
-	if _, err := os.Stat(d.key); err != nil && encrypt {
+	header, err := d.passphraseHeader()
+	if err != nil {
+		return err
+	}
+	_, keyErr := os.Stat(d.key)
+	if keyErr != nil && encrypt && (header != nil || os.Getenv(PassphraseEnvVar) != "") {
+		if err := d.loadPassphrase(header); err != nil {
+			return err
+		}
+	} else if keyErr != nil && encrypt {
+		if err := d.secrets.loadRecipients(); err != nil {
+			return err
+		}
+		if len(d.secrets.recipients.Recipients) > 0 {
+			return fmt.Errorf("The secrets key is wrapped for recipients. Save your private key in '%s'", d.key)
+		}
 		d.secrets.GenerateKey()
 		d.secrets.SaveKey(d.key)
-	} else {
-		d.secrets.ReadKey(d.key)
+	} else if err := d.secrets.ReadKey(d.key); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Unable to read the secrets key. %s", err)
+	} else if err == nil && header != nil && keyID(d.secrets.key) == header.keyID {
+		// The key derived from a passphrase was unwrapped by a recipient. Keep the KDF parameters in headers.
+		d.secrets.kdf = header.kdf
 	}
 
 	if !encrypt {
@@ -136,6 +209,12 @@ func (d *Secure) Load() error {
 	}
 	inError := false
 
+	// Files without header are loaded only to be upgraded by Upgrade.
+	d.secrets.cipherUpgrade = d.cipherUpgradable()
+	defer func() {
+		d.secrets.cipherUpgrade = false
+	}()
+
 	for key, env := range d.secrets.Envs {
 		if err := env.load(key, true); err != nil {
 			gotrace.Error("%s", err)
@@ -191,6 +270,8 @@ func (d *Secure) InitEnvDefaults(aPath, env string) {
 	}
 	d.defaultPath = aPath
 	d.key = path.Join(aPath, DefaultSecretKeyFile)
+	d.secrets.recipientsFile = path.Join(aPath, DefaultRecipientsFile)
+	d.secrets.recipients = nil
 	d.secrets.Envs = make(map[string]*yamlSecure)
 	for _, curEnv := range []string{Global, env} {
 		d.SetDefaultFile(curEnv)
@@ -230,6 +311,7 @@ func (d *Secure) SetDefaultFile(env string) {
 		file:      path.Clean(d.DefineDefaultCredFileName(d.defaultPath, env)),
 		credFile:  path.Clean(d.DefineDefaultSecretFileName(d.defaultPath, env)),
 		file_path: d.defaultPath,
+		env:       env,
 		s:         &d.secrets,
 	}
 	d.secrets.Envs[env] = &data
@@ -246,6 +328,7 @@ func (d *Secure) SetFile(filePath, env string) {
 		Version:   CredsVersion,
 		file:      path.Clean(filePath),
 		file_path: path.Dir(filePath),
+		env:       env,
 	}
 	d.secrets.Envs[env] = &data
 }
@@ -406,3 +489,34 @@ func (d *Secure) SetSetterHandler(key string, setter func(v *Value, value *gofor
 func (d *Secure) SetGetterHandler(key string, getter func(v *YamlValue) (string, error)) {
 	d.secrets.setGetterHandler(key, getter)
 }
+
+// Envs return the sorted list of environments defined.
+func (d *Secure) Envs() (envs []string) {
+	if d == nil {
+		return
+	}
+	envs = make([]string, 0, len(d.secrets.Envs))
+	for env := range d.secrets.Envs {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+	return
+}
+
+// EnvValues return object values stored in an environment, indexed by key path (<object>/<instance>/<key>).
+func (d *Secure) EnvValues(env string) (values map[string]*Value) {
+	if d == nil {
+		return
+	}
+	values = make(map[string]*Value)
+	if v, found := d.secrets.Envs[env]; found {
+		for objName, instances := range v.Objects {
+			for instanceName, keys := range instances {
+				for keyName, value := range keys {
+					values[objName+"/"+instanceName+"/"+keyName] = value
+				}
+			}
+		}
+	}
+	return
+}
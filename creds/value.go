@@ -70,6 +70,21 @@ func (v *Value) copyFrom(copy *Value) (updated bool) {
 		updated = !copy.value.Equal(v.value)
 		// Set may update the list of resources depending on the type and handler attached.
 		v.Set(copy.source, copy.value)
+		if !v.hasSetter(copy.source) {
+			// Otherwise resources (like generator metadata) are given by the copy.
+			v.resource = make(map[string]string)
+			for key, value := range copy.resource {
+				v.resource[key] = value
+			}
+		}
+	}
+	return
+}
+
+// hasSetter return true if a setter handler is defined for the source.
+func (v *Value) hasSetter(source string) (found bool) {
+	if v.s != nil {
+		_, found = v.s.setter[source]
 	}
 	return
 }
@@ -165,7 +180,7 @@ func (v *Value) GetString() (_ string, err error) {
 	}
 
 	if v.source == Internal || v.source == "" {
-		return v.value.GetString(), nil
+		return v.redacted(v.value.GetString()), nil
 	}
 
 	if v.s != nil {
@@ -173,10 +188,17 @@ func (v *Value) GetString() (_ string, err error) {
 			err = fmt.Errorf("'%s' is an unknown getter type", v.source)
 			return
 		} else {
-			return getter(v.Clone())
+			value, err := getter(v.Clone())
+			return v.redacted(value), err
 		}
 	}
-	return v.value.GetString(), nil
+	return v.redacted(v.value.GetString()), nil
+}
+
+// redacted register the secure value read, to mask it in messages forjj displays. See Redact.
+func (v *Value) redacted(value string) string {
+	secureRedactor.register(value)
+	return value
 }
 
 // ------------- Resource management
@@ -128,11 +128,41 @@ func Test_Value_AddResource(t *testing.T) {
 	}
 }
 
-// ************* TODO: Write test on following functions **************************
 func Test_Value_copyFrom(t *testing.T) {
+	t.Log("Expecting Value.copyFrom to copy value and resources to an existing Value.")
+	assert := assert.New(t)
+
+	s := NewSecrets()
+	s.setSetterHandler("link", func(v *Value, value *goforjj.ValueStruct) error {
+		v.SetValue("")
+		v.AddResource("linked-to", value.GetString())
+		return nil
+	})
+	v := NewValue(Internal, goforjj.NewValueStruct("old")).clone(s)
+	v.AddResource("generator", "password")
+
+	// ------------- call the function
+	copy := NewValue(Internal, goforjj.NewValueStruct("new"))
+	copy.AddResource("generator", "token")
+	updated := v.copyFrom(copy)
+
+	// -------------- testing
+	when := "when an internal value is copied"
+	assert.Truef(updated, "Expected value to be updated %s", when)
+	assert.Equalf("new", v.value.GetString(), "Expected value to be copied %s", when)
+	assert.Equalf(map[string]string{"generator": "token"}, v.resource, "Expected resources to be copied %s", when)
+
+	// ------------- call the function
+	v.copyFrom(NewValue("link", goforjj.NewValueStruct("/tmp/file")))
 
+	// -------------- testing
+	when = "when the value source has a setter"
+	assert.Equalf("link", v.source, "Expected source to be copied %s", when)
+	assert.Equalf(map[string]string{"linked-to": "/tmp/file"}, v.resource, "Expected resources to be set by the setter %s", when)
 }
 
+// ************* TODO: Write test on following functions **************************
+
 func Test_Value_clone(t *testing.T) {
 
 }
@@ -33,6 +33,11 @@ type yamlSecure struct {
 	file_path  string
 	loaded     bool
 
+	// env is the environment name bound to the encrypted file.
+	env string
+	// legacyCipher is true if the encrypted file was loaded without ciphertext header. See Secure.Upgrade.
+	legacyCipher bool
+
 	Version string
 	Forj    map[string]*Value
 	Objects map[string]map[string]map[string]*Value
@@ -165,7 +170,7 @@ func (d *yamlSecure) save(secretFile bool) (err error) {
 		return err
 	}
 
-	if err = ioutil.WriteFile(d.credFile, yamlData, 0644); err != nil {
+	if err = writeFileAtomic(d.credFile, yamlData, 0644); err != nil {
 		return err
 	}
 	gotrace.Trace("File name saved: %s", file)
@@ -181,9 +186,12 @@ func (d *yamlSecure) setForjValue(source, key string, value *Value) (updated boo
 	if v, found := d.Forj[key]; found {
 		updated = v.copyFrom(value)
 	} else {
-		d.Forj[key] = value.clone(d.s)
+		d.Forj[key] = d.newValue(value)
 		updated = true
 	}
+	if updated {
+		d.Forj[key].stamp(value)
+	}
 	return
 }
 
@@ -216,6 +224,24 @@ func (d *yamlSecure) unsetObjectValue(obj_name, instance_name, key_name string)
 	return
 }
 
+// newValue return a copy of the value attached to secrets. The source setter, if any, defines the value resources.
+func (d *yamlSecure) newValue(value *Value) (ret *Value) {
+	ret = value.clone(d.s)
+	ret.resource = make(map[string]string)
+	for key, resource := range value.resource {
+		ret.resource[key] = resource
+	}
+	if d.s == nil {
+		return
+	}
+	if _, found := d.s.setter[value.source]; found {
+		if err := ret.Set(value.source, value.value); err != nil {
+			gotrace.Error("Unable to set the '%s' secret value. %s", value.source, err)
+		}
+	}
+	return
+}
+
 func (d *yamlSecure) setObjectValue(source, obj_name, instance_name, key_name string, value *Value) (updated bool) {
 	if d.Objects == nil {
 		d.Objects = make(map[string]map[string]map[string]*Value)
@@ -226,22 +252,25 @@ func (d *yamlSecure) setObjectValue(source, obj_name, instance_name, key_name st
 		keys = make(map[string]*Value)
 		instances = make(map[string]map[string]*Value)
 
-		keys[key_name] = value.clone(d.s)
+		keys[key_name] = d.newValue(value)
 		instances[instance_name] = keys
 		d.Objects[obj_name] = instances
 		updated = true
 	} else if k, found := i[instance_name]; !found {
 		keys = make(map[string]*Value)
 
-		keys[key_name] = value.clone(d.s)
+		keys[key_name] = d.newValue(value)
 		d.Objects[obj_name][instance_name] = keys
 		updated = true
 	} else if v, found := k[key_name]; !found {
-		k[key_name] = value.clone(d.s)
+		k[key_name] = d.newValue(value)
 		updated = true
 	} else {
 		updated = v.copyFrom(value)
 	}
+	if updated {
+		d.Objects[obj_name][instance_name][key_name].stamp(value)
+	}
 	d.sources = d.sources.Set(source, obj_name+"/"+instance_name+"/"+key_name, value.value.GetString())
 	return
 }
@@ -251,7 +280,7 @@ func (d *yamlSecure) getString(obj_name, instance_name, key_name string) (string
 	if !found || v == nil || v.value == nil {
 		return "", found, source
 	}
-	return v.value.GetString(), found, source
+	return v.redacted(v.value.GetString()), found, source
 }
 
 func (d *yamlSecure) get(obj_name, instance_name, key_name string) (ret *Value, found bool, source string) {
@@ -128,6 +128,7 @@ func (a *Forj) doDriverClean(d *drivers.Driver) error {
 			return fmt.Errorf("Unable to complete commit process. '%d' Uncontrolled files found", num)
 		}
 	}
+	a.commitData.AddFiles(d.InstanceName, d.Plugin.Result.Data.Files)
 	return nil
 }
 
@@ -270,6 +271,7 @@ func (a *Forj) driver_do(d *drivers.Driver, instance_name, action string, args .
 		return fmt.Errorf("An error occured in '%s' plugin. No data has been returned. Please check plugin logs.", instance_name), false
 	}
 
+	// Plugin messages are displayed with log, which masks secure values. See creds.RedactWriter.
 	termBrown, termReset := utils.DefColor(33)
 	for _, line := range strings.Split(d.Plugin.Result.Data.Status, "\n") {
 		log.Println(termBrown, line, termReset)
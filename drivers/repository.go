@@ -128,7 +128,23 @@ func (d *Driver) gitCleanPluginFiles(where string, files []string) error {
 		}
 		index := statusFile.Index()
 		workTree := statusFile.WorkTree()
-		if index != ' ' && index != '?' {
+		if statusFile.IsUnmerged() {
+			gotrace.Warning("%s not restored. It has a merge conflict to fix.", fileToCleanUp)
+			continue
+		}
+		if isSubmodule, _, _, _ := statusFile.Submodule(); isSubmodule {
+			gotrace.Warning("%s not restored. Submodules are not restored.", fileToCleanUp)
+			continue
+		}
+		if index == 'R' || index == 'C' {
+			// The file is a new copy of the source path. A renamed source path is restored.
+			git.Do("reset", "HEAD", fileToCleanUp)
+			os.Remove(fileToCleanUp)
+			if origPath := statusFile.OrigPath(); index == 'R' && origPath != "" {
+				git.Do("reset", "HEAD", origPath)
+				git.Do("checkout", origPath)
+			}
+		} else if index != ' ' && index != '?' {
 			git.Do("reset", "HEAD", fileToCleanUp)
 			if index == 'A' {
 				os.Remove(fileToCleanUp)
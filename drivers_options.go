@@ -433,11 +433,11 @@ func (a *Forj) objectGetInstances(object_name string) (ret []string) {
 
 func (a *Forj) copyCliObjectData(ffd *forjfile.DeployForgeYaml, object_name, instance, flag_name, def_value string) {
 	if v, found, _, _ := a.cli.GetStringValue(object_name, instance, flag_name); found && v != "" {
-		ffd.Set("forjj", object_name, instance, flag_name, v)
+		ffd.Set("cli", object_name, instance, flag_name, v)
 		gotrace.Trace("Set %s/%s:%s value to Forjfile from cli.", object_name, instance, flag_name)
 	} else {
 		if def_value != "" {
-			ffd.SetDefault("forjj", object_name, instance, flag_name, def_value)
+			ffd.SetDefault("plugin-default", object_name, instance, flag_name, def_value)
 			gotrace.Trace("Setting Forjfile flag '%s/%s:%s' default value to '%s'",
 				object_name, instance, flag_name, def_value)
 		}
@@ -604,7 +604,7 @@ func (a *Forj) GetObjectsData(r *goforjj.PluginReqData, d *drivers.Driver, actio
 
 			keys := make(goforjj.InstanceKeys)
 			extent := make(goforjj.InstanceExtentKeys)
-			creds := make(map[string]string)
+			secureValues := make(map[string]string)
 
 			flags := Obj.FlagsRange("setup")
 
@@ -617,6 +617,7 @@ func (a *Forj) GetObjectsData(r *goforjj.PluginReqData, d *drivers.Driver, actio
 				}
 
 				value := new(goforjj.ValueStruct)
+				var refs *forjfile.SecretRefs // Secrets referenced by the Forjfile value, resolved after evaluation.
 				if flag.Options.Secure {
 					// From creds.yml
 					def_value := "{{ (index .Current.Creds \"" + key + "\").GetString }}"
@@ -631,6 +632,13 @@ func (a *Forj) GetObjectsData(r *goforjj.PluginReqData, d *drivers.Driver, actio
 					if v, found, _ := ffd.Get(object_name, instance_name, key); !found {
 						gotrace.Trace("%s/%s: NOT ADDED: Key '%s' has not been found in Forjfile. ", object_name, instance_name, key)
 						continue
+					} else if forjfile.HasSecretRef(v.GetString()) {
+						tmpl, secretRefs, err := forjfile.ParseSecretRefs(v.GetString())
+						if err != nil {
+							return fmt.Errorf("%s/%s: Key '%s'. %s", object_name, instance_name, key, err)
+						}
+						value.Set(tmpl)
+						refs = secretRefs
 					} else {
 						value.Set(v)
 					}
@@ -642,22 +650,41 @@ func (a *Forj) GetObjectsData(r *goforjj.PluginReqData, d *drivers.Driver, actio
 					gotrace.Trace("%s/%s: NOT ADDED: Key '%s' has not been added and set", object_name, instance_name, key)
 					continue
 				}
-				gotrace.Trace("%s/%s: ADDED: Key '%s' has been added and set to '%s'", object_name, instance_name, key, value.GetString())
+				if refs != nil {
+					gotrace.Trace("%s/%s: ADDED: Key '%s' has been added and set to '%s'", object_name, instance_name, key, creds.Redact(refs.Redact(value.GetString())))
+					resolved, err := refs.Resolve(value.GetString(), a.getSecretRef)
+					if err != nil {
+						return fmt.Errorf("%s/%s: Key '%s'. %s", object_name, instance_name, key, err)
+					}
+					value.Set(resolved)
+				} else {
+					gotrace.Trace("%s/%s: ADDED: Key '%s' has been added and set to '%s'", object_name, instance_name, key, creds.Redact(value.GetString()))
+				}
 				if flag.IsExtentFlag() && !flag.Options.Secure {
 					extent[key] = value
 				} else {
 					keys[key] = value
 				}
 				if flag.Options.Secure {
-					creds[key] = value.GetString()
+					secureValues[key] = value.GetString()
 				}
 			}
-			r.AddObjectActions(object_name, instance_name, keys, extent, creds)
+			r.AddObjectActions(object_name, instance_name, keys, extent, secureValues)
 		}
 	}
 	return nil
 }
 
+// getSecretRef return a secret referenced in a Forjfile value, from the current deployment or global secrets.
+func (a *Forj) getSecretRef(keyPath string) (string, error) {
+	keys := strings.Split(keyPath, "/")
+	v, found, _, _ := a.s.Get(keys[0], keys[1], keys[2])
+	if !found {
+		return "", fmt.Errorf("Secret not found. Set it with `forjj secrets set %s`", keyPath)
+	}
+	return v.GetString()
+}
+
 // AddReqDeployment create a new deployment-env key in the forj-settings section of a plugin payload.
 // Information retrieved from InMemForjfile
 func (a *Forj) AddReqDeployment(req *goforjj.PluginReqData) (err error) {
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"forjj/creds"
+)
+
+// buildExplainForjfile build the in memory Forjfile like an update does, without calling any plugins.
+//
+// Used by `forjj explain` to get values set by flows and plugins defaults.
+func (a *Forj) buildExplainForjfile() error {
+	// Set plugin defaults for objects defined by plugins loaded.
+	if err := a.scanAndSetDefaults(a.f.DeployForjfile(), creds.Global); err != nil {
+		return fmt.Errorf("Global dispatch issue. %s", err)
+	}
+
+	// Build in memory representation from source files loaded.
+	if err := a.f.BuildForjfileInMem(); err != nil {
+		return err
+	}
+
+	ffd := a.f.InMemForjfile()
+
+	// Add missing deployment Repositories
+	if err := a.DefineDeployRepositories(ffd, false); err != nil {
+		return fmt.Errorf("Issues to automatically add your deployment repositories. %s", err)
+	}
+
+	// Load flow identified by Forjfile source and apply them.
+	if err := a.FlowInit(); err != nil {
+		return err
+	}
+	if err := a.FlowApply(); err != nil {
+		return fmt.Errorf("Unable to apply flows. %s", err)
+	}
+
+	// Set plugin defaults for objects added dynamically in the in memory Forjfile.
+	if err := a.scanAndSetDefaults(ffd, creds.Global); err != nil {
+		return fmt.Errorf("Global dispatch issue. %s", err)
+	}
+	return nil
+}
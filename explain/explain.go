@@ -0,0 +1,148 @@
+package explain
+
+import (
+	"forjj/creds"
+	"forjj/forjfile"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/forj-oss/forjj-modules/cli/clier"
+	"github.com/forj-oss/forjj-modules/trace"
+)
+
+// Explain represents the `forjj explain` cli command.
+//
+// It shows, for a Forjfile value, the list of sources which has set it and which one won.
+type Explain struct {
+	cmd     *kingpin.CmdClause
+	context Context
+
+	key      *string
+	all      *bool
+	deployTo *string
+
+	forjfile *forjfile.Forge
+	secrets  *creds.Secure
+	build    func() error
+}
+
+// Init configure the explain cli command.
+//
+// build is called to build the in memory Forjfile (flows, plugins defaults, etc...) before explaining values.
+func (e *Explain) Init(app *kingpin.Application, forjfile *forjfile.Forge, secrets *creds.Secure, isParsePhase func() bool, build func() error, initCommon func(context *Context, cmd *kingpin.CmdClause)) {
+	if e == nil || app == nil {
+		return
+	}
+
+	e.cmd = app.Command("explain", "Explain where Forjfile values come from.")
+	e.context.init(isParsePhase)
+	e.key = e.cmd.Arg("key", "Key path to explain. Format is <object>/<instance>/<key>. ex: repos/myrepo/flow").String()
+	e.all = e.cmd.Flag("all", "Explain all values known by forjj.").Bool()
+	e.deployTo = e.context.Flag("deploy-to",
+		e.cmd.Flag("deploy-to", "forjj deployment environment to explain. You can set 'FORJJ_DEPLOY_ENV' as environment variable.").Envar("FORJJ_DEPLOY_ENV")).String()
+	initCommon(&e.context, e.cmd)
+
+	e.forjfile = forjfile
+	e.secrets = secrets
+	e.build = build
+}
+
+// Action executed for explain cli command
+func (e *Explain) Action(string) {
+	if !*e.all && *e.key == "" {
+		gotrace.Error("Missing key to explain. Provide a key path like 'repos/myrepo/flow' or use --all.")
+		return
+	}
+
+	var paths []keyPath
+	if *e.all {
+		paths = e.listKeys(e.forjfile.DeployForjfile(), e.deployForjfile())
+	} else if path, err := newKeyPath(*e.key); err != nil {
+		gotrace.Error("%s", err)
+		return
+	} else {
+		paths = []keyPath{path}
+	}
+
+	// Forjfile layers are captured before building the in memory Forjfile
+	// as the build may update the Forjfiles loaded.
+	chains := make(map[keyPath]*valueChain)
+	for _, path := range paths {
+		chains[path] = e.newValueChain(path)
+	}
+
+	if e.build != nil {
+		if err := e.build(); err != nil {
+			gotrace.Error("Unable to build the Forjfile in memory. %s", err)
+			return
+		}
+	}
+
+	if *e.all {
+		for _, path := range e.listKeys(e.forjfile.InMemForjfile()) {
+			if _, found := chains[path]; !found {
+				chains[path] = newEmptyValueChain(path)
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	for _, path := range sortKeyPaths(paths) {
+		chain := chains[path]
+		e.completeValueChain(chain)
+		if *e.all && chain.winner == -1 {
+			continue
+		}
+		chain.print()
+	}
+}
+
+// DefineContext define cli Context to permit ParseContext to retrieve
+// common variable set.
+func (e *Explain) DefineContext(context clier.ParseContexter) {
+	e.context.defineContext(context)
+}
+
+// GetStringValue Return a field value from the given context (parse time, or after)
+func (e *Explain) GetStringValue(field string) (value string, found, isDefault bool, _ error) {
+	return e.context.GetStringValue(field)
+}
+
+// deployForjfile return the current deployment Forjfile, if exist.
+func (e *Explain) deployForjfile() *forjfile.DeployForgeYaml {
+	if deploy, found := e.forjfile.GetADeployment(e.forjfile.GetDeployment()); found {
+		return deploy.Details
+	}
+	return nil
+}
+
+// listKeys return the list of keys found in all Forjfiles given.
+func (e *Explain) listKeys(forjfiles ...*forjfile.DeployForgeYaml) (ret []keyPath) {
+	found := make(map[keyPath]bool)
+	add := func(ffd *forjfile.DeployForgeYaml, object, instance string) {
+		for _, key := range ffd.GetKeys(object, instance) {
+			path := keyPath{object: object, instance: instance, key: key}
+			if !found[path] {
+				found[path] = true
+				ret = append(ret, path)
+			}
+		}
+	}
+
+	for _, ffd := range forjfiles {
+		if ffd == nil {
+			continue
+		}
+		add(ffd, "infra", "")
+		add(ffd, "settings", "default")
+		objects := []string{"repo", "app", "user", "group"}
+		for object := range ffd.More {
+			objects = append(objects, object)
+		}
+		for _, object := range objects {
+			for _, instance := range ffd.GetInstances(object) {
+				add(ffd, object, instance)
+			}
+		}
+	}
+	return
+}
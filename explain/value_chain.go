@@ -0,0 +1,245 @@
+package explain
+
+import (
+	"fmt"
+	"forjj/forjfile"
+	"sort"
+	"strings"
+)
+
+// keyPath identify a Forjfile key.
+type keyPath struct {
+	object   string
+	instance string
+	key      string
+}
+
+// objectAliases map Forjfile section names to forjj object names.
+var objectAliases = map[string]string{
+	"repos":         "repo",
+	"repositories":  "repo",
+	"apps":          "app",
+	"applications":  "app",
+	"users":         "user",
+	"groups":        "group",
+	"forj-settings": "settings",
+}
+
+// settingsDefaults identify the forj-settings default used by an object key when not set.
+var settingsDefaults = map[string]keyPath{
+	"repo/flow":          {object: "settings", instance: "default", key: "flow"},
+	"repo/apps:upstream": {object: "settings", instance: "default-repo-apps", key: "upstream"},
+	"repo/upstream":      {object: "settings", instance: "default-repo-apps", key: "upstream"},
+}
+
+// newKeyPath decode a key path string '<object>/<instance>/<key>' or 'infra/<key>'.
+func newKeyPath(path string) (ret keyPath, err error) {
+	parts := strings.Split(path, "/")
+	if v, found := objectAliases[parts[0]]; found {
+		parts[0] = v
+	}
+	switch {
+	case len(parts) == 2 && parts[0] == "infra":
+		ret = keyPath{object: parts[0], key: parts[1]}
+	case len(parts) == 3:
+		ret = keyPath{object: parts[0], instance: parts[1], key: parts[2]}
+	default:
+		err = fmt.Errorf("Invalid key path '%s'. Format supported is '<object>/<instance>/<key>'", path)
+	}
+	return
+}
+
+func (p keyPath) String() string {
+	if p.object == "infra" {
+		return p.object + "/" + p.key
+	}
+	return p.object + "/" + p.instance + "/" + p.key
+}
+
+func sortKeyPaths(paths []keyPath) []keyPath {
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i].String() < paths[j].String()
+	})
+	return paths
+}
+
+// chainLayer is one source which may set a value.
+type chainLayer struct {
+	name     string
+	source   string // forjj internal source name, if any.
+	value    string
+	found    bool
+	secret   bool
+	fallback bool // true if the layer gives the value only when no Forjfile layer set it.
+}
+
+// valueChain is the list of sources of a key, in precedence order.
+type valueChain struct {
+	path   keyPath
+	value  string
+	layers []chainLayer
+	winner int // index of the layer which gives the value. -1 if the value is not set.
+}
+
+// Internal source names which are not a file layer.
+const (
+	flowSource          = "flow"
+	pluginDefaultSource = "plugin-default"
+	cliSource           = "cli"
+)
+
+func newEmptyValueChain(path keyPath) (ret *valueChain) {
+	ret = new(valueChain)
+	ret.path = path
+	ret.winner = -1
+	ret.layers = []chainLayer{
+		{name: "Forjfile"},
+		{name: "deployment Forjfile"},
+	}
+	return
+}
+
+// newValueChain create the chain with Forjfile layers.
+func (e *Explain) newValueChain(path keyPath) (ret *valueChain) {
+	ret = newEmptyValueChain(path)
+	ret.layers[0].value, ret.layers[0].found = fileValue(e.forjfile.DeployForjfile(), path)
+	ret.layers[1].name = fmt.Sprintf("deployment Forjfile (%s)", e.forjfile.GetDeployment())
+	ret.layers[1].value, ret.layers[1].found = fileValue(e.deployForjfile(), path)
+	return
+}
+
+// fileValue return a value loaded from a Forjfile, ie not updated by forjj.
+func fileValue(ffd *forjfile.DeployForgeYaml, path keyPath) (_ string, _ bool) {
+	if ffd == nil {
+		return
+	}
+	if v, found, source := ffd.Get(path.object, path.instance, path.key); found && source == "" {
+		return v.GetString(), true
+	}
+	return
+}
+
+// completeValueChain add to the chain all layers set at runtime and identify the winner.
+func (e *Explain) completeValueChain(chain *valueChain) {
+	path := chain.path
+	ffd := e.forjfile.InMemForjfile()
+
+	layer := chainLayer{name: "forj-settings defaults", fallback: true}
+	if def, found := settingsDefaults[path.object+"/"+path.key]; found && ffd != nil {
+		if v, found, _ := ffd.Get(def.object, def.instance, def.key); found {
+			layer.value, layer.found = v.GetString(), true
+			layer.name += " (" + def.String() + ")"
+		}
+	}
+	chain.layers = append(chain.layers, layer)
+
+	runtime := make(map[string]chainLayer)
+	others := []string{}
+	if ffd != nil {
+		for _, source := range ffd.GetSourcesChain(path.object, path.instance, path.key) {
+			if source.Source == "" {
+				continue // Set by a Forjfile merge.
+			}
+			if _, found := runtime[source.Source]; !found {
+				switch source.Source {
+				case flowSource, pluginDefaultSource, cliSource:
+				default:
+					others = append(others, source.Source)
+				}
+			}
+			runtime[source.Source] = chainLayer{name: source.Source, source: source.Source, value: source.Value, found: true}
+		}
+	}
+	addRuntime := func(name, source string) {
+		layer := runtime[source]
+		layer.name = name
+		layer.source = source
+		chain.layers = append(chain.layers, layer)
+	}
+	addRuntime("flow task", flowSource)
+	addRuntime("plugin defaults", pluginDefaultSource)
+	for _, source := range others {
+		addRuntime(source, source)
+	}
+
+	credsLayer := chainLayer{name: "creds", secret: true, fallback: true}
+	if v, found, _, env := e.secrets.Get(path.object, path.instance, path.key); found {
+		credsLayer.found = true
+		credsLayer.name += " (" + env + ")"
+		if s, err := v.GetString(); err == nil {
+			credsLayer.value = s
+		}
+	}
+	chain.layers = append(chain.layers, credsLayer)
+
+	addRuntime("cli", cliSource)
+
+	chain.defineWinner(ffd)
+}
+
+// defineWinner identify the layer which gives the effective value.
+func (c *valueChain) defineWinner(ffd *forjfile.DeployForgeYaml) {
+	c.winner = -1
+	if ffd == nil {
+		return
+	}
+	value, found, source := ffd.Get(c.path.object, c.path.instance, c.path.key)
+	if !found {
+		// Not in the Forjfile. Secrets or forj-settings defaults can give it.
+		for index := len(c.layers) - 1; index >= 0; index-- {
+			if layer := c.layers[index]; layer.found && layer.fallback {
+				c.value = layer.value
+				c.winner = index
+				return
+			}
+		}
+		return
+	}
+	c.value = value.GetString()
+
+	for index, layer := range c.layers {
+		if layer.found && layer.source != "" && layer.source == source && layer.value == c.value {
+			c.winner = index
+			return
+		}
+	}
+	// Set by a Forjfile or a source without value identified. Take the highest layer with the same value.
+	for index := len(c.layers) - 1; index >= 0; index-- {
+		if layer := c.layers[index]; layer.found && !layer.secret && layer.value == c.value {
+			c.winner = index
+			return
+		}
+	}
+}
+
+// print display the chain.
+func (c *valueChain) print() {
+	if c.winner == -1 {
+		fmt.Printf("%s is not set\n", c.path)
+	} else if c.layers[c.winner].secret {
+		fmt.Printf("%s = <secret>\n", c.path)
+	} else {
+		fmt.Printf("%s = '%s'\n", c.path, c.value)
+	}
+	for index, layer := range c.layers {
+		mark := " "
+		if index == c.winner {
+			mark = "*"
+		}
+		value := "-"
+		switch {
+		case !layer.found:
+		case layer.secret:
+			value = "<secret>"
+		case layer.value == "":
+			value = "<removed>"
+		default:
+			value = "'" + layer.value + "'"
+		}
+		fmt.Printf("  %s %-40s: %s\n", mark, layer.name, value)
+	}
+	if c.winner != -1 {
+		fmt.Printf("  => value from %s\n", c.layers[c.winner].name)
+	}
+	fmt.Println()
+}
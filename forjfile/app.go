@@ -18,6 +18,8 @@ type AppStruct struct {
 	name          string
 	sources       *sourcesinfo.Sources
 	AppYamlStruct `yaml:",inline"`
+	// Merge directive (!delete or !replace) used when merging this application to another Forjfile.
+	mergeDirective string
 }
 
 type AppYamlStruct struct {
@@ -73,6 +75,11 @@ func (a *AppStruct) UnmarshalYAML(unmarchal func(interface{}) error) error {
 	if a == nil {
 		return nil
 	}
+	if directive, found, err := decodeMergeDirective(unmarchal); found {
+		a.mergeDirective = directive
+		return err
+	}
+
 	var app AppYamlStruct
 
 	if err := unmarchal(&app); err != nil {
@@ -82,6 +89,7 @@ func (a *AppStruct) UnmarshalYAML(unmarchal func(interface{}) error) error {
 		return fmt.Errorf("Application type value is required.")
 	}
 
+	a.mergeDirective = extractMergeReplace(app.More)
 	app.more = make(ForjValues)
 	for key, value := range app.More {
 		app.more[key] = ForjValue{value: value}
@@ -95,8 +103,11 @@ func (a *AppStruct) MarshalYAML() (interface{}, error) {
 	if a == nil {
 		return nil, nil
 	}
+	if a.mergeDirective == mergeDelete {
+		return mergeDelete, nil
+	}
 	// Ensure we write only Yaml Data.
-	a.AppYamlStruct.More = a.AppYamlStruct.more.Map()
+	a.AppYamlStruct.More = withMergeReplace(a.AppYamlStruct.more.Map(), a.mergeDirective)
 	return a.AppYamlStruct, nil
 }
 
@@ -185,6 +196,10 @@ func (a *AppStruct) set(source, flag, value string, set func(*ForjValue, string)
 				a.more[flag] = v
 				updated = true
 			}
+			if !v.selected(value) { // A default value not applied is not in the chain of sources.
+				a.sources = a.sources.SetUnchained(source, flag, value)
+				return
+			}
 		}
 	}
 	a.sources = a.sources.Set(source, flag, value)
@@ -204,7 +219,28 @@ func (a *AppStruct) mergeFrom(from *AppStruct) {
 	}
 	for _, flag := range from.Flags() {
 		if v, found, source := from.Get(flag); found {
-			a.set(source, flag, v.GetString(), (*ForjValue).Set)
+			value := v.GetString()
+			if value == mergeDelete {
+				value = ""
+			}
+			a.set(source, flag, value, (*ForjValue).Set)
+		}
+	}
+}
+
+// withoutMergeDirectives return the application without any key merge directives.
+//
+// If the application has some, a copy is returned, without those directives.
+func (a *AppStruct) withoutMergeDirectives() *AppStruct {
+	if a == nil || !a.more.hasMergeDirectives() {
+		return a
+	}
+	ret := *a
+	ret.more = make(ForjValues)
+	for key, value := range a.more {
+		if value.value != mergeDelete {
+			ret.more[key] = value
 		}
 	}
+	return &ret
 }
@@ -20,11 +20,14 @@ func (a AppsStruct) mergeFrom(from AppsStruct) AppsStruct {
 		a = make(AppsStruct)
 	}
 	for k, appFrom := range from {
-		if app, found := a[k]; found {
+		if appFrom != nil && appFrom.mergeDirective == mergeDelete {
+			delete(a, k)
+			continue
+		}
+		if app, found := a[k]; found && (appFrom == nil || appFrom.mergeDirective != mergeReplace) {
 			app.mergeFrom(appFrom)
 		} else {
-			
-			a[k] = appFrom
+			a[k] = appFrom.withoutMergeDirectives()
 		}
 	}
 	return a
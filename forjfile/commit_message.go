@@ -0,0 +1,131 @@
+package forjfile
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// commitMessageSetting is the forj-settings key of the commit message template.
+	commitMessageSetting = "commit-message"
+	// defaultCommitMessage keeps the commit message proposed by forjj.
+	defaultCommitMessage = "{{ .Message }}"
+
+	// Trailers added to every commit message created by forjj.
+	RunTrailer        = "Forjj-Run:"
+	DeploymentTrailer = "Forjj-Deployment:"
+	InstancesTrailer  = "Forjj-Instances:"
+
+	// RunIDEnvVar is the environment variable giving the forjj run identifier, like a CI build identifier.
+	RunIDEnvVar = "FORJJ_RUN_ID"
+)
+
+// runIDInvalidChars are characters not supported in a run identifier. It is used in git branch and file names.
+var runIDInvalidChars = regexp.MustCompile(`[^\w.-]+`)
+
+// CommitMessageData is the data given to the commit message template.
+//
+// Ex:
+//
+//	forj-settings:
+//	  commit-message: |-
+//	    forjj {{ .Action }} of {{ .Deployment }}: {{ join .Instances ", " }}
+//
+//	    {{ range $instance, $files := .Files }}{{ $instance }}: {{ join $files ", " }}
+//	    {{ end }}
+type CommitMessageData struct {
+	Action       string              // forjj action committing. ie create, update or maintain
+	Message      string              // Commit message proposed by forjj.
+	Organization string              // Organization name.
+	Deployment   string              // Deployment name.
+	Instances    []string            // Driver instances which generated files.
+	Files        map[string][]string // Files generated, per driver instance.
+	RunID        string              // Identify the forjj run.
+}
+
+// NewRunID return a new forjj run identifier.
+//
+// It is given by FORJJ_RUN_ID if set. Otherwise, it is built from the current UTC time and random bytes, so
+// runs started at the same time, like parallel CI jobs, get different identifiers.
+func NewRunID() string {
+	if runID := strings.Trim(runIDInvalidChars.ReplaceAllString(os.Getenv(RunIDEnvVar), "-"), ".-"); runID != "" {
+		return runID
+	}
+	random := make([]byte, 4)
+	rand.Read(random)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(random)
+}
+
+// AddFiles register files generated by a driver instance.
+//
+// files are given per repository type, as returned by plugins.
+func (d *CommitMessageData) AddFiles(instance string, files map[string][]string) {
+	if len(files) == 0 {
+		return
+	}
+	if d.Files == nil {
+		d.Files = make(map[string][]string)
+	}
+	if _, found := d.Files[instance]; !found {
+		d.Instances = append(d.Instances, instance)
+		sort.Strings(d.Instances)
+	}
+	for _, list := range files {
+		d.Files[instance] = append(d.Files[instance], list...)
+	}
+	sort.Strings(d.Files[instance])
+}
+
+// trailers return git trailers identifying the forjj run.
+func (d *CommitMessageData) trailers() (ret []string) {
+	if d.RunID != "" {
+		ret = append(ret, RunTrailer+" "+d.RunID)
+	}
+	if d.Deployment != "" {
+		ret = append(ret, DeploymentTrailer+" "+d.Deployment)
+	}
+	if len(d.Instances) > 0 {
+		ret = append(ret, InstancesTrailer+" "+strings.Join(d.Instances, ", "))
+	}
+	return
+}
+
+// CommitMessage render the commit message from the Forjfile:/forj-settings/commit-message template.
+//
+// Without template, the message proposed by forjj is used. Run trailers are added to the message.
+func (f *Forge) CommitMessage(data *CommitMessageData) (string, error) {
+	tmpl := defaultCommitMessage
+	if f != nil && f.yaml != nil && f.yaml.ForjCore.ForjSettings.CommitMessage != "" {
+		tmpl = f.yaml.ForjCore.ForjSettings.CommitMessage
+	}
+	return renderCommitMessage(tmpl, data)
+}
+
+// renderCommitMessage render a commit message template and add run trailers.
+func renderCommitMessage(tmpl string, data *CommitMessageData) (string, error) {
+	t, err := template.New(commitMessageSetting).Funcs(template.FuncMap{"join": strings.Join}).Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("Invalid forj-settings/%s template. %s", commitMessageSetting, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("Unable to render forj-settings/%s template. %s", commitMessageSetting, err)
+	}
+
+	message := strings.TrimSpace(buf.String())
+	if message == "" {
+		return "", fmt.Errorf("forj-settings/%s template renders an empty commit message", commitMessageSetting)
+	}
+	if trailers := data.trailers(); len(trailers) > 0 {
+		message += "\n\n" + strings.Join(trailers, "\n")
+	}
+	return message, nil
+}
@@ -0,0 +1,139 @@
+package forjfile
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitMessageDataAddFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	data := CommitMessageData{}
+
+	/*********************************/
+	testCase := "when files are added by drivers"
+
+	// ------------ Run function to test
+	data.AddFiles("jenkins", map[string][]string{"deploy": {"jobs/b.xml", "jobs/a.xml"}})
+	data.AddFiles("github", map[string][]string{"source": {"github.yaml"}})
+	data.AddFiles("jenkins", map[string][]string{"source": {"jenkins.yaml"}})
+	data.AddFiles("none", nil)
+
+	// ------------ Test result
+	assert.Equalf([]string{"github", "jenkins"}, data.Instances, "Expect instances to be sorted %s", testCase)
+	assert.Equalf(map[string][]string{
+		"github":  {"github.yaml"},
+		"jenkins": {"jenkins.yaml", "jobs/a.xml", "jobs/b.xml"},
+	}, data.Files, "Expect files per instance %s", testCase)
+}
+
+func TestNewRunID(t *testing.T) {
+	assert := assert.New(t)
+
+	defer os.Setenv(RunIDEnvVar, os.Getenv(RunIDEnvVar))
+	os.Unsetenv(RunIDEnvVar)
+
+	/*********************************/
+	testCase := "when runs start at the same time"
+
+	// ------------ Run function to test
+	runID, otherRunID := NewRunID(), NewRunID()
+
+	// ------------ Test result
+	assert.Regexpf(regexp.MustCompile(`^\d{8}-\d{6}-[0-9a-f]{8}$`), runID, "Expect a time and random run ID %s", testCase)
+	assert.NotEqualf(runID, otherRunID, "Expect different run IDs %s", testCase)
+
+	/*********************************/
+	testCase = "when the run ID is given by the environment"
+	os.Setenv(RunIDEnvVar, "jenkins-infra/master #42")
+
+	// ------------ Run function to test
+	runID = NewRunID()
+
+	// ------------ Test result
+	assert.Equalf("jenkins-infra-master-42", runID, "Expect the run ID given, usable in branch names %s", testCase)
+}
+
+func TestCommitMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	load := func(data string) *Forge {
+		forge := new(Forge)
+		forge.Init()
+		if err := yaml.Unmarshal([]byte(data), forge.yaml); err != nil {
+			t.Fatalf("Unable to load the Forjfile. %s", err)
+		}
+		return forge
+	}
+	data := &CommitMessageData{
+		Action:       "update",
+		Message:      "Forge 'forj' updated.",
+		Organization: "forj",
+		Deployment:   "prod",
+		RunID:        "20180101-120000",
+	}
+	data.AddFiles("github", map[string][]string{"source": {"github.yaml"}})
+	data.AddFiles("jenkins", map[string][]string{"deploy": {"jobs/a.xml"}})
+
+	tests := []struct {
+		testCase string
+		forjfile string
+		expected string
+		fails    bool
+	}{
+		{
+			testCase: "when no template is set",
+			expected: "Forge 'forj' updated.\n\n" +
+				"Forjj-Run: 20180101-120000\nForjj-Deployment: prod\nForjj-Instances: github, jenkins",
+		},
+		{
+			testCase: "when a template is set",
+			forjfile: `
+forj-settings:
+  commit-message: |
+    {{ .Organization }}: {{ .Action }} of {{ .Deployment }}
+
+    {{ range $instance, $files := .Files }}{{ $instance }}: {{ join $files ", " }}
+    {{ end }}
+`,
+			expected: "forj: update of prod\n\ngithub: github.yaml\njenkins: jobs/a.xml\n\n" +
+				"Forjj-Run: 20180101-120000\nForjj-Deployment: prod\nForjj-Instances: github, jenkins",
+		},
+		{
+			testCase: "when the template is invalid",
+			forjfile: `
+forj-settings:
+  commit-message: "{{ .Unknown }"
+`,
+			fails: true,
+		},
+		{
+			testCase: "when the template renders nothing",
+			forjfile: `
+forj-settings:
+  commit-message: "{{ if false }}nothing{{ end }}"
+`,
+			fails: true,
+		},
+	}
+
+	for _, test := range tests {
+		forge := load(test.forjfile)
+
+		// ------------ Run function to test
+		message, err := forge.CommitMessage(data)
+
+		// ------------ Test result
+		if test.fails {
+			assert.Errorf(err, "Expect an error %s", test.testCase)
+			continue
+		}
+		assert.NoErrorf(err, "Expect no error %s", test.testCase)
+		assert.Equalf(test.expected, message, "Expect the commit message %s", test.testCase)
+	}
+}
@@ -0,0 +1,230 @@
+package forjfile
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DeployDiff is a difference found between the merged Forjfile of 2 deployments.
+//
+// Path is the yaml path of the value in the Forjfile. ie [repositories myrepo title]
+// From is the value found in the source deployment. nil if not found.
+// To is the value found in the target deployment. nil if not found.
+type DeployDiff struct {
+	Path []string
+	From interface{}
+	To   interface{}
+}
+
+// DeployDiffs is a list of differences between 2 deployments.
+type DeployDiffs []DeployDiff
+
+// Sections of a deployment Forjfile which are not promoted. Infra can be defined only in the master Forjfile.
+var deployDiffIgnored = map[string]bool{
+	"infra":          true,
+	"local-settings": true,
+}
+
+// Keys which can't be declared in a deployment Forjfile.
+var deployDiffIgnoredKeys = map[string]bool{
+	"deploy-repo-of": true,
+}
+
+// Key return the yaml path of the difference, as '<section>/<instance>/<key>'.
+func (d DeployDiff) Key() string {
+	return strings.Join(d.Path, "/")
+}
+
+// String return the difference as a diff line.
+func (d DeployDiff) String() string {
+	switch {
+	case d.To == nil:
+		return fmt.Sprintf("+ %s: %s", d.Key(), deployDiffValue(d.From))
+	case d.From == nil:
+		return fmt.Sprintf("- %s: %s", d.Key(), deployDiffValue(d.To))
+	default:
+		return fmt.Sprintf("~ %s: %s => %s", d.Key(), deployDiffValue(d.To), deployDiffValue(d.From))
+	}
+}
+
+// Match return true if the difference path starts with one of the selectors.
+//
+// A selector is a yaml path like 'repositories/myrepo' or 'repositories/myrepo/title'.
+func (d DeployDiff) Match(selectors ...string) bool {
+	for _, selector := range selectors {
+		selectorPath := strings.Split(strings.Trim(selector, "/"), "/")
+		if len(selectorPath) > len(d.Path) {
+			continue
+		}
+		if stringListEqual(selectorPath, d.Path[:len(selectorPath)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select return the list of differences which match one of the selectors.
+func (d DeployDiffs) Select(selectors ...string) (ret DeployDiffs) {
+	ret = make(DeployDiffs, 0, len(d))
+	for _, diff := range d {
+		if diff.Match(selectors...) {
+			ret = append(ret, diff)
+		}
+	}
+	return
+}
+
+func deployDiffValue(value interface{}) string {
+	if v, ok := value.(string); ok {
+		return "'" + v + "'"
+	}
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	ret := strings.TrimSpace(string(data))
+	if _, isMap := value.(yaml.MapSlice); isMap || strings.Contains(ret, "\n") {
+		ret = "\n    " + strings.Replace(ret, "\n", "\n    ", -1)
+	}
+	return ret
+}
+
+// diffDeployTrees compare 2 yaml trees and return the differences.
+func diffDeployTrees(path []string, from, to yaml.MapSlice) (ret DeployDiffs) {
+	for _, item := range from {
+		key := fmt.Sprintf("%v", item.Key)
+		if (len(path) == 0 && deployDiffIgnored[key]) || deployDiffIgnoredKeys[key] {
+			continue
+		}
+		itemPath := append(append([]string{}, path...), key)
+		toValue, found := mapSliceGet(to, key)
+		if !found {
+			ret = append(ret, DeployDiff{Path: itemPath, From: item.Value})
+			continue
+		}
+		fromMap, isFromMap := item.Value.(yaml.MapSlice)
+		toMap, isToMap := toValue.(yaml.MapSlice)
+		if isFromMap && isToMap {
+			ret = append(ret, diffDeployTrees(itemPath, fromMap, toMap)...)
+			continue
+		}
+		if !reflect.DeepEqual(item.Value, toValue) {
+			ret = append(ret, DeployDiff{Path: itemPath, From: item.Value, To: toValue})
+		}
+	}
+	for _, item := range to {
+		key := fmt.Sprintf("%v", item.Key)
+		if (len(path) == 0 && deployDiffIgnored[key]) || deployDiffIgnoredKeys[key] {
+			continue
+		}
+		if _, found := mapSliceGet(from, key); !found {
+			ret = append(ret, DeployDiff{Path: append(append([]string{}, path...), key), To: item.Value})
+		}
+	}
+	return
+}
+
+// applyDeployDiff update a deployment Forjfile tree (overlay) to get the source value of the difference
+// once merged with the master Forjfile tree.
+func applyDeployDiff(overlay, master yaml.MapSlice, diff DeployDiff) (yaml.MapSlice, error) {
+	masterValue, inMaster := mapSliceGetPath(master, diff.Path)
+	_, inOverlay := mapSliceGetPath(overlay, diff.Path)
+
+	if diff.From == nil {
+		if !inMaster {
+			if !inOverlay {
+				return overlay, fmt.Errorf("Unable to remove '%s'. It is not defined by the master or the deployment Forjfile", diff.Key())
+			}
+			return mapSliceDeletePath(overlay, diff.Path), nil
+		}
+		if diff.Path[0] == "forj-settings" {
+			return overlay, fmt.Errorf("Unable to remove '%s'. forj-settings keys can't be deleted by a deployment Forjfile", diff.Key())
+		}
+		return mapSliceSetPath(overlay, diff.Path, mergeDelete), nil
+	}
+
+	if inMaster && reflect.DeepEqual(masterValue, diff.From) {
+		if inOverlay {
+			return mapSliceDeletePath(overlay, diff.Path), nil
+		}
+		return overlay, nil
+	}
+
+	value := diff.From
+	if inMaster {
+		switch v := value.(type) {
+		case []interface{}:
+			// Lists are merged with the master one. So, we need to replace it.
+			value = append([]interface{}{mergeReplace}, v...)
+		case yaml.MapSlice:
+			// Instances are merged with the master one. So, we need to replace it.
+			if len(diff.Path) == 2 && diff.Path[0] != "forj-settings" {
+				value = append(yaml.MapSlice{{Key: mergeReplace, Value: true}}, v...)
+			}
+		}
+	}
+	return mapSliceSetPath(overlay, diff.Path, value), nil
+}
+
+func mapSliceGet(data yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range data {
+		if fmt.Sprintf("%v", item.Key) == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+func mapSliceGetPath(data yaml.MapSlice, path []string) (value interface{}, found bool) {
+	value = data
+	for _, key := range path {
+		current, isMap := value.(yaml.MapSlice)
+		if !isMap {
+			return nil, false
+		}
+		if value, found = mapSliceGet(current, key); !found {
+			return
+		}
+	}
+	return
+}
+
+// mapSliceSetPath set the value at path. Missing maps are created.
+func mapSliceSetPath(data yaml.MapSlice, path []string, value interface{}) yaml.MapSlice {
+	if len(path) > 1 {
+		child, _ := mapSliceGet(data, path[0])
+		childMap, _ := child.(yaml.MapSlice)
+		value = mapSliceSetPath(childMap, path[1:], value)
+	}
+	for index, item := range data {
+		if fmt.Sprintf("%v", item.Key) == path[0] {
+			data[index].Value = value
+			return data
+		}
+	}
+	return append(data, yaml.MapItem{Key: path[0], Value: value})
+}
+
+// mapSliceDeletePath remove the value at path. Maps left empty are removed.
+func mapSliceDeletePath(data yaml.MapSlice, path []string) yaml.MapSlice {
+	for index, item := range data {
+		if fmt.Sprintf("%v", item.Key) != path[0] {
+			continue
+		}
+		if len(path) > 1 {
+			childMap, isMap := item.Value.(yaml.MapSlice)
+			if !isMap {
+				return data
+			}
+			if childMap = mapSliceDeletePath(childMap, path[1:]); len(childMap) > 0 {
+				data[index].Value = childMap
+				return data
+			}
+		}
+		return append(data[:index], data[index+1:]...)
+	}
+	return data
+}
@@ -195,6 +195,86 @@ func (f *DeployForgeYaml) Get(object, instance, key string) (value *goforjj.Valu
 	return
 }
 
+// GetSourcesChain return the list of sources which updated the object instance key, in order of update.
+func (f *DeployForgeYaml) GetSourcesChain(object, instance, key string) (_ []sourcesinfo.Source) {
+	if !f.init() {
+		return
+	}
+	switch object {
+	case "infra":
+		return f.Infra.sources.Chain(key)
+	case "user":
+		if user, found := f.Users[instance]; found && user != nil {
+			return user.sources.Chain(key)
+		}
+	case "group":
+		if group, found := f.Groups[instance]; found && group != nil {
+			return group.sources.Chain(key)
+		}
+	case "app":
+		if app, found := f.Apps[instance]; found && app != nil {
+			return app.sources.Chain(key)
+		}
+	case "repo":
+		if repo, found := f.Repos[instance]; found && repo != nil {
+			return repo.sources.Chain(key)
+		}
+	case "settings":
+		if instance == "default" {
+			return f.ForjSettings.Default.sources.Chain(key)
+		}
+		return f.ForjSettings.sources.Chain(key)
+	default:
+		return f.sources.Chain(object + "/" + instance + "/" + key)
+	}
+	return
+}
+
+// GetKeys return the list of keys known in an object instance.
+func (f *DeployForgeYaml) GetKeys(object, instance string) (_ []string) {
+	if !f.init() {
+		return
+	}
+	switch object {
+	case "infra":
+		return f.Infra.Flags()
+	case "user":
+		if user, found := f.Users[instance]; found && user != nil {
+			return user.Flags()
+		}
+	case "group":
+		if group, found := f.Groups[instance]; found && group != nil {
+			return group.Flags()
+		}
+	case "app":
+		if app, found := f.Apps[instance]; found && app != nil {
+			return app.Flags()
+		}
+	case "repo":
+		if repo, found := f.Repos[instance]; found && repo != nil {
+			return repo.Flags()
+		}
+	case "settings":
+		if instance == "default" {
+			return f.ForjSettings.Default.Flags()
+		}
+		return f.ForjSettings.Flags()
+	default:
+		if instances, found := f.More[object]; found {
+			if keys, found := instances[instance]; found {
+				ret := make([]string, 0, len(keys))
+				for key := range keys {
+					if key != mergeDelete && key != mergeReplace {
+						ret = append(ret, key)
+					}
+				}
+				return ret
+			}
+		}
+	}
+	return
+}
+
 // Set a value to the object instance key.
 func (f *DeployForgeYaml) Set(source, object, name, key, value string) {
 	from := func(string) (string, bool) {
@@ -383,9 +463,10 @@ func (f *DeployForgeYaml) NewRepoStruct(name string) (repo *RepoStruct) {
 
 func (f *DeployForgeYaml) get(object, instance, key string) (value *goforjj.ValueStruct, found bool, source string) {
 	if obj, f1 := f.More[object]; f1 {
-		if instance, f2 := obj[instance]; f2 {
-			v, f3 := instance[key]
+		if instanceData, f2 := obj[instance]; f2 {
+			v, f3 := instanceData[key]
 			value, found = value.SetIfFound(v.Get(), f3)
+			source = f.sources.Get(object + "/" + instance + "/" + key)
 		}
 	}
 	return
@@ -420,14 +501,16 @@ func (f *DeployForgeYaml) setHandler(source, object, instance string, from func(
 			set(&v, value)
 			instanceData[key] = v
 			f.forge.updated = true
-		} else {
-			if !found {
-				set(&v, value)
-				instanceData[key] = v
-				f.forge.updated = true
-			}
-			f.sources.Set(source, key, v.Get())
+		} else if !found {
+			set(&v, value)
+			instanceData[key] = v
+			f.forge.updated = true
 		}
+		if v := instanceData[key]; !v.selected(value) { // A default value not applied is not in the chain of sources.
+			f.sources = f.sources.SetUnchained(source, object+"/"+instance+"/"+key, value)
+			continue
+		}
+		f.sources = f.sources.Set(source, object+"/"+instance+"/"+key, value)
 	}
 }
 
@@ -476,8 +559,23 @@ func (f *DeployForgeYaml) mergeFrom(from *DeployForgeYaml) error {
 		f.Infra = f.Repos[from.Infra.name]
 	}
 	// if infra requires to be defined, it MUST be in the main Forjfile. No Infra merge is made from deployment.
-	for k, v := range from.More {
-		f.More[k] = v
+	for object, instances := range from.More {
+		to := f.More[object]
+		if to == nil {
+			to = make(map[string]ForjValues)
+			f.More[object] = to
+		}
+		for name, values := range instances {
+			if values.isMergeDelete() {
+				delete(to, name)
+				continue
+			}
+			if toValues, found := to[name]; found && toValues != nil && !values.isMergeReplace() {
+				toValues.mergeFrom(values)
+			} else {
+				to[name] = values.withoutMergeDirectives()
+			}
+		}
 	}
 	return nil
 }
@@ -0,0 +1,44 @@
+package forjfile
+
+import (
+	"testing"
+
+	sourcesinfo "forjj/sources_info"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeployForgeYamlSourcesChain(t *testing.T) {
+	assert := assert.New(t)
+
+	f := NewDeployForgeYaml()
+	f.initDefaults(NewForgeYaml())
+
+	/*********************************/
+	testCase := "when a default value is hidden by a value"
+
+	// ------------ Run function to test
+	f.Set("forjfile", "app", "jenkins", "seed", "my-seed")
+	f.SetDefault("plugin-default", "app", "jenkins", "seed", "default-seed")
+	f.Set("forjfile", "plugin", "jenkins", "port", "8080")
+	f.SetDefault("plugin-default", "plugin", "jenkins", "port", "80")
+
+	// ------------ Test result
+	assert.Equalf([]sourcesinfo.Source{{Source: "forjfile", Value: "my-seed"}}, f.GetSourcesChain("app", "jenkins", "seed"),
+		"Expect the default not in the app chain %s", testCase)
+	assert.Equalf([]sourcesinfo.Source{{Source: "forjfile", Value: "8080"}}, f.GetSourcesChain("plugin", "jenkins", "port"),
+		"Expect the default not in the object chain %s", testCase)
+
+	/*********************************/
+	testCase = "when a default value is applied"
+
+	// ------------ Run function to test
+	f.SetDefault("plugin-default", "app", "jenkins", "admin", "admin")
+	f.SetDefault("plugin-default", "plugin", "jenkins", "host", "localhost")
+
+	// ------------ Test result
+	assert.Equalf([]sourcesinfo.Source{{Source: "plugin-default", Value: "admin"}}, f.GetSourcesChain("app", "jenkins", "admin"),
+		"Expect the default in the app chain %s", testCase)
+	assert.Equalf([]sourcesinfo.Source{{Source: "plugin-default", Value: "localhost"}}, f.GetSourcesChain("plugin", "jenkins", "host"),
+		"Expect the default in the object chain %s", testCase)
+}
@@ -0,0 +1,459 @@
+package forjfile
+
+import (
+	"fmt"
+	"forjj/git"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/forj-oss/forjj-modules/trace"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// approvalsFile is the approval file name stored in deployments/<deployName>/ of the infra repo.
+	approvalsFile = "approvals.yaml"
+)
+
+// approvalsYaml is the content of an approval file.
+//
+// approvals:
+//   - user: <user>
+//     commit: <infra commit approved>
+//     approvers: true # Set it to approve a change of the approvers.
+type approvalsYaml struct {
+	Approvals []approvalYaml
+}
+
+// approvalYaml is an approval of an infra commit by a user.
+//
+// Approvers is true if the approval covers a change of the approvers group, the approvers signing keys or the
+// deployment type approval policy.
+type approvalYaml struct {
+	User      string
+	Commit    string
+	Approvers bool `yaml:",omitempty"`
+}
+
+// approvalsPolicy is the approval policy of a deployment, as declared in a Forjfile.
+type approvalsPolicy struct {
+	deployType  string
+	required    int
+	group       string
+	members     []string
+	signingKeys map[string][]string
+}
+
+// changeCommit is a commit of the change approved, with his author and signature.
+type changeCommit struct {
+	email       string // %ae
+	status      string // %G?
+	signingKey  string // %GK
+	fingerprint string // %GF
+}
+
+// CheckPublishApprovals verify that the deployment can be published.
+//
+// If the deployment type requires some approvals, the infra repository HEAD must be approved by enough members
+// of the approvers group, with an entry in deployments/<deployName>/approvals.yaml:
+//   - The entry must be added by a commit signed with a key declared in the approver 'signing-key' user field.
+//   - The approved commit must be HEAD or only followed by commits updating the approval file.
+//   - The approver must not be an author of the change approved.
+//
+// The approval policy, the approvers group members and their signing keys are read from the trusted base: the
+// infra commit merged with the upstream 'master' branch. The change approved is made of the commits from this
+// base. If the change updates the approval policy, approvers or their keys, approvals must set 'approvers: true'.
+//
+// Signatures are verified by gpg or ssh-keygen through git (%G? and %GK/%GF log formats), with both git backends.
+func (f *Forge) CheckPublishApprovals(deployTo string) error {
+	deploy, found := f.GetADeployment(deployTo)
+	if !found {
+		return fmt.Errorf("Deployment '%s' not defined", deployTo)
+	}
+	current := f.approvalsPolicy(deployTo, deploy.Type)
+
+	file := path.Join("deployments", deployTo, approvalsFile)
+	var (
+		trusted   approvalsPolicy
+		base      string
+		approvals []approvalYaml
+	)
+	err := git.RunInPath(f.infra_path, func() (err error) {
+		if base, err = approvalsBase(); err != nil && current.required == 0 {
+			// Without upstream, nothing is trusted. A Forjfile which doesn't require approvals is published.
+			gotrace.Trace("%s", err)
+			return nil
+		} else if err != nil {
+			return
+		}
+		if trusted, err = f.commitApprovalsPolicy(base, deployTo, deploy.Type); err != nil || trusted.required == 0 {
+			return
+		}
+		approvals, err = signedFileApprovers(file, base, trusted.signingKeys)
+		return
+	})
+	if err != nil {
+		return err
+	}
+	if trusted.required == 0 {
+		return nil
+	}
+
+	approvers := make(map[string]bool)
+	changeApprovers := make(map[string]bool)
+	for _, approval := range approvals {
+		approvers[approval.User] = true
+		changeApprovers[approval.User] = approval.Approvers
+	}
+
+	approved := countApprovals(approvers, trusted.members)
+	if len(approved) < trusted.required {
+		return fmt.Errorf("Publishing to deployment '%s' (type '%s') requires %d approval(s) from members of group '%s'. Found %d. "+
+			"Each approver must add an entry in '%s' with a commit signed with a key declared in their user '%s' field. "+
+			"Authors of the change can't approve it",
+			deployTo, trusted.deployType, trusted.required, trusted.group, len(approved), file, UserSigningKey)
+	}
+	if !trusted.equal(current) {
+		if approved := countApprovals(changeApprovers, trusted.members); len(approved) < trusted.required {
+			return fmt.Errorf("The change updates the approvers of deployment '%s' (type '%s'): approval policy, group '%s' "+
+				"or members '%s' field. It requires %d approval(s) with 'approvers: true' in '%s'. Found %d",
+				deployTo, trusted.deployType, trusted.group, UserSigningKey, trusted.required, file, len(approved))
+		}
+	}
+	gotrace.Info("Deployment '%s' publish approved by '%s'.", deployTo, strings.Join(approved, "', '"))
+	return nil
+}
+
+// approvalsBase return the trusted infra commit: the merge base of HEAD with the upstream 'master' branch.
+//
+// It must be called from the infra repository root.
+func approvalsBase() (string, error) {
+	remote, _ := git.Get("config", "branch.master.remote")
+	if remote == "" {
+		remote = "origin"
+	}
+	upstream := remote + "/master"
+	base, err := git.Get("merge-base", "HEAD", upstream)
+	if err != nil || base == "" {
+		return "", fmt.Errorf("Unable to find the infra commit approvers are read from. HEAD must be based on '%s'", upstream)
+	}
+	return base, nil
+}
+
+// commitApprovalsPolicy return the approval policy of the deployment given, from the Forjfiles of an infra commit.
+//
+// If the deployment doesn't exist in this commit, deployType is used.
+// It must be called from the infra repository root.
+func (f *Forge) commitApprovalsPolicy(commit, deployTo, deployType string) (policy approvalsPolicy, err error) {
+	dir, err := ioutil.TempDir("", "forjj-approvers")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	base := &Forge{infra_path: dir, file_name: f.Forjfile_name()}
+	data, err := commitFile(commit, base.file_name, dir)
+	if err != nil {
+		return policy, fmt.Errorf("Unable to read the Forjfile of '%s'. %s", commit, err)
+	}
+	var master ForgeYaml
+	if err = yaml.Unmarshal(data, &master); err != nil {
+		return policy, fmt.Errorf("Unable to load the Forjfile of '%s'. %s", commit, err)
+	}
+	for name := range master.Deployments {
+		if _, err = commitFile(commit, path.Join("deployments", name, base.file_name), dir); err != nil {
+			return policy, fmt.Errorf("Unable to read the deployment '%s' Forjfile of '%s'. %s", name, commit, err)
+		}
+	}
+
+	if _, err = base.Load("global"); err != nil {
+		return policy, fmt.Errorf("Unable to load the Forjfiles of '%s'. %s", commit, err)
+	}
+	if _, found := base.GetADeployment(deployTo); found {
+		base.SetDeployment(deployTo)
+		if err = base.BuildForjfileInMem(); err != nil {
+			return
+		}
+	}
+	return base.approvalsPolicy(deployTo, deployType), nil
+}
+
+// commitFile write a file of an infra commit in dir, and return his content.
+func commitFile(commit, file, dir string) (data []byte, err error) {
+	content, err := git.Get("show", commit+":"+file)
+	if err != nil {
+		return
+	}
+	data = []byte(content + "\n")
+	if err = os.MkdirAll(path.Dir(path.Join(dir, file)), 0755); err != nil {
+		return
+	}
+	err = ioutil.WriteFile(path.Join(dir, file), data, 0644)
+	return
+}
+
+// approvalsPolicy return the approval policy of a deployment. If the deployment doesn't exist, deployType is used.
+func (f *Forge) approvalsPolicy(deployTo, deployType string) (policy approvalsPolicy) {
+	if deploy, found := f.GetADeployment(deployTo); found {
+		deployType = deploy.Type
+	}
+	policy.deployType = deployType
+	policyType, _ := f.GetDeploymentTypePolicy(deployType)
+	policy.required = policyType.GetRequiredApprovals()
+	if policyType != nil {
+		policy.group = policyType.ApproversGroup
+	}
+	policy.members = f.approvers(policyType)
+	policy.signingKeys = f.signingKeys(policy.members)
+	return
+}
+
+// equal return true if both policies have the same approval rules, approvers and signing keys.
+func (p approvalsPolicy) equal(other approvalsPolicy) bool {
+	if p.deployType != other.deployType || p.required != other.required || p.group != other.group ||
+		!sameStrings(p.members, other.members) || len(p.signingKeys) != len(other.signingKeys) {
+		return false
+	}
+	for user, keys := range p.signingKeys {
+		if otherKeys, found := other.signingKeys[user]; !found || !sameStrings(keys, otherKeys) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameStrings return true if both lists have the same strings, in any order.
+func sameStrings(list, other []string) bool {
+	if len(list) != len(other) {
+		return false
+	}
+	sorted := append([]string{}, list...)
+	otherSorted := append([]string{}, other...)
+	sort.Strings(sorted)
+	sort.Strings(otherSorted)
+	for index := range sorted {
+		if sorted[index] != otherSorted[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// approvers return the list of members of the deployment type approvers group.
+func (f *Forge) approvers(deployType *DeploymentTypeStruct) (_ []string) {
+	forge := f.selectCore()
+	if forge == nil || deployType == nil {
+		return
+	}
+	if group, found := forge.Groups[deployType.ApproversGroup]; found && group != nil {
+		return group.GetMembers()
+	}
+	return
+}
+
+// signingKeys return the signing keys declared by each user given.
+//
+// The 'signing-key' user field can contain several keys, separated by spaces or commas.
+func (f *Forge) signingKeys(users []string) (ret map[string][]string) {
+	ret = make(map[string][]string)
+	forge := f.selectCore()
+	if forge == nil {
+		return
+	}
+	for _, name := range users {
+		if user, found := forge.Users[name]; found && user != nil {
+			ret[name] = strings.Fields(strings.Replace(user.More[UserSigningKey], ",", " ", -1))
+		}
+	}
+	return
+}
+
+// countApprovals return the sorted list of approvers which are members of the approvers group.
+func countApprovals(approvers map[string]bool, members []string) (ret []string) {
+	for _, member := range members {
+		if approvers[member] && indexOfString(ret, member) == -1 {
+			ret = append(ret, member)
+		}
+	}
+	sort.Strings(ret)
+	return
+}
+
+// signedFileApprovers return the approvals of HEAD found in the approval file.
+//
+// An approval is counted only if it was added by a commit with a good signature, made with one of the approver
+// signing keys. Approvals of a commit followed by other changes than the approval file are outdated.
+// Approvals from an author of the change, ie the commits from the trusted base to the commit approved, are refused.
+// It must be called from the infra repository root.
+func signedFileApprovers(file, base string, signingKeys map[string][]string) (approved []approvalYaml, _ error) {
+	if _, err := os.Stat(file); err != nil {
+		return
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	approvals, err := loadApprovals(data)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load '%s'. %s", file, err)
+	}
+
+	verified, err := verifiedApprovals(file, signingKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string][]changeCommit)
+	for _, approval := range approvals {
+		if approval.User == "" || approval.Commit == "" {
+			continue
+		}
+		approverEmail, found := verified[approval]
+		if !found {
+			gotrace.Warning("'%s': approval of '%s' by '%s' ignored. It was not added by a commit signed with a '%s' signing key.",
+				file, approval.Commit, approval.User, approval.User)
+			continue
+		}
+		changed, err := git.Get("diff", "--name-only", approval.Commit, "HEAD")
+		if err != nil {
+			gotrace.Warning("'%s': approval of '%s' by '%s' ignored. Unknown commit.", file, approval.Commit, approval.User)
+			continue
+		}
+		if changed != "" && changed != file {
+			gotrace.Trace("'%s': approval of '%s' by '%s' is outdated.", file, approval.Commit, approval.User)
+			continue
+		}
+		if _, found := changes[approval.Commit]; !found {
+			if changes[approval.Commit], err = changeCommits(base, approval.Commit, file); err != nil {
+				return nil, err
+			}
+		}
+		if isChangeAuthor(changes[approval.Commit], signingKeys[approval.User], approverEmail) {
+			gotrace.Warning("'%s': approval of '%s' by '%s' ignored. '%s' is an author of the change.",
+				file, approval.Commit, approval.User, approval.User)
+			continue
+		}
+		approved = append(approved, approval)
+	}
+	return
+}
+
+// changeCommits return the commits of the change approved: from the trusted base to the commit approved.
+//
+// Commits updating only the approval file are approvals, not changes.
+func changeCommits(base, commit, file string) (changes []changeCommit, _ error) {
+	commits, err := git.Get("log", "--format=%H%x09%ae%x09%G?%x09%GK%x09%GF", base+".."+commit)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the change approved by '%s'. %s", commit, err)
+	}
+	for _, line := range strings.Split(commits, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 {
+			continue
+		}
+		if changed, err := git.Get("diff", "--name-only", fields[0]+"^", fields[0]); err == nil && changed == file {
+			continue
+		}
+		changes = append(changes, changeCommit{email: fields[1], status: fields[2], signingKey: fields[3], fingerprint: fields[4]})
+	}
+	return
+}
+
+// isChangeAuthor return true if a change commit was signed with one of the user keys, or was authored with the
+// email of the approval commit author.
+func isChangeAuthor(changes []changeCommit, userKeys []string, approverEmail string) bool {
+	for _, change := range changes {
+		if approverEmail != "" && strings.EqualFold(change.email, approverEmail) {
+			return true
+		}
+		if (change.status == "G" || change.status == "U") && signedBy(userKeys, change.signingKey, change.fingerprint) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifiedApprovals return the approvals added to the approval file by a commit signed by the approver, with the
+// email of the commit author.
+//
+// Each commit updating the file is verified. Approvals added by a commit with a good signature ('G' or 'U') are
+// verified if the signing key (%GK or %GF) is one of the approver signing keys.
+func verifiedApprovals(file string, signingKeys map[string][]string) (verified map[approvalYaml]string, _ error) {
+	verified = make(map[approvalYaml]string)
+	commits, err := git.Get("log", "--format=%H%x09%G?%x09%GK%x09%GF%x09%ae", "--", file)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to verify '%s' signatures. %s", file, err)
+	}
+	for _, line := range strings.Split(commits, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 || (fields[1] != "G" && fields[1] != "U") {
+			continue
+		}
+		commit, signingKey, fingerprint, email := fields[0], fields[2], fields[3], fields[4]
+
+		after, _ := git.Get("show", commit+":"+file)
+		before, _ := git.Get("show", commit+"^:"+file) // Not found if the commit created the file.
+		for _, approval := range addedApprovals(before, after) {
+			if signedBy(signingKeys[approval.User], signingKey, fingerprint) {
+				verified[approval] = email
+			}
+		}
+	}
+	return
+}
+
+// loadApprovals return the approvals of an approval file content.
+func loadApprovals(data []byte) (_ []approvalYaml, err error) {
+	var approvals approvalsYaml
+	if err = yaml.Unmarshal(data, &approvals); err != nil {
+		return
+	}
+	return approvals.Approvals, nil
+}
+
+// addedApprovals return the approvals found in the 'after' approval file content and not in the 'before' one.
+// A content which can't be loaded has no approvals.
+func addedApprovals(before, after string) (added []approvalYaml) {
+	previous := make(map[approvalYaml]bool)
+	if approvals, err := loadApprovals([]byte(before)); err == nil {
+		for _, approval := range approvals {
+			previous[approval] = true
+		}
+	}
+	approvals, err := loadApprovals([]byte(after))
+	if err != nil {
+		return
+	}
+	for _, approval := range approvals {
+		if !previous[approval] {
+			added = append(added, approval)
+		}
+	}
+	return
+}
+
+// signedBy return true if the commit signing key or fingerprint is one of the user keys.
+//
+// A GPG key is declared with his fingerprint or his long key ID (16 hexadecimal characters at least). An SSH key
+// is declared with his fingerprint ('SHA256:...').
+func signedBy(userKeys []string, signingKey, fingerprint string) bool {
+	for _, key := range userKeys {
+		if strings.HasPrefix(key, "SHA256:") {
+			if key == signingKey || key == fingerprint {
+				return true
+			}
+			continue
+		}
+		if key = strings.ToUpper(key); len(key) < 16 || strings.Trim(key, "0123456789ABCDEF") != "" {
+			continue
+		}
+		if key == strings.ToUpper(signingKey) || strings.HasSuffix(strings.ToUpper(fingerprint), key) {
+			return true
+		}
+	}
+	return false
+}
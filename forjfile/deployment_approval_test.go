@@ -0,0 +1,315 @@
+package forjfile
+
+import (
+	"fmt"
+	"forjj/git"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddedApprovals(t *testing.T) {
+	assert := assert.New(t)
+
+	testCase := "when an approval is added to the approval file"
+	before := "approvals:\n- user: user1\n  commit: abc\n"
+	after := before + "- user: user2\n  commit: abc\n"
+
+	// ------------ Run function to test
+	added := addedApprovals(before, after)
+
+	// ------------ Test result
+	assert.Equalf([]approvalYaml{{User: "user2", Commit: "abc"}}, added, "Expect only the new approval %s", testCase)
+
+	testCase = "when the approval file is created"
+	assert.Lenf(addedApprovals("", after), 2, "Expect all approvals %s", testCase)
+
+	testCase = "when an approval is removed"
+	assert.Emptyf(addedApprovals(after, before), "Expect no approvals %s", testCase)
+}
+
+func TestSignedBy(t *testing.T) {
+	assert := assert.New(t)
+
+	const (
+		fingerprint = "0123456789ABCDEF0123456789ABCDEF01234567"
+		keyID       = "89ABCDEF01234567"
+		sshKey      = "SHA256:AbCdEfGhIjKlMnOpQrStUvWxYz0123456789+/abcd"
+	)
+
+	testCase := "when the commit is signed with a user GPG key"
+	assert.Truef(signedBy([]string{fingerprint}, keyID, fingerprint), "Expect fingerprint to match %s", testCase)
+	assert.Truef(signedBy([]string{"89abcdef01234567"}, keyID, fingerprint), "Expect long key ID to match %s", testCase)
+
+	testCase = "when the user key is a short key ID"
+	assert.Falsef(signedBy([]string{"01234567"}, keyID, fingerprint), "Expect short key ID to be refused %s", testCase)
+
+	testCase = "when the commit is signed with a user SSH key"
+	assert.Truef(signedBy([]string{"other", sshKey}, sshKey, ""), "Expect SSH fingerprint to match %s", testCase)
+	assert.Falsef(signedBy([]string{strings.ToLower(sshKey)}, sshKey, ""), "Expect SSH fingerprint to be case sensitive %s", testCase)
+
+	testCase = "when the commit is signed with another key"
+	assert.Falsef(signedBy([]string{fingerprint}, "FEDCBA9876543210", "FEDCBA9876543210FEDCBA9876543210FEDCBA98"), "Expect no match %s", testCase)
+	assert.Falsef(signedBy(nil, keyID, fingerprint), "Expect no match without user keys %s", testCase)
+}
+
+func TestCountApprovals(t *testing.T) {
+	assert := assert.New(t)
+
+	testCase := "when some approvers are not members of the approvers group"
+	approvers := map[string]bool{"user1": true, "user3": true, "user4": true}
+
+	// ------------ Run function to test
+	approved := countApprovals(approvers, []string{"user3", "user1", "user2"})
+
+	// ------------ Test result
+	assert.Equalf([]string{"user1", "user3"}, approved, "Expect only group members to be counted %s", testCase)
+}
+
+func TestDeploymentTypeCheckApprovers(t *testing.T) {
+	assert := assert.New(t)
+
+	deployType := &DeploymentTypeStruct{name: "PRO", RequiredApprovals: 1}
+	groups := GroupsStruct{"approvers": &GroupStruct{Members: []string{"user1"}}}
+
+	testCase := "when no approvers group is set"
+	assert.Errorf(deployType.checkApprovers(groups), "Expect an error %s", testCase)
+
+	testCase = "when the approvers group doesn't exist"
+	deployType.ApproversGroup = "admins"
+	assert.Errorf(deployType.checkApprovers(groups), "Expect an error %s", testCase)
+
+	testCase = "when the approvers group exists"
+	deployType.ApproversGroup = "approvers"
+	assert.NoErrorf(deployType.checkApprovers(groups), "Expect no error %s", testCase)
+
+	testCase = "when no approvals are required"
+	assert.NoErrorf((&DeploymentTypeStruct{}).checkApprovers(nil), "Expect no error %s", testCase)
+}
+
+func TestIsChangeAuthor(t *testing.T) {
+	assert := assert.New(t)
+
+	const sshKey = "SHA256:AbCdEfGhIjKlMnOpQrStUvWxYz0123456789+/abcd"
+	changes := []changeCommit{
+		{email: "author@forjj.io", status: "N"},
+		{email: "other@forjj.io", status: "G", signingKey: sshKey, fingerprint: sshKey},
+	}
+
+	testCase := "when the approver authored a change commit"
+	assert.Truef(isChangeAuthor(changes, nil, "Author@forjj.io"), "Expect the approver to be an author %s", testCase)
+
+	testCase = "when the approver signed a change commit"
+	assert.Truef(isChangeAuthor(changes, []string{sshKey}, "approver@forjj.io"), "Expect the approver to be an author %s", testCase)
+
+	testCase = "when the approver didn't author the change"
+	assert.Falsef(isChangeAuthor(changes, []string{"SHA256:other"}, "approver@forjj.io"), "Expect the approver to not be an author %s", testCase)
+	assert.Falsef(isChangeAuthor(nil, []string{sshKey}, "author@forjj.io"), "Expect no author without change %s", testCase)
+}
+
+func TestApprovalsPolicyEqual(t *testing.T) {
+	assert := assert.New(t)
+
+	policy := approvalsPolicy{deployType: "PRO", required: 1, group: "approvers", members: []string{"user1", "user2"},
+		signingKeys: map[string][]string{"user1": {"key1"}, "user2": {"key2", "key3"}}}
+
+	testCase := "when members and keys are in another order"
+	other := policy
+	other.members = []string{"user2", "user1"}
+	other.signingKeys = map[string][]string{"user1": {"key1"}, "user2": {"key3", "key2"}}
+	assert.Truef(policy.equal(other), "Expect policies to be equal %s", testCase)
+
+	testCase = "when an approver is added"
+	other = policy
+	other.members = []string{"user1", "user2", "user3"}
+	other.signingKeys = map[string][]string{"user1": {"key1"}, "user2": {"key2", "key3"}, "user3": {"key2"}}
+	assert.Falsef(policy.equal(other), "Expect policies to differ %s", testCase)
+
+	testCase = "when an approver signing key is updated"
+	other = policy
+	other.signingKeys = map[string][]string{"user1": {"key2"}, "user2": {"key2", "key3"}}
+	assert.Falsef(policy.equal(other), "Expect policies to differ %s", testCase)
+
+	testCase = "when the required approvals are updated"
+	other = policy
+	other.required = 0
+	assert.Falsef(policy.equal(other), "Expect policies to differ %s", testCase)
+}
+
+func TestCheckPublishApprovals(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is required to sign commits with SSH")
+	}
+	defer git.SetBackend("")
+	defer git.SetSigning("", "", "")
+
+	for _, backend := range []string{git.CliBackend, git.GoGitBackend} {
+		if err := git.SetBackend(backend); err != nil {
+			t.Fatalf("Unable to select the git backend '%s'. %s", backend, err)
+		}
+		t.Run(backend, testCheckPublishApprovals)
+	}
+}
+
+func testCheckPublishApprovals(t *testing.T) {
+	assert := assert.New(t)
+
+	tmp, _ := ioutil.TempDir("", "forjj-approvals")
+	defer os.RemoveAll(tmp)
+	infra, upstream := path.Join(tmp, "infra"), path.Join(tmp, "upstream")
+	if out, err := exec.Command("git", "init", "-q", "--bare", upstream).CombinedOutput(); err != nil {
+		t.Fatalf("Unable to create the upstream repository. %s: %s", err, out)
+	}
+
+	// user1 and user2 are approvers. user2 authors changes.
+	keys, fingerprints := make(map[string]string), make(map[string]string)
+	allowedSigners := ""
+	for _, user := range []string{"user1", "user2"} {
+		keys[user] = path.Join(tmp, user)
+		if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", user, "-f", keys[user]).CombinedOutput(); err != nil {
+			t.Fatalf("Unable to create a SSH key. %s: %s", err, out)
+		}
+		out, _ := exec.Command("ssh-keygen", "-lf", keys[user]+".pub").Output()
+		fingerprints[user] = strings.Fields(string(out))[1]
+		publicKey, _ := ioutil.ReadFile(keys[user] + ".pub")
+		allowedSigners += user + "@forjj.io " + string(publicKey)
+	}
+	ioutil.WriteFile(path.Join(tmp, "allowed_signers"), []byte(allowedSigners), 0644)
+
+	forjfile := func(members, user1Key, more string) string {
+		return fmt.Sprintf(`forj-settings:
+  deployment-types:
+    PRO:
+      required-approvals: 1
+      approvers-group: approvers
+deployments:
+  prod:
+    type: PRO
+groups:
+  approvers:
+    members: [ %s ]
+users:
+  user1:
+    signing-key: %s
+  user2:
+    signing-key: %s
+%s`, members, user1Key, fingerprints["user2"], more)
+	}
+	commit := func(user, file, content, msg string) (hash string) {
+		git.RunInPath(infra, func() error {
+			os.MkdirAll(path.Dir(path.Join(infra, file)), 0755)
+			ioutil.WriteFile(path.Join(infra, file), []byte(content), 0644)
+			git.Add([]string{file})
+			git.Do("config", "user.email", user+"@forjj.io")
+			if user == "user3" { // Fake user signing with the user2 key.
+				user = "user2"
+			}
+			git.SetSigning(git.SigningSSH, keys[user], "")
+			git.Commit(msg, true)
+			hash, _ = git.Get("rev-parse", "HEAD")
+			return nil
+		})
+		return
+	}
+	approve := func(user, approved string, approvers bool) {
+		file := path.Join("deployments", "prod", approvalsFile)
+		data, _ := ioutil.ReadFile(path.Join(infra, file))
+		if len(data) == 0 {
+			data = []byte("approvals:\n")
+		}
+		data = append(data, fmt.Sprintf("- user: %s\n  commit: %s\n  approvers: %t\n", user, approved, approvers)...)
+		commit(user, file, string(data), "approve "+approved)
+	}
+	check := func() error {
+		f := new(Forge)
+		if err := f.SetInfraPath(infra, false); err != nil {
+			return err
+		}
+		if _, err := f.Load("prod"); err != nil {
+			return err
+		}
+		if err := f.BuildForjfileInMem(); err != nil {
+			return err
+		}
+		return f.CheckPublishApprovals("prod")
+	}
+
+	os.MkdirAll(infra, 0755)
+	git.RunInPath(infra, func() error {
+		git.Do("init", "-q")
+		git.Do("config", "user.name", "test")
+		git.Do("config", "gpg.ssh.allowedSignersFile", path.Join(tmp, "allowed_signers"))
+		git.Do("checkout", "-q", "-b", "master")
+		return nil
+	})
+	commit("user1", "deployments/prod/Forjfile", "{}\n", "prod deployment")
+	commit("user1", "Forjfile", forjfile("user1, user2", fingerprints["user1"], ""), "approvers")
+	git.RunInPath(infra, func() error {
+		git.Do("remote", "add", "origin", upstream)
+		git.Do("push", "-q", "origin", "master")
+		git.Do("fetch", "-q", "origin")
+		git.Do("checkout", "-q", "-b", "feature")
+		return nil
+	})
+
+	/*********************************/
+	testCase := "when the author approves their own change"
+	change := commit("user2", "change", "change\n", "change")
+	approve("user2", change, false)
+
+	// ------------ Run function to test
+	err := check()
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect the approval to be refused %s", testCase)
+
+	/*********************************/
+	testCase = "when another approver approves the change"
+	approve("user1", change, false)
+
+	// ------------ Run function to test
+	err = check()
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect the change to be approved %s", testCase)
+
+	/*********************************/
+	testCase = "when the change adds a fake approver with the author signing key"
+	change = commit("user2", "Forjfile", forjfile("user1, user2, user3", fingerprints["user1"],
+		"  user3:\n    signing-key: "+fingerprints["user2"]+"\n"), "add user3")
+	approve("user3", change, true)
+
+	// ------------ Run function to test
+	err = check()
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect the approval to be refused %s", testCase)
+
+	/*********************************/
+	testCase = "when the approvers change is approved without 'approvers: true'"
+	change = commit("user2", "Forjfile", forjfile("user1, user2", fingerprints["user1"]+", "+fingerprints["user2"], ""), "add a user1 key")
+	approve("user1", change, false)
+
+	// ------------ Run function to test
+	err = check()
+
+	// ------------ Test result
+	if assert.Errorf(err, "Expect a separate approval to be required %s", testCase) {
+		assert.Containsf(err.Error(), "'approvers: true'", "Expect the approvers change to be reported %s", testCase)
+	}
+
+	/*********************************/
+	testCase = "when the approvers change is approved with 'approvers: true'"
+	approve("user1", change, true)
+
+	// ------------ Run function to test
+	err = check()
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect the change to be approved %s", testCase)
+}
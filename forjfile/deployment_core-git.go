@@ -11,6 +11,12 @@ import (
 	"github.com/forj-oss/forjj-modules/trace"
 )
 
+const (
+	// ReviewBranchPrefix is the prefix of deployment review branches created by forjj.
+	ReviewBranchPrefix = "forjj/"
+	defaultBranch      = "master"
+)
+
 // GitSetRepo define where the Deployment repo is located. It creates the repo even just empty and sync if possible and origin given.
 // It switch to master branch
 func (d *DeploymentCoreStruct) GitSetRepo(aPath, origin string) (err error) {
@@ -85,8 +91,8 @@ func (d *DeploymentCoreStruct) SwitchTo(branch string) error {
 		if git.GetCurrentBranch() != branch {
 
 			trackedFiles := git.GetStatus().CountTracked()
-			if trackedFiles > 0 {
-				git.Do("stash")
+			if trackedFiles > 0 && git.Do("stash") != 0 {
+				return fmt.Errorf("Unable to stash updated files before switching to branch '%s'", branch)
 			}
 			git.Do("reset", "--hard", "HEAD")
 			if found, err := git.BranchExist(branch); err != nil {
@@ -109,7 +115,7 @@ func (d *DeploymentCoreStruct) GitCommit(message string) (_ error) {
 	return d.RunInContext(func() (err error) {
 		status := git.GetStatus()
 		if status.Ready.CountFiles() > 0 {
-			git.Commit(message, true)
+			err = git.Commit(message, false)
 		}
 		return
 	})
@@ -143,6 +149,133 @@ func (d *DeploymentCoreStruct) GitPush(force bool) (_ error) {
 	})
 }
 
+// GitPublishBranch commit the deployment files in a review branch and push it, instead of pushing to master.
+//
+// The local repository is moved back to the master branch, so only merged code is applied.
+// It returns the list of commits published, as '<short sha> <subject>'.
+func (d *DeploymentCoreStruct) GitPublishBranch(branch, message string) (commits []string, _ error) {
+	return commits, d.RunInContext(func() (err error) {
+		if d.syncStatus == -2 {
+			return fmt.Errorf("Unable to push to an inexistent remote")
+		}
+		if d.syncStatus == 0 {
+			return fmt.Errorf("Unable to push. You need to sync up before")
+		}
+		if d.syncStatus == -1 {
+			return fmt.Errorf("Unable to publish a review branch. The remote branch '%s' doesn't exist yet. Publish it directly first", d.syncRemoteBranch)
+		}
+		if !strings.HasPrefix(branch, ReviewBranchPrefix) {
+			return fmt.Errorf("Invalid review branch '%s'. It must start with '%s'", branch, ReviewBranchPrefix)
+		}
+
+		if git.Do("checkout", "-B", branch) != 0 {
+			return fmt.Errorf("Unable to create the review branch '%s'", branch)
+		}
+		defer git.Do("checkout", defaultBranch)
+
+		if err = git.Commit(message, false); err != nil {
+			return
+		}
+
+		var log string
+		if log, err = git.Get("log", "--format=%h %s", d.syncRemoteBranch+"..HEAD"); err != nil {
+			return fmt.Errorf("Unable to list commits to publish. %s", err)
+		}
+		if log == "" {
+			return
+		}
+		commits = strings.Split(log, "\n")
+
+		if git.Do("push", "-u", d.syncRemote, branch) != 0 {
+			return fmt.Errorf("Unable to push the review branch '%s'", branch)
+		}
+		return
+	})
+}
+
+// HasReviewBranches return true if the deployment repository has some local review branches.
+func (d *DeploymentCoreStruct) HasReviewBranches() (found bool) {
+	d.RunInContext(func() error {
+		branches, err := git.Branches()
+		if err != nil {
+			return err
+		}
+		for _, branch := range branches {
+			if strings.HasPrefix(strings.TrimLeft(branch, "* "), ReviewBranchPrefix) {
+				found = true
+				break
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// GitUpstream return the remote and the remote branch tracked by the deployment repository master branch.
+func (d *DeploymentCoreStruct) GitUpstream() (remote, branch string, _ error) {
+	return remote, branch, d.RunInContext(func() error {
+		remote, _ = git.Get("config", "branch."+defaultBranch+".remote")
+		merge, _ := git.Get("config", "branch."+defaultBranch+".merge")
+		branch = strings.TrimPrefix(merge, "refs/heads/")
+		if remote == "" || branch == "" {
+			return fmt.Errorf("The deployment repository branch '%s' has no upstream branch", defaultBranch)
+		}
+		return nil
+	})
+}
+
+// GitCleanReviewBranches fetch the remote and remove local review branches merged in the remote branch.
+//
+// A review branch is merged if his last commit is in the remote branch. A branch squashed or rebased when merged
+// is not detected and must be removed manually.
+// It returns the list of review branches removed.
+func (d *DeploymentCoreStruct) GitCleanReviewBranches(remote, branch string) (removed []string, _ error) {
+	return removed, d.RunInContext(func() error {
+		if git.Do("fetch", remote) != 0 {
+			return fmt.Errorf("Unable to fetch '%s'", remote)
+		}
+		branches, err := git.Branches()
+		if err != nil {
+			return err
+		}
+		for _, review := range branches {
+			if strings.HasPrefix(review, "*") {
+				continue // Current branch.
+			}
+			if review = strings.TrimSpace(review); !strings.HasPrefix(review, ReviewBranchPrefix) {
+				continue
+			}
+			head, err := git.Get("rev-parse", review)
+			if err != nil {
+				return fmt.Errorf("Unable to read the review branch '%s'. %s", review, err)
+			}
+			if base, err := git.Get("merge-base", review, remote+"/"+branch); err != nil || base != head {
+				continue
+			}
+			if git.Do("branch", "-D", review) != 0 {
+				return fmt.Errorf("Unable to remove the merged review branch '%s'", review)
+			}
+			removed = append(removed, review)
+		}
+		return nil
+	})
+}
+
+// GitResetFromUpstream clean the master branch and reset it to the remote branch given.
+// Local commits not pushed are lost.
+func (d *DeploymentCoreStruct) GitResetFromUpstream(remote, branch string) error {
+	return d.RunInContext(func() error {
+		git.Do("reset", "--hard", "HEAD")
+		if git.Do("checkout", defaultBranch) != 0 {
+			return fmt.Errorf("Unable to checkout '%s'", defaultBranch)
+		}
+		if git.Do("reset", "--hard", remote+"/"+branch) != 0 {
+			return fmt.Errorf("Unable to reset '%s' to '%s/%s'", defaultBranch, remote, branch)
+		}
+		return nil
+	})
+}
+
 // GitResetBranchFromRemote clean current branch, check out to the requested branch and reset against remote branch.
 // The reset is not made if the fetch return an error.
 func (d *DeploymentCoreStruct) GitResetBranchFromRemote(branch, remote string) {
@@ -0,0 +1,85 @@
+package forjfile
+
+import (
+	"forjj/git"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSwitchTo(t *testing.T) {
+	defer git.SetBackend("")
+
+	for _, backend := range []string{git.CliBackend, git.GoGitBackend} {
+		if err := git.SetBackend(backend); err != nil {
+			t.Fatalf("Unable to select the git backend '%s'. %s", backend, err)
+		}
+		t.Run(backend, testSwitchTo)
+	}
+}
+
+func testSwitchTo(t *testing.T) {
+	assert := assert.New(t)
+
+	tmp, _ := ioutil.TempDir("", "forjj-deploy-repo")
+	defer os.RemoveAll(tmp)
+
+	d := DeploymentCoreStruct{name: "deploy"}
+	if err := d.GitSetRepo(tmp, ""); err != nil {
+		t.Fatalf("Unable to create the deployment repository. %s", err)
+	}
+	write := func(content string) {
+		if err := ioutil.WriteFile(path.Join(d.repoPath, "a"), []byte(content), 0644); err != nil {
+			t.Fatalf("Unable to write 'a'. %s", err)
+		}
+	}
+	d.RunInContext(func() error {
+		git.Do("config", "user.email", "test@forjj.io")
+		git.Do("config", "user.name", "test")
+		git.Do("config", "commit.gpgsign", "false")
+		git.Do("checkout", "-q", "-b", "master")
+		return nil
+	})
+
+	/*********************************/
+	testCase := "when updated files can't be stashed"
+
+	write("a\n")
+	d.RunInContext(func() error {
+		git.Add([]string{"a"})
+		return nil
+	})
+
+	// ------------ Run function to test
+	err := d.SwitchTo("feature")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error without initial commit %s", testCase)
+	_, errFile := os.Stat(path.Join(d.repoPath, "a"))
+	assert.NoErrorf(errFile, "Expect the updated file to be kept %s", testCase)
+
+	/*********************************/
+	testCase = "when updated files are stashed"
+
+	d.GitCommit("first commit")
+	write("updated\n")
+	d.RunInContext(func() error {
+		git.Add([]string{"a"})
+		return nil
+	})
+
+	// ------------ Run function to test
+	err = d.SwitchTo("feature")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	d.RunInContext(func() error {
+		assert.Equalf("feature", git.GetCurrentBranch(), "Expect feature to be the current branch %s", testCase)
+		return nil
+	})
+	data, _ := ioutil.ReadFile(path.Join(d.repoPath, "a"))
+	assert.Equalf("updated\n", string(data), "Expect the updated file to be restored %s", testCase)
+}
@@ -0,0 +1,175 @@
+package forjfile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DeploymentTypeStruct describes a deployment type and his policies.
+//
+// Types are declared in Forjfile:/forj-settings/deployment-types. PRO, TEST and DEV are
+// defined by default and can be updated.
+type DeploymentTypeStruct struct {
+	name               string
+	Desc               string   `yaml:"description,omitempty"`
+	MaxCount           int      `yaml:"max-count,omitempty"`           // Maximum number of deployments of this type. 0 means no limit.
+	DevDeploy          bool     `yaml:"dev-deploy,omitempty"`          // true if a deployment of this type can be the default development deployment.
+	RequiredApprovals  int      `yaml:"required-approvals,omitempty"`  // Number of approvals required before publishing the deployment.
+	ApproversGroup     string   `yaml:"approvers-group,omitempty"`     // Group of users who can approve a publish.
+	AllowedApps        []string `yaml:"allowed-apps,omitempty"`        // Application names or types allowed. Empty means all applications.
+	RequiredParameters []string `yaml:"required-parameters,omitempty"` // Deployment parameters which must be set.
+}
+
+// DeploymentTypes is the collection of deployment types declared in the Forjfile.
+type DeploymentTypes map[string]*DeploymentTypeStruct
+
+// defaultDeploymentTypes return types known by forjj without any declaration.
+func defaultDeploymentTypes() DeploymentTypes {
+	return DeploymentTypes{
+		ProDeployType:  {name: ProDeployType, Desc: "Production", MaxCount: 1},
+		testDeployType: {name: testDeployType, Desc: "Test"},
+		DevDeployType:  {name: DevDeployType, Desc: "Development", DevDeploy: true},
+	}
+}
+
+// Get return the deployment type policy.
+//
+// A type declared with a forjj default name is merged over the default type: fields not declared keep the default
+// value. PRO is limited to one deployment, whatever is declared. See check.
+func (t DeploymentTypes) Get(deployType string) (ret *DeploymentTypeStruct, found bool) {
+	ret, found = defaultDeploymentTypes()[deployType]
+	declared, isDeclared := t[deployType]
+	if !isDeclared || declared == nil {
+		return
+	}
+	if !found {
+		return declared, true
+	}
+
+	merged := *declared
+	merged.name = deployType
+	if merged.Desc == "" {
+		merged.Desc = ret.Desc
+	}
+	if merged.MaxCount == 0 {
+		merged.MaxCount = ret.MaxCount
+	}
+	merged.DevDeploy = merged.DevDeploy || ret.DevDeploy
+	return &merged, true
+}
+
+// initNames set the name of each declared deployment type, when the Forjfile is loaded.
+func (t DeploymentTypes) initNames() {
+	for name, deployType := range t {
+		if deployType != nil {
+			deployType.name = name
+		}
+	}
+}
+
+// check verify the deployment types declared. PRO can only be declared with 'max-count: 1'.
+func (t DeploymentTypes) check() error {
+	if pro, found := t[ProDeployType]; found && pro != nil && pro.MaxCount != 0 && pro.MaxCount != 1 {
+		return fmt.Errorf("Deployment type declaration error in 'forj-settings/deployment-types/%s'. 'max-count' can only be 1. Please fix it", ProDeployType)
+	}
+	return nil
+}
+
+// Names return the sorted list of deployment types known.
+func (t DeploymentTypes) Names() (ret []string) {
+	ret = make([]string, 0, len(t)+3)
+	for name := range defaultDeploymentTypes() {
+		ret = append(ret, name)
+	}
+	for name := range t {
+		if _, found := defaultDeploymentTypes()[name]; !found {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return
+}
+
+// Name return the deployment type name.
+func (t *DeploymentTypeStruct) Name() (_ string) {
+	if t == nil {
+		return
+	}
+	return t.name
+}
+
+// CanBeDevDeploy return true if a deployment of this type can be the default development deployment.
+func (t *DeploymentTypeStruct) CanBeDevDeploy() (_ bool) {
+	if t == nil {
+		return
+	}
+	return t.DevDeploy
+}
+
+// GetRequiredApprovals return the number of approvals required to publish a deployment of this type.
+func (t *DeploymentTypeStruct) GetRequiredApprovals() (_ int) {
+	if t == nil {
+		return
+	}
+	return t.RequiredApprovals
+}
+
+// IsAppAllowed return true if the application can be declared in a deployment of this type.
+func (t *DeploymentTypeStruct) IsAppAllowed(name, appType string) bool {
+	if t == nil || len(t.AllowedApps) == 0 {
+		return true
+	}
+	for _, allowed := range t.AllowedApps {
+		if allowed == name || allowed == appType {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDeployment verify that the deployment respects the deployment type policies.
+//
+// apps is the list of applications used by the deployment, ie master and deployment Forjfile applications.
+func (t *DeploymentTypeStruct) checkDeployment(deploy *DeploymentStruct, apps AppsStruct) error {
+	if t == nil || deploy == nil {
+		return nil
+	}
+	missing := []string{}
+	for _, param := range t.RequiredParameters {
+		if v, found := deploy.Pars[param]; !found || v == "" {
+			missing = append(missing, param)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("Deployment declaration error in '%s'. Deployment type '%s' requires parameters '%s'. Please fix it",
+			deploy.name, t.name, strings.Join(missing, "', '"))
+	}
+
+	notAllowed := []string{}
+	for name, app := range apps {
+		if app != nil && !t.IsAppAllowed(name, app.Type) {
+			notAllowed = append(notAllowed, name)
+		}
+	}
+	if len(notAllowed) > 0 {
+		sort.Strings(notAllowed)
+		return fmt.Errorf("Deployment declaration error in '%s'. Applications '%s' are not allowed for deployment type '%s'. Allowed: '%s'. Please fix it",
+			deploy.name, strings.Join(notAllowed, "', '"), t.name, strings.Join(t.AllowedApps, "', '"))
+	}
+	return nil
+}
+
+// checkApprovers verify that the approval gate can be satisfied by the groups declared.
+func (t *DeploymentTypeStruct) checkApprovers(groups GroupsStruct) error {
+	if t == nil || t.RequiredApprovals == 0 {
+		return nil
+	}
+	if t.ApproversGroup == "" {
+		return fmt.Errorf("Deployment type '%s' requires %d approval(s) but no 'approvers-group' is set. Please fix it", t.name, t.RequiredApprovals)
+	}
+	if group, found := groups[t.ApproversGroup]; !found || group == nil {
+		return fmt.Errorf("Deployment type '%s': approvers group '%s' is not defined in groups. Please fix it", t.name, t.ApproversGroup)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package forjfile
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeploymentTypesValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	load := func(data string) (forge *Forge, err error) {
+		forge = new(Forge)
+		forge.Init()
+		if err = yaml.Unmarshal([]byte(data), forge.yaml); err != nil {
+			return
+		}
+		forge.yaml.set_defaults()
+		return
+	}
+
+	/*********************************/
+	testCase := "when only default deployment types are used"
+
+	forge, err := load(`
+deployments:
+  dev:
+    type: DEV
+  prod:
+    type: PRO
+`)
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+
+	// ------------ Run function to test
+	err = forge.Validate()
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect Forjfile to be valid %s", testCase)
+
+	/*********************************/
+	testCase = "when 2 PRO deployments are declared"
+
+	forge, _ = load(`
+deployments:
+  prod1:
+    type: PRO
+  prod2:
+    type: PRO
+`)
+
+	// ------------ Run function to test
+	err = forge.Validate()
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when an unknown deployment type is used"
+
+	forge, _ = load(`
+deployments:
+  staging:
+    type: STAGING
+`)
+
+	// ------------ Run function to test
+	err = forge.Validate()
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when custom deployment types are declared"
+
+	customTypes := `
+forj-settings:
+  deployment-types:
+    STAGING:
+      max-count: 2
+      required-parameters: [ customer ]
+      allowed-apps: [ github, jenkins ]
+    PRO:
+      description: Production site
+`
+	forge, err = load(customTypes + `
+applications:
+  github:
+    type: upstream
+deployments:
+  staging1:
+    type: STAGING
+    parameters:
+      customer: acme
+  prod1:
+    type: PRO
+`)
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+
+	// ------------ Run function to test
+	err = forge.Validate()
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect Forjfile to be valid %s", testCase)
+	if deployType, found := forge.GetDeploymentTypePolicy("STAGING"); assert.Truef(found, "Expect STAGING to be found %s", testCase) {
+		assert.Equalf("STAGING", deployType.Name(), "Expect type name to be set %s", testCase)
+		assert.Falsef(deployType.CanBeDevDeploy(), "Expect STAGING to not be a DEV deploy type %s", testCase)
+	}
+
+	if deployType, found := forge.GetDeploymentTypePolicy("PRO"); assert.Truef(found, "Expect PRO to be found %s", testCase) {
+		assert.Equalf("PRO", deployType.Name(), "Expect type name to be set %s", testCase)
+		assert.Equalf("Production site", deployType.Desc, "Expect declared description %s", testCase)
+		assert.Equalf(1, deployType.MaxCount, "Expect PRO default max-count to be kept %s", testCase)
+	}
+
+	/*********************************/
+	testCase = "when PRO is declared without max-count and 2 PRO deployments are declared"
+
+	forge, _ = load(customTypes + `
+deployments:
+  prod1:
+    type: PRO
+  prod2:
+    type: PRO
+`)
+
+	// ------------ Run function to test
+	err = forge.Validate()
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when PRO is declared with a max-count different than 1"
+
+	forge, _ = load(`
+forj-settings:
+  deployment-types:
+    PRO:
+      max-count: 2
+deployments:
+  prod1:
+    type: PRO
+`)
+
+	// ------------ Run function to test
+	err = forge.Validate()
+
+	// ------------ Test result
+	assert.EqualErrorf(err, "Deployment type declaration error in 'forj-settings/deployment-types/PRO'. 'max-count' can only be 1. Please fix it",
+		"Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when a required parameter is missing"
+
+	forge, _ = load(customTypes + `
+deployments:
+  staging1:
+    type: STAGING
+`)
+
+	// ------------ Run function to test
+	err = forge.Validate()
+
+	// ------------ Test result
+	assert.EqualErrorf(err, "Deployment declaration error in 'staging1'. Deployment type 'STAGING' requires parameters 'customer'. Please fix it",
+		"Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when an application is not allowed"
+
+	forge, err = load(customTypes + `
+applications:
+  nexus:
+    type: repository
+deployments:
+  staging1:
+    type: STAGING
+    parameters:
+      customer: acme
+`)
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+
+	// ------------ Run function to test
+	err = forge.Validate()
+
+	// ------------ Test result
+	assert.EqualErrorf(err, "Deployment declaration error in 'staging1'. Applications 'nexus' are not allowed for deployment type 'STAGING'. Allowed: 'github', 'jenkins'. Please fix it",
+		"Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when the default dev deployment type can't be a dev deployment"
+
+	forge, _ = load(`
+forj-settings:
+  default:
+    dev-deploy: prod
+deployments:
+  prod:
+    type: PRO
+`)
+
+	// ------------ Run function to test
+	err = forge.Validate()
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+}
@@ -23,7 +23,7 @@ func (d Deployments) GetDeploymentType(deployType string) (v Deployments, found
 // GetDeploymentPROType return the PRO deployment structure
 func (d Deployments) GetDeploymentPROType() (v *DeploymentStruct, err error) {
 
-	if deployObjs, _ := d.GetDeploymentType("PRO"); len(deployObjs) != 1 {
+	if deployObjs, _ := d.GetDeploymentType(ProDeployType); len(deployObjs) != 1 {
 		err = fmt.Errorf("Found more than one PRO environment")
 	} else {
 		for k := range deployObjs {
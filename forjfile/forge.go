@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/forj-oss/forjj-modules/trace"
 	"github.com/forj-oss/goforjj"
@@ -227,7 +228,7 @@ func (f *Forge) Load(deployTo string) (loaded bool, err error) {
 			return
 		}
 		deployDetail.Repos.attachToDeploy(deployName)
-		if deployData.Type == "PRO" {
+		if deployData.Type == ProDeployType {
 			f.attachInfraToDeployment(deployName)
 		}
 		deployData.Details = deployDetail
@@ -256,7 +257,7 @@ func (f *Forge) Load(deployTo string) (loaded bool, err error) {
 		}
 	}
 	if deployTo == "" {
-		if deploys, _ := f.yaml.Deployments.GetDeploymentType("DEV"); len(deploys) == 1 {
+		if deploys := f.yaml.devDeployments(); len(deploys) == 1 {
 			deployTo = deploys.One().Name()
 			gotrace.Info("Single DEV deployment environment selected: %s", deployTo)
 		}
@@ -905,21 +906,36 @@ func (f *Forge) Validate() error {
 	// ForgeYaml.More
 
 	// DeploymentStruct
-	pro := false
+	deployTypes := f.yaml.ForjCore.ForjSettings.DeployTypes
+	if err := deployTypes.check(); err != nil {
+		return err
+	}
+	typesCount := make(map[string]int)
 	devDefault := forge.ForjSettings.Default.getDevDeploy()
 	devDefaultFound := false
 	for name, deploy := range f.yaml.Deployments {
 		if deploy.Type == "" {
-			return fmt.Errorf("Deployment declaration error in '%s'. Missing type. Provide at least `Type: (PRO|TEST|DEV)`", name)
+			return fmt.Errorf("Deployment declaration error in '%s'. Missing type. Provide at least `Type: (%s)`", name, strings.Join(deployTypes.Names(), "|"))
 		}
-		if deploy.Type == ProDeployType {
-			if pro {
-				return fmt.Errorf("Deployment declaration error in '%s'. You cannot have more than 1 deployment of type 'PRO'. Please fix it", name)
-			} else {
-				pro = true
-			}
+		deployType, found := deployTypes.Get(deploy.Type)
+		if !found {
+			return fmt.Errorf("Deployment declaration error in '%s'. Unknown type '%s'. Use one of '%s' or declare it in forj-settings/deployment-types. Please fix it",
+				name, deploy.Type, strings.Join(deployTypes.Names(), "', '"))
+		}
+		typesCount[deploy.Type]++
+		if max := deployType.MaxCount; max > 0 && typesCount[deploy.Type] > max {
+			return fmt.Errorf("Deployment declaration error in '%s'. You cannot have more than %d deployment of type '%s'. Please fix it", name, max, deploy.Type)
 		}
-		if deploy.Type == DevDeployType && devDefault == deploy.name {
+		if err := deployType.checkDeployment(deploy, f.deploymentApps(deploy)); err != nil {
+			return err
+		}
+		if err := deployType.checkApprovers(forge.Groups); err != nil {
+			return err
+		}
+		if devDefault == deploy.name {
+			if !deployType.CanBeDevDeploy() {
+				return fmt.Errorf("Deployment declaration error in '%s'. '%s' is of type '%s' which can't be the default DEV deployment. Please fix it", "forj-settings/default/dev-deploy", devDefault, deploy.Type)
+			}
 			devDefaultFound = true
 		}
 	}
@@ -930,6 +946,30 @@ func (f *Forge) Validate() error {
 	return nil
 }
 
+// GetDeploymentTypePolicy return the deployment type policies declared in the Forjfile, or the forjj default one.
+func (f *Forge) GetDeploymentTypePolicy(deployType string) (*DeploymentTypeStruct, bool) {
+	return f.yaml.ForjCore.ForjSettings.DeployTypes.Get(deployType)
+}
+
+// deploymentApps return the list of applications used by a deployment, from the master and the deployment Forjfile.
+func (f *Forge) deploymentApps(deploy *DeploymentStruct) (ret AppsStruct) {
+	ret = make(AppsStruct)
+	for name, app := range f.yaml.ForjCore.Apps {
+		ret[name] = app
+	}
+	if deploy.Details == nil {
+		return
+	}
+	for name, app := range deploy.Details.Apps {
+		if app != nil && app.mergeDirective == mergeDelete {
+			delete(ret, name)
+			continue
+		}
+		ret[name] = app
+	}
+	return
+}
+
 // GetDeployments returns all deployments.
 func (f *Forge) GetDeployments() (result Deployments) {
 	result = f.yaml.Deployments
@@ -60,7 +60,7 @@ func (f *ForgeYaml) defineDefaults(warn bool) {
 	}
 
 	for name, deploy := range f.Deployments {
-		if deploy.Type == DevDeployType && f.ForjCore.ForjSettings.Default.getDevDeploy() == "" {
+		if deployType, _ := f.ForjCore.ForjSettings.DeployTypes.Get(deploy.Type); deployType.CanBeDevDeploy() && f.ForjCore.ForjSettings.Default.getDevDeploy() == "" {
 			comm("Defining development deployment '%s' as Default (dev-deploy).", name)
 			f.ForjCore.ForjSettings.Default.Set("forjj", "dev-deploy", name)
 		}
@@ -99,3 +99,14 @@ func (f *ForgeYaml) dirty() {
 	}
 	f.updated = true
 }
+
+// devDeployments return the list of deployments which can be the default development deployment.
+func (f *ForgeYaml) devDeployments() (ret Deployments) {
+	ret = make(Deployments)
+	for name, deploy := range f.Deployments {
+		if deployType, _ := f.ForjCore.ForjSettings.DeployTypes.Get(deploy.Type); deployType.CanBeDevDeploy() {
+			ret[name] = deploy
+		}
+	}
+	return
+}
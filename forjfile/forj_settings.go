@@ -51,9 +51,11 @@ func (s *ForjSettingsStruct) mergeFrom(from *ForjSettingsStruct) {
 }
 
 type ForjSettingsStructTmpl struct {
-	Default  DefaultSettingsStruct
-	RepoApps DefaultRepoAppSettingsStruct `yaml:"default-repo-apps,omitempty"` // Default repo Application
-	More     map[string]string            `yaml:",inline"`
+	Default       DefaultSettingsStruct
+	RepoApps      DefaultRepoAppSettingsStruct `yaml:"default-repo-apps,omitempty"` // Default repo Application
+	DeployTypes   DeploymentTypes              `yaml:"deployment-types,omitempty"`  // Deployment types and policies
+	CommitMessage string                       `yaml:"commit-message,omitempty"`    // Template of commit messages. See CommitMessageData
+	More          map[string]string            `yaml:",inline"`
 }
 
 func (f *ForjSettingsStruct) MarshalYAML() (interface{}, error) {
@@ -143,4 +145,5 @@ func (s *ForjSettingsStruct) Set(source, instance, key, value string) {
 func (g *ForjSettingsStruct) set_forge(f *ForgeYaml) {
 	g.forge = f
 	g.Default.set_forge(f)
+	g.DeployTypes.initNames()
 }
@@ -16,10 +16,14 @@ func (g GroupsStruct) mergeFrom(from GroupsStruct) GroupsStruct {
 		g = make(GroupsStruct)
 	}
 	for k, groupFrom := range from {
-		if group, found := g[k]; found {
+		if groupFrom != nil && groupFrom.mergeDirective == mergeDelete {
+			delete(g, k)
+			continue
+		}
+		if group, found := g[k]; found && group != nil && (groupFrom == nil || groupFrom.mergeDirective != mergeReplace) {
 			group.mergeFrom(groupFrom)
 		} else {
-			g[k] = groupFrom
+			g[k] = groupFrom.withoutMergeDirectives()
 		}
 	}
 	return g
@@ -31,8 +35,13 @@ type GroupStruct struct {
 	Members []string          `yaml:",omitempty"`
 	More    map[string]string `yaml:",inline"`
 	sources *sourcesinfo.Sources
+	// Merge directive (!delete or !replace) used when merging this group to another Forjfile.
+	mergeDirective string
 }
 
+// groupStructYaml is used to decode/encode GroupStruct with default yaml functions.
+type groupStructYaml GroupStruct
+
 const (
 	groupRole    = "role"
 	groupMembers = "members"
@@ -168,10 +177,65 @@ func (g *GroupStruct) Set(source, field, value string) {
 	return
 }
 
+// UnmarshalYAML decode a group. The group can be set to "!delete" to remove it when merged.
+func (g *GroupStruct) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if directive, found, err := decodeMergeDirective(unmarshal); found {
+		g.mergeDirective = directive
+		return err
+	}
+	if err := unmarshal((*groupStructYaml)(g)); err != nil {
+		return err
+	}
+	g.mergeDirective = extractMergeReplace(g.More)
+	return nil
+}
+
+// MarshalYAML encode a group with his merge directive.
+func (g *GroupStruct) MarshalYAML() (interface{}, error) {
+	if g == nil {
+		return nil, nil
+	}
+	if g.mergeDirective == mergeDelete {
+		return mergeDelete, nil
+	}
+	data := *(*groupStructYaml)(g)
+	data.More = withMergeReplace(g.More, g.mergeDirective)
+	return data, nil
+}
+
 func (g *GroupStruct) mergeFrom(from *GroupStruct) {
+	if from == nil {
+		return
+	}
 	for _, flag := range from.Flags() {
 		if v, found, source := from.Get(flag); found {
-			g.Set(source, flag, v.GetString())
+			value := v.GetString()
+			if value == mergeDelete {
+				value = ""
+			}
+			g.Set(source, flag, value)
+		}
+	}
+	if from.Members != nil {
+		if members := mergeStringList(g.Members, from.Members); !stringListEqual(members, g.Members) {
+			g.Members = members
+			g.forge.dirty()
 		}
 	}
 }
+
+// withoutMergeDirectives return the group without any merge directives.
+//
+// If the group has some, a copy is returned, without those directives.
+func (g *GroupStruct) withoutMergeDirectives() *GroupStruct {
+	if g == nil || (g.Role != mergeDelete && !stringMapHasDirectives(g.More) && !stringListHasDirectives(g.Members)) {
+		return g
+	}
+	ret := *g
+	if ret.Role == mergeDelete {
+		ret.Role = ""
+	}
+	ret.More = mergeStringMap(make(map[string]string), g.More)
+	ret.Members = mergeStringList(nil, g.Members)
+	return &ret
+}
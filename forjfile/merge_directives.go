@@ -0,0 +1,145 @@
+package forjfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Merge directives can be used in a deployment Forjfile (or in a deployment `define` section)
+// to change how data are merged with the master Forjfile. Forjfile models are built from the
+// merged result, so they never see those directives.
+//
+//   - `<instance>: "!delete"` removes an object instance declared by the master Forjfile.
+//   - `"!replace": true` set in an instance replaces the master instance instead of merging keys.
+//   - `<key>: "!delete"` removes a key value declared by the master Forjfile.
+//   - in a list (ie group `members`), `-<item>` removes the item and any other item is appended.
+//     If the first item is "!replace", the list replaces the master one.
+//   - in a map (ie repo `in-relation-with`), `<key>: "!delete"` removes the key.
+const (
+	mergeDelete     = "!delete"
+	mergeReplace    = "!replace"
+	mergeListRemove = "-"
+)
+
+// decodeMergeDirective read a merge directive given as an object instance value.
+func decodeMergeDirective(unmarshal func(interface{}) error) (directive string, found bool, err error) {
+	if unmarshal(&directive) != nil {
+		return
+	}
+	found = true
+	if directive != mergeDelete {
+		err = fmt.Errorf("Invalid merge directive '%s'. Only '%s' can be set as value", directive, mergeDelete)
+	}
+	return
+}
+
+// extractMergeReplace remove the "!replace" key from the instance keys and return the directive found.
+func extractMergeReplace(more map[string]string) (directive string) {
+	if more == nil {
+		return
+	}
+	if _, found := more[mergeReplace]; found {
+		delete(more, mergeReplace)
+		directive = mergeReplace
+	}
+	return
+}
+
+// withMergeReplace return a copy of keys with the "!replace" key to save it back in the Forjfile.
+func withMergeReplace(more map[string]string, directive string) map[string]string {
+	if directive != mergeReplace {
+		return more
+	}
+	ret := make(map[string]string, len(more)+1)
+	for key, value := range more {
+		ret[key] = value
+	}
+	ret[mergeReplace] = "true"
+	return ret
+}
+
+// mergeStringList merge a list of strings with list directives.
+func mergeStringList(to, from []string) (ret []string) {
+	if len(from) > 0 && from[0] == mergeReplace {
+		to = nil
+		from = from[1:]
+	}
+	ret = make([]string, 0, len(to)+len(from))
+	ret = append(ret, to...)
+	for _, item := range from {
+		if strings.HasPrefix(item, mergeListRemove) {
+			ret = removeStringFromList(ret, strings.TrimPrefix(item, mergeListRemove))
+			continue
+		}
+		if indexOfString(ret, item) == -1 {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+// stringListHasDirectives return true if the list has some merge directives.
+func stringListHasDirectives(list []string) bool {
+	for index, item := range list {
+		if strings.HasPrefix(item, mergeListRemove) || (index == 0 && item == mergeReplace) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeStringMap merge a map of strings. A "!delete" value removes the key.
+func mergeStringMap(to, from map[string]string) map[string]string {
+	if from == nil {
+		return to
+	}
+	if to == nil {
+		to = make(map[string]string)
+	}
+	for key, value := range from {
+		if value == mergeDelete {
+			delete(to, key)
+			continue
+		}
+		to[key] = value
+	}
+	return to
+}
+
+// stringMapHasDirectives return true if the map has some "!delete" values.
+func stringMapHasDirectives(values map[string]string) bool {
+	for _, value := range values {
+		if value == mergeDelete {
+			return true
+		}
+	}
+	return false
+}
+
+func stringListEqual(list1, list2 []string) bool {
+	if len(list1) != len(list2) {
+		return false
+	}
+	for index, item := range list1 {
+		if list2[index] != item {
+			return false
+		}
+	}
+	return true
+}
+
+func indexOfString(list []string, search string) int {
+	for index, item := range list {
+		if item == search {
+			return index
+		}
+	}
+	return -1
+}
+
+func removeStringFromList(list []string, item string) []string {
+	if index := indexOfString(list, item); index >= 0 {
+		return append(list[:index], list[index+1:]...)
+	}
+	return list
+}
@@ -0,0 +1,159 @@
+package forjfile
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeStringList(t *testing.T) {
+	assert := assert.New(t)
+
+	testCase := "when items are added and removed"
+	ret := mergeStringList([]string{"user1", "user2"}, []string{"-user1", "user3", "user2"})
+	assert.Equalf([]string{"user2", "user3"}, ret, "Expect list to be merged %s", testCase)
+
+	testCase = "when the list is replaced"
+	ret = mergeStringList([]string{"user1", "user2"}, []string{mergeReplace, "user3"})
+	assert.Equalf([]string{"user3"}, ret, "Expect list to be replaced %s", testCase)
+
+	testCase = "when the list to merge is empty"
+	ret = mergeStringList([]string{"user1"}, nil)
+	assert.Equalf([]string{"user1"}, ret, "Expect list to be unchanged %s", testCase)
+}
+
+func TestMergeStringMap(t *testing.T) {
+	assert := assert.New(t)
+
+	testCase := "when keys are updated and deleted"
+	ret := mergeStringMap(map[string]string{"upstream": "github", "ci": "jenkins"},
+		map[string]string{"ci": mergeDelete, "upstream": "gitlab"})
+	assert.Equalf(map[string]string{"upstream": "gitlab"}, ret, "Expect map to be merged %s", testCase)
+
+	testCase = "when the map to update is nil"
+	ret = mergeStringMap(nil, map[string]string{"ci": "jenkins"})
+	assert.Equalf(map[string]string{"ci": "jenkins"}, ret, "Expect map to be created %s", testCase)
+}
+
+func TestDeployForgeYamlMergeDirectives(t *testing.T) {
+	assert := assert.New(t)
+
+	master := `
+repositories:
+  repo1:
+    title: "repo 1"
+    in-relation-with:
+      ci: jenkins
+  repo2:
+    title: "repo 2"
+  repo3:
+    title: "repo 3"
+    repo-template: "tmpl"
+applications:
+  jenkins:
+    type: ci
+    seed-job: "yes"
+  github:
+    type: upstream
+groups:
+  team:
+    role: admin
+    members: [ user1, user2 ]
+users:
+  user1:
+    role: admin
+  user2:
+    role: user
+projects:
+  prj1:
+    key1: value1
+    key2: value2
+  prj2:
+    key1: value1
+`
+	deploy := `
+repositories:
+  repo1:
+    in-relation-with:
+      ci: "!delete"
+  repo2: "!delete"
+  repo3:
+    "!replace": true
+    title: "new repo 3"
+applications:
+  jenkins:
+    type: ci
+    seed-job: "!delete"
+  github: "!delete"
+groups:
+  team:
+    members: [ "-user1", user3 ]
+users:
+  user2: "!delete"
+projects:
+  prj1:
+    key2: "!delete"
+  prj2: "!delete"
+`
+	/*********************************/
+	testCase := "when a deployment Forjfile with merge directives is loaded"
+
+	from := NewDeployForgeYaml()
+	err := yaml.Unmarshal([]byte(deploy), from)
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+
+	/*********************************/
+	testCase = "when a deployment Forjfile with merge directives is merged to the master one"
+
+	to := NewDeployForgeYaml()
+	err = yaml.Unmarshal([]byte(master), to)
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	forge := NewForgeYaml()
+	to.initDefaults(forge)
+	from.initDefaults(forge)
+
+	// ------------ Run function to test
+	err = to.mergeFrom(from)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+
+	assert.Containsf(to.Repos, "repo1", "Expect repo1 to be kept %s", testCase)
+	assert.NotContainsf(to.Repos["repo1"].Apps, "ci", "Expect repo1 'ci' relation to be removed %s", testCase)
+	assert.NotContainsf(to.Repos, "repo2", "Expect repo2 to be removed %s", testCase)
+	if assert.Containsf(to.Repos, "repo3", "Expect repo3 to be kept %s", testCase) {
+		assert.Equalf("new repo 3", to.Repos["repo3"].Title, "Expect repo3 title to be replaced %s", testCase)
+		assert.Equalf("", to.Repos["repo3"].RepoTemplate, "Expect repo3 template to be removed %s", testCase)
+	}
+
+	assert.NotContainsf(to.Apps, "github", "Expect github app to be removed %s", testCase)
+	if assert.Containsf(to.Apps, "jenkins", "Expect jenkins app to be kept %s", testCase) {
+		_, found, _ := to.Apps["jenkins"].Get("seed-job")
+		assert.Falsef(found, "Expect jenkins 'seed-job' to be removed %s", testCase)
+	}
+
+	if assert.Containsf(to.Groups, "team", "Expect team group to be kept %s", testCase) {
+		assert.Equalf([]string{"user2", "user3"}, to.Groups["team"].Members, "Expect team members to be merged %s", testCase)
+	}
+	assert.Containsf(to.Users, "user1", "Expect user1 to be kept %s", testCase)
+	assert.NotContainsf(to.Users, "user2", "Expect user2 to be removed %s", testCase)
+
+	assert.NotContainsf(to.More["projects"], "prj2", "Expect prj2 project to be removed %s", testCase)
+	if assert.Containsf(to.More["projects"], "prj1", "Expect prj1 project to be kept %s", testCase) {
+		assert.Containsf(to.More["projects"]["prj1"], "key1", "Expect prj1 key1 to be kept %s", testCase)
+		assert.NotContainsf(to.More["projects"]["prj1"], "key2", "Expect prj1 key2 to be removed %s", testCase)
+	}
+
+	/*********************************/
+	testCase = "when a deployment Forjfile with merge directives is saved"
+
+	// ------------ Run function to test
+	yml, err := yaml.Marshal(from)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Containsf(string(yml), "repo2: '!delete'", "Expect repo2 directive to be saved %s", testCase)
+	assert.Containsf(string(yml), "'!replace': \"true\"", "Expect repo3 directive to be saved %s", testCase)
+}
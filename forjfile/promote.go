@@ -0,0 +1,186 @@
+package forjfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/forj-oss/forjj-modules/trace"
+	"gopkg.in/yaml.v2"
+)
+
+// DiffDeployments compare the merged Forjfile of 2 deployments and return the differences.
+//
+// Each deployment is merged from a fresh load of the Forjfiles, as a merge updates the master Forjfile loaded.
+func (f *Forge) DiffDeployments(from, to string) (_ DeployDiffs, err error) {
+	if err = f.checkPromotion(from, to); err != nil {
+		return
+	}
+
+	var fromTree, toTree yaml.MapSlice
+	if fromTree, err = f.mergedTree(from); err != nil {
+		return
+	}
+	if toTree, err = f.mergedTree(to); err != nil {
+		return
+	}
+	return diffDeployTrees(nil, fromTree, toTree), nil
+}
+
+// Promote update the deployment Forjfile of 'to' with the source values of differences given.
+//
+// The deployment Forjfile is written again from its yaml tree, so comments and formatting are not kept.
+// The updated Forjfiles are validated and the differences checked again. If something is wrong, the deployment
+// Forjfile is restored.
+// It returns the path of the deployment Forjfile updated, relative to the infra repository.
+func (f *Forge) Promote(from, to string, diffs DeployDiffs) (file string, err error) {
+	file, original, yamlData, err := f.promoted(from, to, diffs)
+	if err != nil {
+		return
+	}
+
+	filePath := path.Join(f.infra_path, file)
+	if err = ioutil.WriteFile(filePath, yamlData, 0644); err != nil {
+		return
+	}
+	gotrace.Trace("Deployment file name saved: %s", filePath)
+
+	if err = f.checkPromoted(from, to, diffs); err != nil {
+		if e := ioutil.WriteFile(filePath, original, 0644); e != nil {
+			gotrace.Error("Unable to restore '%s'. %s", filePath, e)
+		}
+		return
+	}
+	return
+}
+
+// PromoteDryRun return the deployment Forjfile of 'to' as Promote would write it, without writing it.
+func (f *Forge) PromoteDryRun(from, to string, diffs DeployDiffs) (file string, yamlData []byte, err error) {
+	file, _, yamlData, err = f.promoted(from, to, diffs)
+	return
+}
+
+// promoted return the original and the updated content of the deployment Forjfile of 'to'.
+func (f *Forge) promoted(from, to string, diffs DeployDiffs) (file string, original, yamlData []byte, err error) {
+	if err = f.checkPromotion(from, to); err != nil {
+		return
+	}
+
+	file = path.Join("deployments", to, f.Forjfile_name())
+	filePath := path.Join(f.infra_path, file)
+
+	var master, overlay yaml.MapSlice
+	if master, err = readTree(path.Join(f.infra_path, f.Forjfile_name())); err != nil {
+		return
+	}
+	if original, err = ioutil.ReadFile(filePath); err != nil {
+		return
+	}
+	if err = yaml.Unmarshal(original, &overlay); err != nil {
+		err = fmt.Errorf("Unable to load deployment file '%s'. %s", filePath, err)
+		return
+	}
+	if hasYamlComments(original) {
+		gotrace.Warning("'%s' comments and formatting are not kept by the promotion. Use --dry-run to review the file updated.", file)
+	}
+
+	for _, diff := range diffs {
+		if overlay, err = applyDeployDiff(overlay, master, diff); err != nil {
+			return
+		}
+	}
+
+	yamlData, err = yaml.Marshal(overlay)
+	return
+}
+
+// hasYamlComments return true if a line of the yaml data looks like a comment or ends with a comment.
+func hasYamlComments(yamlData []byte) bool {
+	for _, line := range strings.Split(string(yamlData), "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "#") || strings.Contains(line, " #") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPromotion verify that both deployments exist and are different.
+func (f *Forge) checkPromotion(from, to string) error {
+	if from == to {
+		return fmt.Errorf("Unable to promote '%s' to itself", from)
+	}
+	for _, deploy := range []string{from, to} {
+		if _, found := f.GetADeployment(deploy); !found {
+			return fmt.Errorf("Deployment '%s' not defined", deploy)
+		}
+	}
+	return nil
+}
+
+// checkPromoted load again the Forjfiles updated, validate them and check that the differences promoted are gone.
+func (f *Forge) checkPromoted(from, to string, diffs DeployDiffs) error {
+	forge, err := f.loadCopy()
+	if err != nil {
+		return fmt.Errorf("Unable to load the deployment Forjfile updated. %s", err)
+	}
+	forge.SetDeployment(to)
+	if err = forge.BuildForjfileInMem(); err != nil {
+		return err
+	}
+	if err = forge.Validate(); err != nil {
+		return fmt.Errorf("The promotion to '%s' gives an invalid Forjfile. %s", to, err)
+	}
+
+	remaining, err := f.DiffDeployments(from, to)
+	if err != nil {
+		return err
+	}
+	for _, diff := range diffs {
+		if len(remaining.Select(diff.Key())) > 0 {
+			return fmt.Errorf("Unable to promote '%s' to deployment '%s'", diff.Key(), to)
+		}
+	}
+	return nil
+}
+
+// loadCopy load the Forjfiles in a new Forge object.
+func (f *Forge) loadCopy() (ret *Forge, err error) {
+	ret = new(Forge)
+	ret.infra_path = f.infra_path
+	ret.file_name = f.file_name
+	if _, err = ret.Load("global"); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// mergedTree return the yaml tree of the deployment Forjfile merged with the master one.
+func (f *Forge) mergedTree(deploy string) (ret yaml.MapSlice, err error) {
+	forge, err := f.loadCopy()
+	if err != nil {
+		return
+	}
+	merged, err := forge.MergeFromDeployment(deploy)
+	if err != nil {
+		return
+	}
+	yamlData, err := yaml.Marshal(merged)
+	if err != nil {
+		return
+	}
+	err = yaml.Unmarshal(yamlData, &ret)
+	return
+}
+
+// readTree load a yaml file as yaml tree.
+func readTree(aPath string) (ret yaml.MapSlice, err error) {
+	file, yamlData, err := loadFile(aPath)
+	if err != nil {
+		return
+	}
+	if err = yaml.Unmarshal(yamlData, &ret); err != nil {
+		err = fmt.Errorf("Unable to load '%s'. %s", file, err)
+	}
+	return
+}
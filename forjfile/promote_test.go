@@ -0,0 +1,144 @@
+package forjfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromote(t *testing.T) {
+	assert := assert.New(t)
+
+	master := `
+deployments:
+  dev:
+    type: DEV
+  test:
+    type: TEST
+  prod:
+    type: PRO
+repositories:
+  repo1:
+    title: "repo 1"
+  repo2:
+    title: "repo 2"
+groups:
+  team:
+    members: [ user1 ]
+`
+	dev := `
+repositories:
+  repo1:
+    title: "new repo 1"
+  repo3:
+    title: "repo 3"
+groups:
+  team:
+    members: [ user2 ]
+`
+	test := `
+repositories:
+  repo2: "!delete"
+`
+	infraPath, err := ioutil.TempDir("", "forjj-promote-")
+	if !assert.NoErrorf(err, "Expect temporary infra path to be created") {
+		return
+	}
+	defer os.RemoveAll(infraPath)
+
+	files := map[string]string{
+		"Forjfile":                  master,
+		"deployments/dev/Forjfile":  dev,
+		"deployments/test/Forjfile": test,
+		"deployments/prod/Forjfile": "",
+	}
+	for file, data := range files {
+		os.MkdirAll(path.Dir(path.Join(infraPath, file)), 0755)
+		ioutil.WriteFile(path.Join(infraPath, file), []byte(data), 0644)
+	}
+
+	forge := new(Forge)
+	forge.infra_path = infraPath
+	forge.file_name = forjfileName
+	_, err = forge.Load("global")
+	assert.NoErrorf(err, "Expect Forjfiles to be loaded")
+
+	/*********************************/
+	testCase := "when deployments dev and test are compared"
+
+	// ------------ Run function to test
+	diffs, err := forge.DiffDeployments("dev", "test")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	keys := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		keys = append(keys, diff.Key())
+	}
+	assert.ElementsMatchf([]string{
+		"repositories/repo1/title",
+		"repositories/repo2",
+		"repositories/repo3",
+		"groups/team/members",
+	}, keys, "Expect differences to be found %s", testCase)
+
+	/*********************************/
+	testCase = "when a deployment is compared to itself"
+
+	// ------------ Run function to test
+	_, err = forge.DiffDeployments("dev", "dev")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when differences are promoted from dev to test with dry-run"
+
+	// ------------ Run function to test
+	file, yamlData, err := forge.PromoteDryRun("dev", "test", diffs)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf("deployments/test/Forjfile", file, "Expect the test deployment Forjfile to be returned %s", testCase)
+	assert.Containsf(string(yamlData), "new repo 1", "Expect the differences to be promoted %s", testCase)
+	original, _ := ioutil.ReadFile(path.Join(infraPath, file))
+	assert.Equalf(test, string(original), "Expect the test deployment Forjfile unchanged %s", testCase)
+
+	/*********************************/
+	testCase = "when all differences are promoted from dev to test"
+
+	// ------------ Run function to test
+	file, err = forge.Promote("dev", "test", diffs)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf("deployments/test/Forjfile", file, "Expect the test deployment Forjfile to be updated %s", testCase)
+
+	diffs, err = forge.DiffDeployments("dev", "test")
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Emptyf(diffs, "Expect no more differences %s", testCase)
+
+	/*********************************/
+	testCase = "when a difference is selected"
+
+	// ------------ Run function to test
+	diffs, err = forge.DiffDeployments("test", "prod")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	selected := diffs.Select("repositories/repo3")
+	if assert.Lenf(selected, 1, "Expect only repo3 difference to be selected %s", testCase) {
+		assert.Equalf("+ repositories/repo3: \n    title: repo 3", selected[0].String(), "Expect repo3 to be added %s", testCase)
+	}
+}
+
+func TestHasYamlComments(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Falsef(hasYamlComments([]byte("repositories:\n  repo1:\n    title: repo#1\n")), "Expect no comments when '#' is in a value")
+	assert.Truef(hasYamlComments([]byte("# Test deployment\nrepositories:\n")), "Expect a comment line to be found")
+	assert.Truef(hasYamlComments([]byte("repositories:\n  repo1: {} # to remove\n")), "Expect an end of line comment to be found")
+}
@@ -31,8 +31,12 @@ type RepoStruct struct {
 	apps            map[string]*AppStruct       // List of applications connected to this repo. Defaults are added automatically.
 	Apps            map[string]string           `yaml:"in-relation-with,omitempty"` // key: <AppRelName>, value: <appName>
 	sources         *sourcesinfo.Sources
+	mergeDirective  string // Merge directive (!delete or !replace) used when merging this repo to another Forjfile.
 }
 
+// repoStructYaml is used to decode/encode RepoStruct with default yaml functions.
+type repoStructYaml RepoStruct
+
 const (
 	FieldRepoName          = "name"
 	FieldRepoUpstream      = "upstream"
@@ -90,18 +94,91 @@ func (r *RepoStruct) Flags() (flags []string) {
 
 }
 
+// UnmarshalYAML decode a repository. The repository can be set to "!delete" to remove it when merged.
+func (r *RepoStruct) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if directive, found, err := decodeMergeDirective(unmarshal); found {
+		r.mergeDirective = directive
+		return err
+	}
+	if err := unmarshal((*repoStructYaml)(r)); err != nil {
+		return err
+	}
+	r.mergeDirective = extractMergeReplace(r.More)
+	return nil
+}
+
+// MarshalYAML encode a repository with his merge directive.
+func (r *RepoStruct) MarshalYAML() (interface{}, error) {
+	if r == nil {
+		return nil, nil
+	}
+	if r.mergeDirective == mergeDelete {
+		return mergeDelete, nil
+	}
+	data := *(*repoStructYaml)(r)
+	data.More = withMergeReplace(r.More, r.mergeDirective)
+	return data, nil
+}
+
 func (r *RepoStruct) mergeFrom(from *RepoStruct) *RepoStruct {
 	if r == nil {
 		return from
 	}
+	if from == nil {
+		return r
+	}
 	for _, flag := range from.Flags() {
 		if v, found, source := from.Get(flag); found {
-			r.Set(source, flag, v.GetString())
+			value := v.GetString()
+			if value == mergeDelete {
+				if flag == FieldRepoUpstream {
+					continue // Removed from in-relation-with merge.
+				}
+				value = ""
+			}
+			r.Set(source, flag, value)
+		}
+	}
+	if from.Apps != nil {
+		r.Apps = mergeStringMap(r.Apps, from.Apps)
+		for appRelName, appName := range from.Apps {
+			if appName == mergeDelete && r.apps != nil {
+				delete(r.apps, appRelName)
+			}
 		}
 	}
 	return r
 }
 
+// withoutMergeDirectives return the repo without any key merge directives.
+//
+// If the repo has some, a copy is returned, without those directives.
+func (r *RepoStruct) withoutMergeDirectives() *RepoStruct {
+	if r == nil {
+		return nil
+	}
+	fields := func(repo *RepoStruct) []*string {
+		return []*string{&repo.Title, &repo.RepoTemplate, &repo.GitRemote, &repo.Upstream, &repo.Flow.Name}
+	}
+	hasDirectives := stringMapHasDirectives(r.More) || stringMapHasDirectives(r.Apps)
+	for _, field := range fields(r) {
+		hasDirectives = hasDirectives || (*field == mergeDelete)
+	}
+	if !hasDirectives {
+		return r
+	}
+
+	ret := *r
+	ret.More = mergeStringMap(make(map[string]string), r.More)
+	ret.Apps = mergeStringMap(make(map[string]string), r.Apps)
+	for _, field := range fields(&ret) {
+		if *field == mergeDelete {
+			*field = ""
+		}
+	}
+	return &ret
+}
+
 type RepoFlow struct {
 	Name    string
 	objects map[string]map[string]string
@@ -12,11 +12,15 @@ func (r ReposStruct) mergeFrom(from ReposStruct) ReposStruct {
 		r = make(ReposStruct)
 	}
 	for key, repoFrom := range from {
-		if repo, found := r[key]; found {
+		if repoFrom != nil && repoFrom.mergeDirective == mergeDelete {
+			delete(r, key)
+			continue
+		}
+		if repo, found := r[key]; found && (repoFrom == nil || repoFrom.mergeDirective != mergeReplace) {
 			repo.mergeFrom(repoFrom)
 			continue
 		}
-		r[key] = repoFrom
+		r[key] = repoFrom.withoutMergeDirectives()
 	}
 	return r
 }
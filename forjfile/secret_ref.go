@@ -0,0 +1,81 @@
+package forjfile
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A Forjfile value can refer to a forjj secret:
+//
+//	apps:
+//	  jenkins:
+//	    seed-job-repo: https://admin:{{ secret "app/jenkins/admin-pwd" }}@git.example.com/seed.git
+//
+// References are resolved only in plugins payloads. Everywhere else, the value keeps the reference, so the
+// secret is never saved or displayed.
+
+var secretRefRegexp = regexp.MustCompile(`{{-?\s*secret\s+"([^"]*)"\s*-?}}`)
+
+// secretRefPlaceholder replace a secret reference while the value is evaluated as a template.
+const secretRefPlaceholder = "__FORJJ_SECRET_%d__"
+
+// SecretRefs are the secrets referenced by a Forjfile value.
+type SecretRefs struct {
+	keyPaths []string // Secret key path, per placeholder index.
+}
+
+// HasSecretRef return true if the value refers to a forjj secret.
+func HasSecretRef(value string) bool {
+	return secretRefRegexp.MatchString(value)
+}
+
+// IsTemplateOnly return true if the value is entirely a single template expression, like
+// '{{ .Current.Creds.<flag> }}'. Text around the expression is not accepted.
+func IsTemplateOnly(value string) bool {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "{{") || strings.Index(value, "}}") != len(value)-2 || strings.LastIndex(value, "{{") != 0 {
+		return false
+	}
+	return strings.Trim(value[2:len(value)-2], "- \t\n") != ""
+}
+
+// ParseSecretRefs replace secret references of a value by placeholders, so the value can be evaluated as a
+// template without the `secret` function and without secrets.
+func ParseSecretRefs(value string) (tmpl string, refs *SecretRefs, err error) {
+	refs = new(SecretRefs)
+	tmpl = secretRefRegexp.ReplaceAllStringFunc(value, func(ref string) string {
+		keyPath := secretRefRegexp.FindStringSubmatch(ref)[1]
+		if keys := strings.Split(keyPath, "/"); len(keys) != 3 || keys[0] == "" || keys[1] == "" || keys[2] == "" {
+			err = fmt.Errorf("'%s' is not a valid secret reference. Format is {{ secret \"<objectType>/<objectInstance>/<key>\" }}", ref)
+		}
+		refs.keyPaths = append(refs.keyPaths, keyPath)
+		return fmt.Sprintf(secretRefPlaceholder, len(refs.keyPaths)-1)
+	})
+	return
+}
+
+// KeyPaths return the key path of secrets referenced.
+func (r *SecretRefs) KeyPaths() []string {
+	return r.keyPaths
+}
+
+// Resolve replace placeholders of the value by secrets returned by get.
+func (r *SecretRefs) Resolve(value string, get func(keyPath string) (string, error)) (string, error) {
+	for index, keyPath := range r.keyPaths {
+		secret, err := get(keyPath)
+		if err != nil {
+			return "", fmt.Errorf("Unable to resolve secret reference '%s'. %s", keyPath, err)
+		}
+		value = strings.Replace(value, fmt.Sprintf(secretRefPlaceholder, index), secret, -1)
+	}
+	return value, nil
+}
+
+// Redact replace placeholders of the value by secret references, to display it without secrets.
+func (r *SecretRefs) Redact(value string) string {
+	for index, keyPath := range r.keyPaths {
+		value = strings.Replace(value, fmt.Sprintf(secretRefPlaceholder, index), `{{ secret "`+keyPath+`" }}`, -1)
+	}
+	return value
+}
@@ -0,0 +1,109 @@
+package forjfile
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretRefs(t *testing.T) {
+	assert := assert.New(t)
+
+	/*********************************/
+	testCase := "when the value has no secret reference"
+
+	// ------------ Run function to test
+	tmpl, refs, err := ParseSecretRefs("https://{{ .Current.Name }}.example.com")
+
+	// ------------ Test result
+	assert.Falsef(HasSecretRef("https://{{ .Current.Name }}.example.com"), "Expect no secret reference %s", testCase)
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf("https://{{ .Current.Name }}.example.com", tmpl, "Expect value unchanged %s", testCase)
+	assert.Emptyf(refs.KeyPaths(), "Expect no key path %s", testCase)
+
+	/*********************************/
+	testCase = "when the value refers to secrets"
+	value := `https://admin:{{ secret "app/jenkins/admin-pwd" }}@{{ .Current.Name }}:{{-secret "app/jenkins/token"-}}`
+	secrets := map[string]string{
+		"app/jenkins/admin-pwd": "s3cr3t",
+		"app/jenkins/token":     "t0k3n",
+	}
+	get := func(keyPath string) (string, error) {
+		if v, found := secrets[keyPath]; found {
+			return v, nil
+		}
+		return "", fmt.Errorf("not found")
+	}
+
+	// ------------ Run function to test
+	tmpl, refs, err = ParseSecretRefs(value)
+
+	// ------------ Test result
+	assert.Truef(HasSecretRef(value), "Expect secret references %s", testCase)
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf("https://admin:__FORJJ_SECRET_0__@{{ .Current.Name }}:__FORJJ_SECRET_1__", tmpl, "Expect references replaced by placeholders %s", testCase)
+	assert.Equalf([]string{"app/jenkins/admin-pwd", "app/jenkins/token"}, refs.KeyPaths(), "Expect key paths %s", testCase)
+
+	// ------------ Run function to test
+	resolved, err := refs.Resolve(tmpl, get)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf("https://admin:s3cr3t@{{ .Current.Name }}:t0k3n", resolved, "Expect secrets resolved %s", testCase)
+	assert.Equalf(`https://admin:{{ secret "app/jenkins/admin-pwd" }}@{{ .Current.Name }}:{{ secret "app/jenkins/token" }}`,
+		refs.Redact(tmpl), "Expect secrets redacted %s", testCase)
+
+	/*********************************/
+	testCase = "when a referenced secret is not found"
+	delete(secrets, "app/jenkins/token")
+
+	// ------------ Run function to test
+	_, err = refs.Resolve(tmpl, get)
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when the secret reference is invalid"
+
+	// ------------ Run function to test
+	_, _, err = ParseSecretRefs(`{{ secret "app/jenkins" }}`)
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	// ------------ Run function to test
+	_, _, err = ParseSecretRefs(`{{ secret "app//admin-pwd" }}`)
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+}
+
+func TestIsTemplateOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{value: "{{ .Current.Creds.token }}", expected: true},
+		{value: " {{- .Current.Creds.token -}}\n", expected: true},
+		{value: "tok{{en"},
+		{value: "token}}"},
+		{value: "my-token {{ .Current.Name }}"},
+		{value: "{{ .Current.Name }}-my-token"},
+		{value: "{{ .Current.Name }}my-token{{ .Current.Name }}"},
+		{value: "{{}}"},
+	}
+
+	for _, test := range tests {
+		testCase := "when the value is '" + test.value + "'"
+
+		// ------------ Run function to test
+		ret := IsTemplateOnly(test.value)
+
+		// ------------ Test result
+		assert.Equalf(test.expected, ret, "Expect the template detection %s", testCase)
+	}
+}
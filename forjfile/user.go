@@ -7,6 +7,10 @@ import (
 
 const (
 	userRole = "role"
+	// UserSecretsPublicKey is the user public key used to wrap the forjj secrets key.
+	UserSecretsPublicKey = "secrets-public-key"
+	// UserSigningKey is the user key fingerprint or ID used to sign approvals of deployments publish.
+	UserSigningKey = "signing-key"
 )
 
 type UserStruct struct {
@@ -14,8 +18,13 @@ type UserStruct struct {
 	Role    string
 	More    map[string]string `yaml:",inline"`
 	sources *sourcesinfo.Sources
+	// Merge directive (!delete or !replace) used when merging this user to another Forjfile.
+	mergeDirective string
 }
 
+// userStructYaml is used to decode/encode UserStruct with default yaml functions.
+type userStructYaml UserStruct
+
 // TODO: Add struct unit tests
 
 // Flags returns the list of keys found in this object.
@@ -29,14 +38,62 @@ func (u *UserStruct) Flags() (flags []string) {
 
 }
 
+// UnmarshalYAML decode a user. The user can be set to "!delete" to remove it when merged.
+func (u *UserStruct) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if directive, found, err := decodeMergeDirective(unmarshal); found {
+		u.mergeDirective = directive
+		return err
+	}
+	if err := unmarshal((*userStructYaml)(u)); err != nil {
+		return err
+	}
+	u.mergeDirective = extractMergeReplace(u.More)
+	return nil
+}
+
+// MarshalYAML encode a user with his merge directive.
+func (u *UserStruct) MarshalYAML() (interface{}, error) {
+	if u == nil {
+		return nil, nil
+	}
+	if u.mergeDirective == mergeDelete {
+		return mergeDelete, nil
+	}
+	data := *(*userStructYaml)(u)
+	data.More = withMergeReplace(u.More, u.mergeDirective)
+	return data, nil
+}
+
 func (u *UserStruct) mergeFrom(from *UserStruct) {
+	if from == nil {
+		return
+	}
 	for _, flag := range from.Flags() {
 		if v, found, source := from.Get(flag); found {
-			u.Set(source, flag, v.GetString())
+			value := v.GetString()
+			if value == mergeDelete {
+				value = ""
+			}
+			u.Set(source, flag, value)
 		}
 	}
 }
 
+// withoutMergeDirectives return the user without any key merge directives.
+//
+// If the user has some, a copy is returned, without those directives.
+func (u *UserStruct) withoutMergeDirectives() *UserStruct {
+	if u == nil || (u.Role != mergeDelete && !stringMapHasDirectives(u.More)) {
+		return u
+	}
+	ret := *u
+	if ret.Role == mergeDelete {
+		ret.Role = ""
+	}
+	ret.More = mergeStringMap(make(map[string]string), u.More)
+	return &ret
+}
+
 func (u *UserStruct) Get(field string) (value *goforjj.ValueStruct, found bool, source string) {
 	source = u.sources.Get(field)
 	switch field {
@@ -69,11 +126,14 @@ func (u *UserStruct) Set(source, field, value string) {
 			u.More = make(map[string]string)
 		}
 		if v, found := u.More[field]; found {
-			if v != value {
+			if value == "" {
+				delete(u.More, field)
+				u.forge.dirty()
+			} else if v != value {
 				u.forge.dirty()
 				u.More[field] = value
 			}
-		} else {
+		} else if value != "" {
 			u.forge.dirty()
 			u.More[field] = value
 		}
@@ -10,10 +10,14 @@ func (u UsersStruct) mergeFrom(from UsersStruct) UsersStruct {
 		u = make(UsersStruct)
 	}
 	for k, userFrom := range from {
-		if user, found := u[k]; found {
+		if userFrom != nil && userFrom.mergeDirective == mergeDelete {
+			delete(u, k)
+			continue
+		}
+		if user, found := u[k]; found && user != nil && (userFrom == nil || userFrom.mergeDirective != mergeReplace) {
 			user.mergeFrom(userFrom)
 		} else {
-			u[k] = userFrom
+			u[k] = userFrom.withoutMergeDirectives()
 		}
 	}
 	return u
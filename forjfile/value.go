@@ -18,6 +18,12 @@ func (v *ForjValue) SetDefault(aDefValue string) (updated bool) {
 	return
 }
 
+// selected return true if aValue is the value returned by Get, or if aValue is empty (value removed).
+// A default value hidden by a value is not selected.
+func (v *ForjValue) selected(aValue string) bool {
+	return aValue == "" || v.Get() == aValue
+}
+
 func (v *ForjValue) Get() string {
 	if v.value != "" {
 		return v.value
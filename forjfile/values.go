@@ -20,6 +20,9 @@ func (v ForjValues) Map() (values map[string]string) {
 }
 
 func (v ForjValues) MarshalYAML() (interface{}, error) {
+	if v.isMergeDelete() {
+		return mergeDelete, nil
+	}
 	values := make(map[string]string)
 
 	for key, value := range v {
@@ -29,3 +32,59 @@ func (v ForjValues) MarshalYAML() (interface{}, error) {
 	}
 	return values, nil
 }
+
+// UnmarshalYAML accept a "!delete" merge directive as object instance value.
+func (v *ForjValues) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if directive, found, err := decodeMergeDirective(unmarshal); found {
+		*v = ForjValues{directive: ForjValue{value: directive}}
+		return err
+	}
+	return unmarshal((*map[string]ForjValue)(v))
+}
+
+// isMergeDelete return true if the instance is a "!delete" merge directive.
+func (v ForjValues) isMergeDelete() (found bool) {
+	_, found = v[mergeDelete]
+	return
+}
+
+// isMergeReplace return true if the instance has the "!replace" merge directive.
+func (v ForjValues) isMergeReplace() (found bool) {
+	_, found = v[mergeReplace]
+	return
+}
+
+// hasMergeDirectives return true if some keys are merge directives.
+func (v ForjValues) hasMergeDirectives() bool {
+	for key, value := range v {
+		if key == mergeDelete || key == mergeReplace || value.value == mergeDelete {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeFrom merge keys values. A "!delete" value removes the key.
+func (v ForjValues) mergeFrom(from ForjValues) {
+	for key, value := range from {
+		switch {
+		case key == mergeDelete || key == mergeReplace:
+		case value.value == mergeDelete:
+			delete(v, key)
+		default:
+			v[key] = value
+		}
+	}
+}
+
+// withoutMergeDirectives return the values without any merge directives.
+//
+// If some directives are found, a copy is returned, without those directives.
+func (v ForjValues) withoutMergeDirectives() ForjValues {
+	if !v.hasMergeDirectives() {
+		return v
+	}
+	ret := make(ForjValues)
+	ret.mergeFrom(v)
+	return ret
+}
@@ -39,6 +39,19 @@ const (
 
 	//InfraDriverNameField identify the infra driver name
 	InfraDriverNameField = "infra-driver-name"
+
+	// GitBackendField identify the git backend used by forjj. 'cli' (default) or 'go-git'.
+	// It is not a standard field, so it is stored as is with `forjj workspace set git-backend go-git`
+	GitBackendField = "git-backend"
+
+	// GitSigningFormatField identify how forjj signs commits. 'gpg' or 'ssh'. Commits are not signed if unset.
+	GitSigningFormatField = "git-signing-format"
+
+	// GitSigningKeyField identify the key used to sign commits. A GPG key ID or a SSH key file.
+	GitSigningKeyField = "git-signing-key"
+
+	// GitSigningProgramField identify the program used to sign commits. 'gpg' or 'ssh-keygen' by default.
+	GitSigningProgramField = "git-signing-program"
 )
 
 var (
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"forjj/creds"
 	"github.com/alecthomas/kingpin"
 	"github.com/forj-oss/forjj-modules/trace"
 	"log"
@@ -31,6 +32,14 @@ func main() {
 		gotrace.SetDebug()
 	}
 
+	// Secure values read from creds are masked in log output, like plugins status and error messages.
+	// gotrace messages are written with log, so they are masked too.
+	if os.Getenv(creds.RedactEnvVar) == "false" {
+		log.Printf("%s set to 'false'. Secure values are displayed.\n", creds.RedactEnvVar)
+		creds.SetRedaction(false)
+	}
+	log.SetOutput(creds.RedactWriter(os.Stderr))
+
 	forj_app.init()
 	parse, err := forj_app.cli.Parse(os.Args[1:], nil)
 
@@ -51,6 +60,10 @@ func main() {
 	if f, found := forj_app.actionDispatch[forj_app.contextAction] ; found {
 		f(action)
 	}
+	if code := forj_app.secrets.ExitCode(); code != 0 {
+		forj_app.driver_cleanup_all()
+		os.Exit(code)
+	}
 }
 
 func (a *Forj) contextDisplayed() {
@@ -1,9 +1,14 @@
 package git
 
+import "strings"
+
 // FileStatus define the GIT file status
 type FileStatus struct {
-	index    rune
-	workTree rune
+	index     rune
+	workTree  rune
+	origPath  string // Source path of a renamed or copied file.
+	submodule string // Submodule state 'S<c><m><u>'. Empty if the file is not a submodule.
+	unmerged  bool
 }
 
 // Index returns the Index status of the file
@@ -16,11 +21,40 @@ func (s FileStatus) WorkTree() rune {
 	return s.workTree
 }
 
+// OrigPath returns the path of the file before a rename or a copy. It is empty otherwise.
+func (s FileStatus) OrigPath() string {
+	return s.origPath
+}
+
+// IsUnmerged returns true if the file has a merge conflict.
+func (s FileStatus) IsUnmerged() bool {
+	return s.unmerged
+}
+
+// Submodule returns the submodule state of the file.
+//
+// commitChanged is true if the submodule commit changed, modified if it has tracked changes and
+// untracked if it has untracked files.
+func (s FileStatus) Submodule() (isSubmodule, commitChanged, modified, untracked bool) {
+	if s.submodule == "" {
+		return
+	}
+	return true, s.submodule[1] == 'C', s.submodule[2] == 'M', s.submodule[3] == 'U'
+}
+
+// set the index and work tree status from the git status 'XY' field. '.' means unmodified.
 func (s *FileStatus) set(value string) {
 	if s == nil {
 		return
 	}
-	statusFile := []rune(value)
+	statusFile := []rune(strings.Replace(value, ".", " ", -1))
 	s.index = statusFile[0]
 	s.workTree = statusFile[1]
-}
\ No newline at end of file
+}
+
+// setSubmodule set the submodule state from the git status '<sub>' field.
+func (s *FileStatus) setSubmodule(value string) {
+	if len(value) == 4 && value[0] == 'S' {
+		s.submodule = value
+	}
+}
@@ -58,58 +58,25 @@ func ShowGitPath() (msg string) {
 	return
 }
 
-// GetStatus return an GitStatus struct with the list of files, added, updated and renamed
+// GetStatus return an GitStatus struct with the list of files, added, updated, renamed, copied and unmerged
 //
-// It currently do not follow strictly all status use case as described in man git status
+// It is based on 'git status --porcelain=v2 -z'. See parseStatus.
 //
 func GetStatus() (gs *Status) {
-	gs = new(Status)
-
-	gs.Ready = make(map[string][]string)
-	gs.Ready.init(false)
-	gs.NotReady = make(map[string][]string)
-	gs.NotReady.init(true)
-	gs.files = make(gitFilesStatus)
-
-	statusRE, _ := regexp.Compile("^([ ?ADMR]{2}) (.*)$")
+	gs = newStatus()
 
 	var s string
 
-	s, gs.Err = Get("status", "--porcelain")
+	s, gs.Err = Get("status", "--porcelain=v2", "-z")
 	if gs.Err != nil || s == "" {
 		return
 	}
 
-	lines := strings.Split(s, "\n")
-
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		m := statusRE.FindStringSubmatch(line)
-		if m == nil {
-			continue
-		}
-
-		var status FileStatus
-		status.set(m[1])
-		file := m[2]
-
-		gs.files[file] = status
-
-		if v := status.workTree ; v == ' ' {
-			gs.Ready.add(string(status.index), file)
-			continue
-		}
-
-		if v := status.index ; v == ' ' || v == '?' {
-			gs.NotReady.add(string(status.workTree), file)
-		}
-	}
+	gs.Err = gs.parseStatus(s)
 	return
 }
 
-// Commit Do a git commit
+// Commit Do a git commit. The commit is signed if SetSigning has been configured.
 func Commit(msg string, errorIfEmpty bool) (err error) {
 	s := GetStatus()
 	if s.Ready.CountTracked() == 0 {
@@ -118,7 +85,10 @@ func Commit(msg string, errorIfEmpty bool) (err error) {
 		}
 		return
 	}
-	if Do("commit", "-m", msg) > 0 {
+	if Do(commitArgs("-m", msg)...) > 0 {
+		if signing.format != "" {
+			return signingError()
+		}
 		return fmt.Errorf("Unable to commit")
 	}
 	return nil
@@ -151,12 +121,18 @@ func Branches() ([]string, error) {
 
 // RemoteBranches returns the list of Remote branches found
 // Formatted as <remote>/<branchName>
-func RemoteBranches() ([]string, error) {
+func RemoteBranches() (branches []string, _ error) {
 	v, err := Get("branch", "-r")
 	if err != nil || v == "" {
 		return []string{}, err
 	}
-	return strings.Split(v, "\n"), nil
+	for _, branch := range strings.Split(v, "\n") {
+		// Symbolic references are displayed as '<remote>/HEAD -> <remote>/<branchName>'
+		if branch = strings.TrimSpace(branch); branch != "" && !strings.Contains(branch, " -> ") {
+			branches = append(branches, branch)
+		}
+	}
+	return
 }
 
 // RemoteBranchExist check is remote branch if known by GIT.
@@ -176,7 +152,7 @@ func RemoteBranchExist(remote string) (bool, error) {
 	return false, nil
 }
 
-// BranchExist return true if the branch exist
+// BranchExist return true if the local branch exist
 func BranchExist(remote string) (bool, error) {
 	branches, err := Branches()
 	if err != nil {
@@ -184,7 +160,8 @@ func BranchExist(remote string) (bool, error) {
 	}
 
 	for _, branch := range branches {
-		if branch == remote {
+		// 'git branch' prefixes the current branch with '* ' and others with spaces.
+		if strings.TrimLeft(branch, "* ") == remote {
 			return true, nil
 		}
 	}
@@ -256,7 +233,7 @@ func RemoteURL(remote string) (string, bool, error) {
 	remMatch, _ := regexp.Compile(`^ *(\w+)[ \t]*(.*) \((fetch)\)$`)
 	for _, aRemote := range remotes {
 		if v := remMatch.FindStringSubmatch(aRemote); v != nil && v[1] == remote {
-			return v[1], true, nil
+			return v[2], true, nil
 		}
 	}
 	return "", false, nil
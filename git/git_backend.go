@@ -0,0 +1,31 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+const (
+	// CliBackend runs git commands with the git binary. This is the default.
+	CliBackend = "cli"
+	// GoGitBackend runs git commands with go-git. The git binary is not required.
+	GoGitBackend = "go-git"
+)
+
+// SetBackend select the implementation used to run git commands.
+// The git binary is required only by the git command line backend.
+func SetBackend(name string) error {
+	switch name {
+	case "", CliBackend:
+		if _, err := exec.LookPath("git"); err != nil {
+			return fmt.Errorf("Unable to find 'git' command. Ensure it available in your PATH and retry, "+
+				"or select the '%s' backend with 'forjj workspace set git-backend %s'", GoGitBackend, GoGitBackend)
+		}
+		defaultCmd = gitCmd{}
+	case GoGitBackend:
+		defaultCmd = gitGo{}
+	default:
+		return fmt.Errorf("Unknown git backend '%s'. Supported: '%s' and '%s'", name, CliBackend, GoGitBackend)
+	}
+	return nil
+}
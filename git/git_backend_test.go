@@ -0,0 +1,319 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testBackends are the git backends which must pass the same tests.
+var testBackends = []string{CliBackend, GoGitBackend}
+
+// runOnBackends run the test with each git backend.
+func runOnBackends(t *testing.T, test func(t *testing.T)) {
+	saved, savedLog := defaultCmd, logFunc
+	defer func() { defaultCmd, logFunc = saved, savedLog }()
+	SetLogFunc(logOutTest)
+
+	for _, backend := range testBackends {
+		if err := SetBackend(backend); err != nil {
+			t.Fatalf("Unable to select the git backend '%s'. %s", backend, err)
+		}
+		t.Run(backend, test)
+	}
+}
+
+func TestSetBackend(t *testing.T) {
+	assert := assert.New(t)
+
+	saved := defaultCmd
+	defer func() { defaultCmd = saved }()
+
+	/*********************************/
+	testCase := "when the go-git backend is selected"
+
+	// ------------ Run function to test
+	err := SetBackend(GoGitBackend)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.IsTypef(gitGo{}, defaultCmd, "Expect go-git backend to be used %s", testCase)
+
+	/*********************************/
+	testCase = "when no backend is selected"
+
+	// ------------ Run function to test
+	err = SetBackend("")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.IsTypef(gitCmd{}, defaultCmd, "Expect git command line to be used %s", testCase)
+
+	/*********************************/
+	testCase = "when an unknown backend is selected"
+
+	// ------------ Run function to test
+	err = SetBackend("svn")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when the git binary is not found"
+
+	savedPath := os.Getenv("PATH")
+	defer os.Setenv("PATH", savedPath)
+	os.Setenv("PATH", "")
+
+	// ------------ Run function to test
+	errCli := SetBackend(CliBackend)
+	errGoGit := SetBackend(GoGitBackend)
+
+	// ------------ Test result
+	assert.Errorf(errCli, "Expect the git command line backend to require git %s", testCase)
+	assert.NoErrorf(errGoGit, "Expect the go-git backend to not require git %s", testCase)
+	assert.IsTypef(gitGo{}, defaultCmd, "Expect go-git backend to be used %s", testCase)
+}
+
+func TestBackendCommands(t *testing.T) {
+	runOnBackends(t, func(t *testing.T) {
+		assert := assert.New(t)
+
+		cur, _ := os.Getwd()
+		defer os.Chdir(cur)
+		repo, _ := ioutil.TempDir("", "forjj-git-repo")
+		defer os.RemoveAll(repo)
+		bare, _ := ioutil.TempDir("", "forjj-git-bare")
+		defer os.RemoveAll(bare)
+		os.Chdir(repo)
+
+		write := func(file, content string) {
+			if err := ioutil.WriteFile(path.Join(repo, file), []byte(content), 0644); err != nil {
+				t.Fatalf("Unable to write '%s'. %s", file, err)
+			}
+		}
+		status := func() string {
+			s, _ := Get("status", "--porcelain")
+			return s
+		}
+
+		/*********************************/
+		testCase := "when the repository is initialized"
+
+		// ------------ Run function to test
+		code := Do("init", "-q")
+		Do("config", "user.email", "test@forjj.io")
+		Do("config", "user.name", "test")
+		Do("config", "commit.gpgsign", "false")
+		Do("checkout", "-q", "-b", "master")
+
+		// ------------ Test result
+		assert.Equalf(0, code, "Expect init to succeed %s", testCase)
+		assert.Equalf("master", GetCurrentBranch(), "Expect master to be the current branch %s", testCase)
+		_, err := Get("log", "-1", "--pretty=%H")
+		assert.Errorf(err, "Expect log to fail without commits %s", testCase)
+
+		/*********************************/
+		testCase = "when a file is added and committed"
+
+		write("a", "a\n")
+		assert.Equalf("?? a", status(), "Expect the file to be untracked %s", testCase)
+
+		// ------------ Run function to test
+		code = Add([]string{"a"})
+		statusAdded := status()
+		err = Commit("first commit", true)
+
+		// ------------ Test result
+		assert.Equalf(0, code, "Expect add to succeed %s", testCase)
+		assert.Equalf("A  a", statusAdded, "Expect the file to be ready %s", testCase)
+		assert.NoErrorf(err, "Expect commit to succeed %s", testCase)
+		assert.Equalf("", status(), "Expect a clean repository %s", testCase)
+		subject, _ := Get("log", "-1", "--format=%s")
+		assert.Equalf("first commit", subject, "Expect the commit subject %s", testCase)
+		first, _ := Get("rev-parse", "HEAD")
+		assert.Lenf(first, 40, "Expect a commit hash %s", testCase)
+		oneline, _ := Get("log", "master", "-1", "--oneline")
+		assert.Equalf(first[:7]+" first commit", oneline, "Expect a short log %s", testCase)
+		files, _ := Get("ls-files", "a")
+		assert.Equalf("a", files, "Expect the file to be controlled %s", testCase)
+		assert.Errorf(Commit("nothing", true), "Expect commit to fail without files %s", testCase)
+
+		/*********************************/
+		testCase = "when a branch is created and merged"
+
+		// ------------ Run function to test
+		Do("checkout", "-q", "-b", "feature")
+		write("b", "b\n")
+		Add([]string{"b"})
+		Commit("second commit", true)
+		feature := GetCurrentBranch()
+		Do("checkout", "-q", "master")
+		_, errMaster := os.Stat(path.Join(repo, "b"))
+		diff, _ := Get("diff", "--name-only", "master", "feature")
+		code = Do("merge", "--ff-only", "feature")
+
+		// ------------ Test result
+		assert.Equalf("feature", feature, "Expect feature to be the current branch %s", testCase)
+		assert.Truef(os.IsNotExist(errMaster), "Expect 'b' to not exist in master %s", testCase)
+		assert.Equalf("b", diff, "Expect 'b' to differ between branches %s", testCase)
+		assert.Equalf(0, code, "Expect merge to succeed %s", testCase)
+		_, err = os.Stat(path.Join(repo, "b"))
+		assert.NoErrorf(err, "Expect 'b' to exist in master %s", testCase)
+		branches, _ := Branches()
+		assert.Equalf([]string{"  feature", "* master"}, branches, "Expect branches to be listed %s", testCase)
+		found, _ := BranchExist("feature")
+		assert.Truef(found, "Expect feature branch to exist %s", testCase)
+		base, _ := Get("merge-base", "@{0}", "feature")
+		head, _ := Get("rev-parse", "@{0}")
+		assert.Equalf(head, base, "Expect the merge base to be HEAD %s", testCase)
+		log, _ := Get("log", "--format=%h %s", first+"..HEAD")
+		assert.Equalf(head[:7]+" second commit", log, "Expect only the second commit %s", testCase)
+
+		/*********************************/
+		testCase = "when a file is updated and restored"
+
+		write("a", "updated\n")
+		Add([]string{"a"})
+
+		// ------------ Run function to test
+		Do("reset", "HEAD", "a")
+		statusReset := status()
+		Do("checkout", "a")
+
+		// ------------ Test result
+		assert.Equalf(" M a", statusReset, "Expect the file to be not ready %s", testCase)
+		assert.Equalf("", status(), "Expect a clean repository %s", testCase)
+		data, _ := ioutil.ReadFile(path.Join(repo, "a"))
+		assert.Equalf("a\n", string(data), "Expect the file to be restored %s", testCase)
+
+		/*********************************/
+		testCase = "when updated files are stashed"
+
+		write("a", "stashed\n")
+		write("d", "d\n")
+		Add([]string{"d"})
+
+		// ------------ Run function to test
+		code = Do("stash")
+		statusStashed := status()
+		_, errAdded := os.Stat(path.Join(repo, "d"))
+		Do("checkout", "-q", "feature")
+		codePop := Do("stash", "pop")
+
+		// ------------ Test result
+		assert.Equalf(0, code, "Expect stash to succeed %s", testCase)
+		assert.Equalf("", statusStashed, "Expect a clean repository %s", testCase)
+		assert.Truef(os.IsNotExist(errAdded), "Expect the added file to be stashed %s", testCase)
+		assert.Equalf(0, codePop, "Expect stash pop to succeed %s", testCase)
+		assert.Equalf(" M a\nA  d", status(), "Expect stashed files to be restored %s", testCase)
+		data, _ = ioutil.ReadFile(path.Join(repo, "a"))
+		assert.Equalf("stashed\n", string(data), "Expect the file content to be restored %s", testCase)
+		assert.NotEqualf(0, Do("stash", "pop"), "Expect stash pop to fail without stash entry %s", testCase)
+		Do("reset", "--hard", "HEAD")
+		os.Remove(path.Join(repo, "d"))
+		Do("checkout", "-q", "master")
+		assert.Equalf("", status(), "Expect a clean repository %s", testCase)
+
+		/*********************************/
+		testCase = "when a file is shown from a revision"
+
+		// ------------ Run function to test
+		content, err := Get("show", "HEAD:a")
+		_, errParent := Get("show", first+"^:a")
+		_, errMissing := Get("show", "HEAD:missing")
+		toplevel, _ := Get("rev-parse", "--show-toplevel")
+
+		// ------------ Test result
+		assert.NoErrorf(err, "Expect show to succeed %s", testCase)
+		assert.Equalf("a", content, "Expect the file content %s", testCase)
+		assert.Errorf(errParent, "Expect show to fail without parent commit %s", testCase)
+		assert.Errorf(errMissing, "Expect show to fail without file %s", testCase)
+		repoPath, _ := filepath.EvalSymlinks(repo)
+		assert.Equalf(repoPath, toplevel, "Expect the work tree root %s", testCase)
+
+		/*********************************/
+		testCase = "when the branch is reset"
+
+		// ------------ Run function to test
+		Do("reset", "--soft", first)
+		statusSoft := status()
+		Do("reset", "--hard", "HEAD")
+
+		// ------------ Test result
+		assert.Equalf("A  b", statusSoft, "Expect changes to be kept ready %s", testCase)
+		assert.Equalf("", status(), "Expect a clean repository %s", testCase)
+		head, _ = Get("rev-parse", "HEAD")
+		assert.Equalf(first, head, "Expect HEAD to be moved %s", testCase)
+
+		/*********************************/
+		testCase = "when the branch is pushed to a remote"
+
+		Do("init", "-q", "--bare", bare)
+
+		// ------------ Run function to test
+		Do("remote", "add", "origin", bare)
+		code = Do("push", "-u", "origin", "master")
+
+		// ------------ Test result
+		assert.Equalf(0, code, "Expect push to succeed %s", testCase)
+		assert.Truef(RemoteExist("origin"), "Expect the remote to exist %s", testCase)
+		url, found, _ := RemoteURL("origin")
+		assert.Truef(found, "Expect the remote URL to be found %s", testCase)
+		assert.Equalf(bare, url, "Expect the remote URL %s", testCase)
+		assert.Equalf(0, Do("fetch", "origin"), "Expect fetch to succeed %s", testCase)
+		found, _ = RemoteBranchExist("origin/master")
+		assert.Truef(found, "Expect the remote branch to exist %s", testCase)
+		remoteStatus, _ := RemoteStatus("origin/master")
+		assert.Equalf("=", remoteStatus, "Expect branches to be in sync %s", testCase)
+		verbose, _ := Get("branch", "-vv")
+		assert.Regexpf(regexp.MustCompile(`[* ] master.* \[origin/master( .*)?\]`), verbose, "Expect master to track origin/master %s", testCase)
+
+		/*********************************/
+		testCase = "when the branch is ahead of the remote"
+
+		write("c", "c\n")
+		Add([]string{"c"})
+		Commit("third commit", true)
+
+		// ------------ Run function to test
+		remoteStatus, _ = RemoteStatus("origin/master")
+		err = Push()
+
+		// ------------ Test result
+		assert.Equalf("+1", remoteStatus, "Expect master to be ahead %s", testCase)
+		assert.NoErrorf(err, "Expect push to succeed %s", testCase)
+		Do("fetch", "origin")
+		remoteStatus, _ = RemoteStatus("origin/master")
+		assert.Equalf("=", remoteStatus, "Expect branches to be in sync %s", testCase)
+
+		/*********************************/
+		testCase = "when the upstream branch configuration is read"
+
+		// ------------ Run function to test
+		remote, _ := Get("config", "branch.master.remote")
+		merge, _ := Get("config", "branch.master.merge")
+
+		// ------------ Test result
+		assert.Equalf("origin", remote, "Expect the upstream remote %s", testCase)
+		assert.Equalf("refs/heads/master", merge, "Expect the upstream branch %s", testCase)
+
+		/*********************************/
+		testCase = "when a branch is deleted"
+
+		// ------------ Run function to test
+		code = Do("branch", "-D", "feature")
+		codeCurrent := Do("branch", "-D", "master")
+
+		// ------------ Test result
+		assert.Equalf(0, code, "Expect the branch to be deleted %s", testCase)
+		found, _ = BranchExist("feature")
+		assert.Falsef(found, "Expect the branch to not exist %s", testCase)
+		assert.NotEqualf(0, codeCurrent, "Expect the current branch to not be deleted %s", testCase)
+	})
+}
@@ -0,0 +1,1304 @@
+package git
+
+import (
+	"fmt"
+	"forjj/utils"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/forj-oss/forjj-modules/trace"
+	gogit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	formatConfig "gopkg.in/src-d/go-git.v4/plumbing/format/config"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/client"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/server"
+)
+
+// gitGo implements GitCmdInterface with go-git. The git binary is not required.
+//
+// It interprets the git commands and options used by forjj, and returns the same output
+// and status code than the git command line. Other commands fail with status code 1.
+type gitGo struct{}
+
+// gitGoExit is an error with the status code returned by the git command line.
+type gitGoExit struct {
+	code int
+	err  error
+}
+
+func (e gitGoExit) Error() string {
+	return e.err.Error()
+}
+
+// gitGoCmd is a git command line parsed.
+type gitGoCmd struct {
+	name   string
+	args   []string          // Arguments, without options.
+	flags  map[string]string // Options. '--format=%H' is stored as flags["--format"] = "%H"
+	paths  []string          // Arguments given after '--'
+	config map[string]string // Configuration given with 'git -c <name>=<value> <command>'
+}
+
+// gitGoValueFlags are options followed by a value.
+var gitGoValueFlags = map[string]bool{
+	"-m": true,
+}
+
+type gitGoHandler func(*gitGoCmd) (string, error)
+
+var gitGoCommands map[string]gitGoHandler
+
+func init() {
+	// Local remotes are served in process instead of calling git-upload-pack/git-receive-pack.
+	client.InstallProtocol("file", server.DefaultServer)
+
+	gitGoCommands = map[string]gitGoHandler{
+		"add":        gitGoAdd,
+		"branch":     gitGoBranch,
+		"checkout":   gitGoCheckout,
+		"commit":     gitGoCommit,
+		"config":     gitGoConfig,
+		"diff":       gitGoDiff,
+		"fetch":      gitGoFetch,
+		"init":       gitGoInit,
+		"log":        gitGoLog,
+		"ls-files":   gitGoLsFiles,
+		"merge":      gitGoMerge,
+		"merge-base": gitGoMergeBase,
+		"push":       gitGoPush,
+		"rebase":     gitGoRebase,
+		"remote":     gitGoRemote,
+		"reset":      gitGoReset,
+		"rev-parse":  gitGoRevParse,
+		"show":       gitGoShow,
+		"stash":      gitGoStash,
+		"status":     gitGoStatus,
+	}
+}
+
+func (c gitGo) getWithStatusCode(opts ...string) (string, int) {
+	colorCyan, colorReset := utils.DefColor(36)
+	logFunc(fmt.Sprintf("%s%sgit %s%s\n", colorCyan, context.indent, strings.Join(opts, " "), colorReset))
+	out, err := c.run(opts...)
+	return out, gitGoStatusCode(err)
+}
+
+func (c gitGo) get(opts ...string) (string, error) {
+	gotrace.Trace("RUNNING: git %s", strings.Join(opts, " "))
+	return c.run(opts...)
+}
+
+func (c gitGo) do(opts ...string) int {
+	colorCyan, colorReset := utils.DefColor(36)
+	logFunc(fmt.Sprintf("%s%sgit %s%s\n", colorCyan, context.indent, strings.Join(opts, " "), colorReset))
+	out, err := c.run(opts...)
+	if out != "" {
+		fmt.Println(out)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%serror: %s\n", context.indent, err)
+	}
+	return gitGoStatusCode(err)
+}
+
+// run parse and execute the git command.
+func (c gitGo) run(opts ...string) (string, error) {
+	cmd := parseGitGoCmd(opts)
+	handler, found := gitGoCommands[cmd.name]
+	if !found {
+		return "", gitGoUnsupported("git %s", cmd.name)
+	}
+	out, err := handler(cmd)
+	return strings.Trim(out, "\n"), err
+}
+
+func parseGitGoCmd(opts []string) (cmd *gitGoCmd) {
+	cmd = &gitGoCmd{flags: make(map[string]string), config: make(map[string]string)}
+	for len(opts) > 1 && opts[0] == "-c" {
+		config := strings.SplitN(opts[1], "=", 2)
+		if len(config) == 1 {
+			config = append(config, "true")
+		}
+		cmd.config[strings.ToLower(config[0])] = config[1]
+		opts = opts[2:]
+	}
+	if len(opts) == 0 {
+		return
+	}
+	cmd.name = opts[0]
+	for index := 1; index < len(opts); index++ {
+		opt := opts[index]
+		switch {
+		case opt == "--":
+			cmd.paths = append(cmd.paths, opts[index+1:]...)
+			return
+		case strings.HasPrefix(opt, "-") && len(opt) > 1:
+			value := ""
+			if i := strings.Index(opt, "="); i > 0 {
+				opt, value = opt[:i], opt[i+1:]
+			} else if gitGoValueFlags[opt] && index+1 < len(opts) {
+				index++
+				value = opts[index]
+			}
+			cmd.flags[opt] = value
+		default:
+			cmd.args = append(cmd.args, opt)
+		}
+	}
+	return
+}
+
+func (cmd *gitGoCmd) flag(names ...string) (value string, found bool) {
+	for _, name := range names {
+		if value, found = cmd.flags[name]; found {
+			return
+		}
+	}
+	return
+}
+
+func (cmd *gitGoCmd) arg(index int) (_ string) {
+	if index < len(cmd.args) {
+		return cmd.args[index]
+	}
+	return
+}
+
+func gitGoStatusCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exit, ok := err.(gitGoExit); ok {
+		return exit.code
+	}
+	return 1
+}
+
+func gitGoFatal(format string, a ...interface{}) error {
+	return gitGoExit{code: 128, err: fmt.Errorf(format, a...)}
+}
+
+func gitGoUnsupported(format string, a ...interface{}) error {
+	return fmt.Errorf("'%s' is not supported by the '%s' git backend", fmt.Sprintf(format, a...), GoGitBackend)
+}
+
+// ------------------ Repository helpers
+
+func gitGoOpen() (*gogit.Repository, error) {
+	r, err := gogit.PlainOpenWithOptions(".", &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, gitGoFatal("not a git repository. %s", err)
+	}
+	return r, nil
+}
+
+func gitGoWorktree() (*gogit.Repository, *gogit.Worktree, error) {
+	r, err := gitGoOpen()
+	if err != nil {
+		return nil, nil, err
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		return nil, nil, gitGoFatal("%s", err)
+	}
+	return r, w, nil
+}
+
+// gitGoRelPath return the path relative to the worktree root, as git stores it.
+func gitGoRelPath(w *gogit.Worktree, file string) (string, error) {
+	root, err := filepath.EvalSymlinks(w.Filesystem.Root())
+	if err != nil {
+		return "", err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if cwd, err = filepath.EvalSymlinks(cwd); err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(cwd, file)
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// gitGoResolve return the commit hash of a revision.
+//
+// Full or abbreviated commit hashes, references, branches and '@{0}' are supported.
+func gitGoResolve(r *gogit.Repository, rev string) (plumbing.Hash, error) {
+	if rev == "@{0}" || rev == "@" {
+		rev = "HEAD"
+	}
+	if hash, err := r.ResolveRevision(plumbing.Revision(rev)); err == nil {
+		return *hash, nil
+	}
+	if len(rev) >= 4 && len(rev) <= 40 && strings.Trim(strings.ToLower(rev), "0123456789abcdef") == "" {
+		commits, err := r.CommitObjects()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		found := plumbing.ZeroHash
+		commits.ForEach(func(c *object.Commit) error {
+			if strings.HasPrefix(c.Hash.String(), strings.ToLower(rev)) {
+				found = c.Hash
+				return io.EOF
+			}
+			return nil
+		})
+		if !found.IsZero() {
+			return found, nil
+		}
+	}
+	return plumbing.ZeroHash, gitGoFatal("ambiguous argument '%s': unknown revision", rev)
+}
+
+func gitGoCommitOf(r *gogit.Repository, rev string) (*object.Commit, error) {
+	hash, err := gitGoResolve(r, rev)
+	if err != nil {
+		return nil, err
+	}
+	return r.CommitObject(hash)
+}
+
+// gitGoCurrentBranch return the branch name referenced by HEAD. Empty if HEAD is detached.
+func gitGoCurrentBranch(r *gogit.Repository) (string, error) {
+	head, err := r.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", gitGoFatal("%s", err)
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		return "", nil
+	}
+	return head.Target().Short(), nil
+}
+
+// gitGoSignature return the user signature from the repository or global git configuration.
+func gitGoSignature(r *gogit.Repository) *object.Signature {
+	sig := &object.Signature{
+		Name:  os.Getenv("GIT_AUTHOR_NAME"),
+		Email: os.Getenv("GIT_AUTHOR_EMAIL"),
+		When:  time.Now(),
+	}
+	if cfg, err := r.Config(); err == nil && cfg.Raw != nil {
+		user := cfg.Raw.Section("user")
+		if sig.Name == "" {
+			sig.Name = user.Option("name")
+		}
+		if sig.Email == "" {
+			sig.Email = user.Option("email")
+		}
+	}
+	if sig.Name == "" || sig.Email == "" {
+		global := gitGoGlobalConfig().Section("user")
+		if sig.Name == "" {
+			sig.Name = global.Option("name")
+		}
+		if sig.Email == "" {
+			sig.Email = global.Option("email")
+		}
+	}
+	if sig.Name == "" {
+		sig.Name = "forjj"
+	}
+	return sig
+}
+
+// gitGoGlobalConfig return the user git configuration (~/.gitconfig).
+func gitGoGlobalConfig() *formatConfig.Config {
+	cfg := formatConfig.New()
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return cfg
+	}
+	fd, err := os.Open(filepath.Join(home, ".gitconfig"))
+	if err != nil {
+		return cfg
+	}
+	defer fd.Close()
+	formatConfig.NewDecoder(fd).Decode(cfg)
+	return cfg
+}
+
+// ------------------ Commands
+
+func gitGoInit(cmd *gitGoCmd) (string, error) {
+	dir := cmd.arg(0)
+	if dir == "" {
+		dir = "."
+	}
+	_, bare := cmd.flag("--bare")
+	abs, _ := filepath.Abs(dir)
+	if _, err := gogit.PlainInit(dir, bare); err == gogit.ErrRepositoryAlreadyExists {
+		return fmt.Sprintf("Reinitialized existing Git repository in %s", abs), nil
+	} else if err != nil {
+		return "", gitGoFatal("%s", err)
+	}
+	if _, quiet := cmd.flag("-q", "--quiet"); quiet {
+		return "", nil
+	}
+	return fmt.Sprintf("Initialized empty Git repository in %s", abs), nil
+}
+
+func gitGoAdd(cmd *gitGoCmd) (string, error) {
+	_, w, err := gitGoWorktree()
+	if err != nil {
+		return "", err
+	}
+	files := append(cmd.args, cmd.paths...)
+	if _, all := cmd.flag("-A", "--all"); all {
+		files = append(files, ".")
+	}
+	for _, file := range files {
+		rel, err := gitGoRelPath(w, file)
+		if err != nil {
+			return "", err
+		}
+		if rel == "." {
+			err = w.AddGlob("*")
+		} else {
+			_, err = w.Add(rel)
+		}
+		if err != nil {
+			return "", gitGoFatal("pathspec '%s' did not match any files. %s", file, err)
+		}
+	}
+	return "", nil
+}
+
+func gitGoCommit(cmd *gitGoCmd) (string, error) {
+	r, w, err := gitGoWorktree()
+	if err != nil {
+		return "", err
+	}
+	message, found := cmd.flag("-m", "--message")
+	if !found {
+		return "", gitGoUnsupported("git commit without -m")
+	}
+	status, err := w.Status()
+	if err != nil {
+		return "", err
+	}
+	staged := false
+	for _, file := range status {
+		if file.Staging != gogit.Unmodified && file.Staging != gogit.Untracked {
+			staged = true
+			break
+		}
+	}
+	if !staged {
+		return "nothing to commit, working tree clean", gitGoExit{code: 1, err: fmt.Errorf("nothing to commit")}
+	}
+	hash, err := w.Commit(message, &gogit.CommitOptions{Author: gitGoSignature(r)})
+	if err != nil {
+		return "", err
+	}
+	if _, sign := cmd.flag("-S", "--gpg-sign"); sign {
+		if hash, err = gitGoSignCommit(r, hash, cmd.config); err != nil {
+			return "", gitGoFatal("%s", err)
+		}
+	}
+	if _, quiet := cmd.flag("-q", "--quiet"); quiet {
+		return "", nil
+	}
+	branch, _ := gitGoCurrentBranch(r)
+	return fmt.Sprintf("[%s %s] %s", branch, hash.String()[:7], strings.SplitN(message, "\n", 2)[0]), nil
+}
+
+func gitGoStatus(cmd *gitGoCmd) (string, error) {
+	version, porcelain := cmd.flag("--porcelain")
+	if !porcelain {
+		return "", gitGoUnsupported("git status without --porcelain")
+	}
+	_, nul := cmd.flag("-z")
+	if version == "v2" && !nul {
+		return "", gitGoUnsupported("git status --porcelain=v2 without -z")
+	}
+	r, w, err := gitGoWorktree()
+	if err != nil {
+		return "", err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return "", err
+	}
+	files := make([]string, 0, len(status))
+	for file, fileStatus := range status {
+		if fileStatus.Staging == gogit.Unmodified && fileStatus.Worktree == gogit.Unmodified {
+			continue
+		}
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	if version == "v2" {
+		return gitGoStatusV2(r, w, status, files)
+	}
+	lines := make([]string, len(files))
+	for index, file := range files {
+		fileStatus := status[file]
+		if fileStatus.Staging == gogit.Renamed && fileStatus.Extra != "" {
+			file = fileStatus.Extra + " -> " + file
+		}
+		lines[index] = fmt.Sprintf("%c%c %s", fileStatus.Staging, fileStatus.Worktree, file)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// gitGoStatusV2 return the status of files in porcelain v2 format, with NUL terminated records.
+//
+// go-git doesn't report submodules and unmerged files.
+func gitGoStatusV2(r *gogit.Repository, w *gogit.Worktree, status gogit.Status, files []string) (string, error) {
+	idx, err := r.Storer.Index()
+	if err != nil {
+		return "", err
+	}
+	var headTree *object.Tree
+	if head, err := gitGoCommitOf(r, "HEAD"); err == nil {
+		if headTree, err = head.Tree(); err != nil {
+			return "", err
+		}
+	}
+	xy := func(code gogit.StatusCode) rune {
+		if code == gogit.Unmodified {
+			return '.'
+		}
+		return rune(code)
+	}
+
+	records := make([]string, 0, len(files)+1)
+	for _, file := range files {
+		fileStatus := status[file]
+		if fileStatus.Staging == gogit.Untracked {
+			records = append(records, "? "+file)
+			continue
+		}
+
+		headFile := file
+		if fileStatus.Staging == gogit.Renamed && fileStatus.Extra != "" {
+			headFile = fileStatus.Extra
+		}
+		headMode, headHash := filemode.Empty, plumbing.ZeroHash
+		if headTree != nil {
+			if entry, err := headTree.FindEntry(headFile); err == nil {
+				headMode, headHash = entry.Mode, entry.Hash
+			}
+		}
+		indexMode, indexHash := filemode.Empty, plumbing.ZeroHash
+		if entry, err := idx.Entry(file); err == nil {
+			indexMode, indexHash = entry.Mode, entry.Hash
+		}
+		workMode := filemode.Empty
+		if info, err := w.Filesystem.Lstat(file); err == nil {
+			workMode, _ = filemode.NewFromOSFileMode(info.Mode())
+		}
+
+		record := fmt.Sprintf("%c%c N... %06o %06o %06o %s %s", xy(fileStatus.Staging), xy(fileStatus.Worktree),
+			uint32(headMode), uint32(indexMode), uint32(workMode), headHash, indexHash)
+		if headFile != file {
+			records = append(records, "2 "+record+" R100 "+file, headFile)
+			continue
+		}
+		records = append(records, "1 "+record+" "+file)
+	}
+	records = append(records, "")
+	return strings.Join(records, "\x00"), nil
+}
+
+func gitGoBranch(cmd *gitGoCmd) (string, error) {
+	r, err := gitGoOpen()
+	if err != nil {
+		return "", err
+	}
+	if upstream, found := cmd.flag("--set-upstream-to", "-u"); found {
+		return gitGoSetUpstream(r, cmd.arg(0), upstream)
+	}
+	if _, found := cmd.flag("-r", "--remotes"); found {
+		return gitGoRemoteBranches(r)
+	}
+	if _, found := cmd.flag("-D", "-d", "--delete"); found {
+		return gitGoDeleteBranch(r, cmd.arg(0))
+	}
+	_, verbose := cmd.flag("-vv")
+	if len(cmd.args) == 0 {
+		return gitGoLocalBranches(r, verbose)
+	}
+
+	name := plumbing.NewBranchReferenceName(cmd.arg(0))
+	if _, err := r.Reference(name, false); err == nil {
+		return "", gitGoFatal("A branch named '%s' already exists.", cmd.arg(0))
+	}
+	start := "HEAD"
+	if len(cmd.args) > 1 {
+		start = cmd.arg(1)
+	}
+	hash, err := gitGoResolve(r, start)
+	if err != nil {
+		return "", err
+	}
+	return "", r.Storer.SetReference(plumbing.NewHashReference(name, hash))
+}
+
+// gitGoDeleteBranch remove a local branch and his configuration, like 'git branch -D'.
+func gitGoDeleteBranch(r *gogit.Repository, branch string) (string, error) {
+	if current, _ := gitGoCurrentBranch(r); current == branch {
+		return "", gitGoFatal("Cannot delete branch '%s' checked out", branch)
+	}
+	name := plumbing.NewBranchReferenceName(branch)
+	if _, err := r.Reference(name, false); err != nil {
+		return "", gitGoFatal("branch '%s' not found.", branch)
+	}
+	if err := r.Storer.RemoveReference(name); err != nil {
+		return "", err
+	}
+	if cfg, err := r.Config(); err == nil {
+		if _, found := cfg.Branches[branch]; found {
+			delete(cfg.Branches, branch) // The branch configuration section is removed when the configuration is saved.
+			return "", r.Storer.SetConfig(cfg)
+		}
+	}
+	return "", nil
+}
+
+func gitGoLocalBranches(r *gogit.Repository, verbose bool) (string, error) {
+	current, _ := gitGoCurrentBranch(r)
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	refs, err := r.Branches()
+	if err != nil {
+		return "", err
+	}
+	branches := make(map[string]plumbing.Hash)
+	names := []string{}
+	refs.ForEach(func(ref *plumbing.Reference) error {
+		branches[ref.Name().Short()] = ref.Hash()
+		names = append(names, ref.Name().Short())
+		return nil
+	})
+	sort.Strings(names)
+
+	lines := make([]string, len(names))
+	for index, name := range names {
+		mark := " "
+		if name == current {
+			mark = "*"
+		}
+		lines[index] = mark + " " + name
+		if !verbose {
+			continue
+		}
+		lines[index] += " " + branches[name].String()[:7]
+		if branch, found := cfg.Branches[name]; found && branch.Remote != "" {
+			lines[index] += fmt.Sprintf(" [%s/%s]", branch.Remote, branch.Merge.Short())
+		}
+		if commit, err := r.CommitObject(branches[name]); err == nil {
+			lines[index] += " " + strings.SplitN(commit.Message, "\n", 2)[0]
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func gitGoRemoteBranches(r *gogit.Repository) (string, error) {
+	refs, err := r.References()
+	if err != nil {
+		return "", err
+	}
+	names := []string{}
+	refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsRemote() && ref.Type() == plumbing.HashReference {
+			names = append(names, ref.Name().Short())
+		}
+		return nil
+	})
+	sort.Strings(names)
+	for index, name := range names {
+		names[index] = "  " + name
+	}
+	return strings.Join(names, "\n"), nil
+}
+
+// gitGoSetUpstream connect a local branch to the remote branch (<remote>/<branch>).
+func gitGoSetUpstream(r *gogit.Repository, branch, upstream string) (string, error) {
+	if branch == "" {
+		var err error
+		if branch, err = gitGoCurrentBranch(r); err != nil {
+			return "", err
+		}
+	}
+	if _, err := r.Reference(plumbing.ReferenceName("refs/remotes/"+upstream), true); err != nil {
+		return "", gitGoFatal("the requested upstream branch '%s' does not exist", upstream)
+	}
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	remote := ""
+	for name := range cfg.Remotes {
+		if strings.HasPrefix(upstream, name+"/") && len(name) > len(remote) {
+			remote = name
+		}
+	}
+	if remote == "" {
+		return "", gitGoFatal("unknown remote of '%s'", upstream)
+	}
+	cfg.Branches[branch] = &config.Branch{
+		Name:   branch,
+		Remote: remote,
+		Merge:  plumbing.NewBranchReferenceName(strings.TrimPrefix(upstream, remote+"/")),
+	}
+	if err := r.Storer.SetConfig(cfg); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Branch '%s' set up to track remote branch '%s'.", branch, upstream), nil
+}
+
+func gitGoCheckout(cmd *gitGoCmd) (string, error) {
+	r, w, err := gitGoWorktree()
+	if err != nil {
+		return "", err
+	}
+	_, create := cmd.flag("-b")
+	_, reset := cmd.flag("-B")
+	name := cmd.arg(0)
+	if name == "" && len(cmd.paths) == 0 {
+		return "", gitGoFatal("nothing to checkout")
+	}
+	branch := plumbing.NewBranchReferenceName(name)
+
+	if create || reset {
+		if _, err := r.Reference(branch, false); err == nil && create {
+			return "", gitGoFatal("A branch named '%s' already exists.", name)
+		}
+		if head, err := r.Head(); err == nil {
+			if err := r.Storer.SetReference(plumbing.NewHashReference(branch, head.Hash())); err != nil {
+				return "", err
+			}
+		}
+		// The branch starts on HEAD, so the index and the working tree are kept.
+		return fmt.Sprintf("Switched to a new branch '%s'", name),
+			r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, branch))
+	}
+
+	if len(cmd.paths) == 0 {
+		if ref, err := r.Reference(branch, true); err == nil {
+			return gitGoSwitch(r, w, ref)
+		}
+	}
+
+	files := append(cmd.args, cmd.paths...)
+	for _, file := range files {
+		if err := gitGoRestoreFile(r, w, file); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// gitGoSwitch move HEAD to the branch and update the working tree.
+func gitGoSwitch(r *gogit.Repository, w *gogit.Worktree, branch *plumbing.Reference) (string, error) {
+	options := &gogit.CheckoutOptions{Branch: branch.Name()}
+	if head, err := r.Head(); err == nil && head.Hash() == branch.Hash() {
+		// Same commit. Local changes are kept, like git does.
+		options.Keep = true
+	}
+	if err := w.Checkout(options); err != nil {
+		return "", gitGoFatal("Unable to switch to branch '%s'. %s", branch.Name().Short(), err)
+	}
+	return fmt.Sprintf("Switched to branch '%s'", branch.Name().Short()), nil
+}
+
+// gitGoRestoreFile restore a file of the working tree from the index.
+func gitGoRestoreFile(r *gogit.Repository, w *gogit.Worktree, file string) error {
+	rel, err := gitGoRelPath(w, file)
+	if err != nil {
+		return err
+	}
+	idx, err := r.Storer.Index()
+	if err != nil {
+		return err
+	}
+	entry, err := idx.Entry(rel)
+	if err != nil {
+		return gitGoExit{code: 1, err: fmt.Errorf("pathspec '%s' did not match any file(s) known to git", file)}
+	}
+	return gitGoWriteBlob(r, w, rel, entry.Hash, entry.Mode == filemode.Executable)
+}
+
+func gitGoRevParse(cmd *gitGoCmd) (string, error) {
+	r, err := gitGoOpen()
+	if err != nil {
+		return "", err
+	}
+	if _, toplevel := cmd.flag("--show-toplevel"); toplevel {
+		w, err := r.Worktree()
+		if err != nil {
+			return "", gitGoFatal("this operation must be run in a work tree")
+		}
+		return w.Filesystem.Root(), nil
+	}
+	if _, abbrev := cmd.flag("--abbrev-ref"); abbrev {
+		if _, err := r.Head(); err != nil {
+			return "HEAD", gitGoFatal("ambiguous argument 'HEAD': unknown revision")
+		}
+		branch, err := gitGoCurrentBranch(r)
+		if branch == "" {
+			branch = "HEAD"
+		}
+		return branch, err
+	}
+	lines := make([]string, 0, len(cmd.args))
+	for _, rev := range cmd.args {
+		hash, err := gitGoResolve(r, rev)
+		if err != nil {
+			return rev, err
+		}
+		lines = append(lines, hash.String())
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func gitGoMergeBase(cmd *gitGoCmd) (string, error) {
+	r, err := gitGoOpen()
+	if err != nil {
+		return "", err
+	}
+	if len(cmd.args) != 2 {
+		return "", gitGoUnsupported("git merge-base with %d commits", len(cmd.args))
+	}
+	base, err := gitGoBase(r, cmd.arg(0), cmd.arg(1))
+	if err != nil {
+		return "", err
+	}
+	return base.Hash.String(), nil
+}
+
+// gitGoBase return the best common ancestor of 2 revisions.
+func gitGoBase(r *gogit.Repository, rev1, rev2 string) (*object.Commit, error) {
+	commit1, err := gitGoCommitOf(r, rev1)
+	if err != nil {
+		return nil, err
+	}
+	commit2, err := gitGoCommitOf(r, rev2)
+	if err != nil {
+		return nil, err
+	}
+	bases, err := commit1.MergeBase(commit2)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) == 0 {
+		return nil, gitGoExit{code: 1, err: fmt.Errorf("no common ancestor between '%s' and '%s'", rev1, rev2)}
+	}
+	return bases[0], nil
+}
+
+func gitGoLog(cmd *gitGoCmd) (string, error) {
+	r, err := gitGoOpen()
+	if err != nil {
+		return "", err
+	}
+	format, found := cmd.flag("--format", "--pretty")
+	format = strings.TrimPrefix(strings.TrimPrefix(format, "format:"), "tformat:")
+	if _, oneline := cmd.flag("--oneline"); oneline {
+		format, found = "%h %s", true
+	}
+	if !found {
+		format = "commit %H%nAuthor: %an <%ae>%n%n    %s"
+	}
+	max := -1
+	for flag := range cmd.flags {
+		var count int
+		if _, err := fmt.Sscanf(flag, "-%d", &count); err == nil && count >= 0 {
+			max = count
+		}
+	}
+
+	from, exclude := "HEAD", ""
+	if rev := cmd.arg(0); rev != "" {
+		from = rev
+		if parts := strings.SplitN(rev, "..", 2); len(parts) == 2 {
+			exclude, from = parts[0], parts[1]
+			if from == "" {
+				from = "HEAD"
+			}
+		}
+	}
+	fromHash, err := gitGoResolve(r, from)
+	if err != nil {
+		return "", err
+	}
+
+	excluded := make(map[plumbing.Hash]bool)
+	if exclude != "" {
+		excludeHash, err := gitGoResolve(r, exclude)
+		if err != nil {
+			return "", err
+		}
+		commits, err := r.Log(&gogit.LogOptions{From: excludeHash})
+		if err != nil {
+			return "", err
+		}
+		commits.ForEach(func(c *object.Commit) error {
+			excluded[c.Hash] = true
+			return nil
+		})
+	}
+
+	options := &gogit.LogOptions{From: fromHash, Order: gogit.LogOrderCommitterTime}
+	if len(cmd.paths) > 0 {
+		file := cmd.paths[0]
+		if _, w, err := gitGoWorktree(); err == nil {
+			file, _ = gitGoRelPath(w, file)
+		}
+		options.FileName = &file
+	}
+	commits, err := r.Log(options)
+	if err != nil {
+		return "", err
+	}
+	lines := []string{}
+	commits.ForEach(func(c *object.Commit) error {
+		if max >= 0 && len(lines) >= max {
+			return io.EOF
+		}
+		if excluded[c.Hash] {
+			return nil
+		}
+		if strings.Contains(format, "%G") {
+			lines = append(lines, gitGoFormat(gitGoSignatureFormat(format, gitGoVerifyCommit(r, cmd.config, c)), c))
+		} else {
+			lines = append(lines, gitGoFormat(format, c))
+		}
+		return nil
+	})
+	return strings.Join(lines, "\n"), nil
+}
+
+// gitGoHexFormat is a character given by his hexadecimal code in log formats. ie %x09
+var gitGoHexFormat = regexp.MustCompile(`%x[0-9a-fA-F]{2}`)
+
+// gitGoFormat format a commit like git log --format.
+func gitGoFormat(format string, c *object.Commit) string {
+	message := strings.TrimRight(c.Message, "\n")
+	parts := strings.SplitN(message, "\n\n", 2)
+	subject, body := strings.Replace(parts[0], "\n", " ", -1), ""
+	if len(parts) == 2 {
+		body = parts[1]
+	}
+	format = gitGoHexFormat.ReplaceAllStringFunc(format, func(hex string) string {
+		var char byte
+		fmt.Sscanf(hex, "%%x%02x", &char)
+		return string([]byte{char})
+	})
+	return strings.NewReplacer(
+		"%%", "%",
+		"%H", c.Hash.String(),
+		"%h", c.Hash.String()[:7],
+		"%s", subject,
+		"%b", body,
+		"%B", message,
+		"%an", c.Author.Name,
+		"%ae", c.Author.Email,
+		"%n", "\n",
+	).Replace(format)
+}
+
+// gitGoShow return the content of a file in a revision, like 'git show <rev>:<path>'.
+func gitGoShow(cmd *gitGoCmd) (string, error) {
+	parts := strings.SplitN(cmd.arg(0), ":", 2)
+	if len(parts) != 2 || len(cmd.args) != 1 {
+		return "", gitGoUnsupported("git show without <revision>:<path>")
+	}
+	r, err := gitGoOpen()
+	if err != nil {
+		return "", err
+	}
+	commit, err := gitGoCommitOf(r, parts[0])
+	if err != nil {
+		return "", err
+	}
+	file, err := commit.File(parts[1])
+	if err == object.ErrFileNotFound {
+		return "", gitGoFatal("path '%s' does not exist in '%s'", parts[1], parts[0])
+	} else if err != nil {
+		return "", err
+	}
+	return file.Contents()
+}
+
+func gitGoDiff(cmd *gitGoCmd) (string, error) {
+	if _, nameOnly := cmd.flag("--name-only"); !nameOnly {
+		return "", gitGoUnsupported("git diff without --name-only")
+	}
+	if filter, found := cmd.flag("--diff-filter"); found {
+		if filter == "U" {
+			return strings.Join(gitGoConflicts, "\n"), nil
+		}
+		return "", gitGoUnsupported("git diff --diff-filter=%s", filter)
+	}
+	if len(cmd.args) != 2 {
+		return "", gitGoUnsupported("git diff with %d commits", len(cmd.args))
+	}
+	r, err := gitGoOpen()
+	if err != nil {
+		return "", err
+	}
+	from, err := gitGoCommitOf(r, cmd.arg(0))
+	if err != nil {
+		return "", err
+	}
+	to, err := gitGoCommitOf(r, cmd.arg(1))
+	if err != nil {
+		return "", err
+	}
+	changes, err := gitGoTreeChanges(from, to)
+	if err != nil {
+		return "", err
+	}
+	files := make([]string, 0, len(changes))
+	for file := range changes {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return strings.Join(files, "\n"), nil
+}
+
+func gitGoLsFiles(cmd *gitGoCmd) (string, error) {
+	r, w, err := gitGoWorktree()
+	if err != nil {
+		return "", err
+	}
+	idx, err := r.Storer.Index()
+	if err != nil {
+		return "", err
+	}
+	filter := make(map[string]bool)
+	for _, file := range append(cmd.args, cmd.paths...) {
+		rel, err := gitGoRelPath(w, file)
+		if err != nil {
+			return "", err
+		}
+		filter[rel] = true
+	}
+	files := []string{}
+	for _, entry := range idx.Entries {
+		if len(filter) == 0 || filter[entry.Name] {
+			files = append(files, entry.Name)
+		}
+	}
+	sort.Strings(files)
+	return strings.Join(files, "\n"), nil
+}
+
+func gitGoRemote(cmd *gitGoCmd) (string, error) {
+	r, err := gitGoOpen()
+	if err != nil {
+		return "", err
+	}
+	switch cmd.arg(0) {
+	case "":
+		return gitGoRemoteList(r, cmd)
+	case "add":
+		if len(cmd.args) != 3 {
+			return "", gitGoFatal("usage: git remote add <name> <url>")
+		}
+		if _, err := r.CreateRemote(&config.RemoteConfig{Name: cmd.arg(1), URLs: []string{cmd.arg(2)}}); err != nil {
+			return "", gitGoFatal("%s", err)
+		}
+		return "", nil
+	case "set-url":
+		if len(cmd.args) != 3 {
+			return "", gitGoFatal("usage: git remote set-url <name> <url>")
+		}
+		cfg, err := r.Config()
+		if err != nil {
+			return "", err
+		}
+		remote, found := cfg.Remotes[cmd.arg(1)]
+		if !found {
+			return "", gitGoFatal("No such remote '%s'", cmd.arg(1))
+		}
+		remote.URLs = []string{cmd.arg(2)}
+		return "", r.Storer.SetConfig(cfg)
+	case "rename":
+		if len(cmd.args) != 3 {
+			return "", gitGoFatal("usage: git remote rename <old> <new>")
+		}
+		return "", gitGoRemoteRename(r, cmd.arg(1), cmd.arg(2))
+	case "remove", "rm":
+		if err := r.DeleteRemote(cmd.arg(1)); err != nil {
+			return "", gitGoFatal("%s", err)
+		}
+		return "", nil
+	}
+	return "", gitGoUnsupported("git remote %s", cmd.arg(0))
+}
+
+func gitGoRemoteList(r *gogit.Repository, cmd *gitGoCmd) (string, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(cfg.Remotes))
+	for name := range cfg.Remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if _, verbose := cmd.flag("-v", "--verbose"); !verbose {
+		return strings.Join(names, "\n"), nil
+	}
+	lines := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		url := ""
+		if urls := cfg.Remotes[name].URLs; len(urls) > 0 {
+			url = urls[0]
+		}
+		lines = append(lines, fmt.Sprintf("%s\t%s (fetch)", name, url), fmt.Sprintf("%s\t%s (push)", name, url))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// gitGoRemoteRename rename a remote, its remote branches and the branches tracking it.
+func gitGoRemoteRename(r *gogit.Repository, old, new string) error {
+	cfg, err := r.Config()
+	if err != nil {
+		return err
+	}
+	remote, found := cfg.Remotes[old]
+	if !found {
+		return gitGoFatal("No such remote: '%s'", old)
+	}
+	if _, found := cfg.Remotes[new]; found {
+		return gitGoFatal("remote %s already exists.", new)
+	}
+	delete(cfg.Remotes, old)
+	cfg.Remotes[new] = &config.RemoteConfig{
+		Name:  new,
+		URLs:  remote.URLs,
+		Fetch: []config.RefSpec{config.RefSpec(fmt.Sprintf(config.DefaultFetchRefSpec, new))},
+	}
+	for _, branch := range cfg.Branches {
+		if branch.Remote == old {
+			branch.Remote = new
+		}
+	}
+	if err := r.Storer.SetConfig(cfg); err != nil {
+		return err
+	}
+
+	refs, err := r.References()
+	if err != nil {
+		return err
+	}
+	prefix := "refs/remotes/" + old + "/"
+	renamed := []*plumbing.Reference{}
+	refs.ForEach(func(ref *plumbing.Reference) error {
+		if strings.HasPrefix(ref.Name().String(), prefix) && ref.Type() == plumbing.HashReference {
+			renamed = append(renamed, ref)
+		}
+		return nil
+	})
+	for _, ref := range renamed {
+		name := plumbing.ReferenceName("refs/remotes/" + new + "/" + strings.TrimPrefix(ref.Name().String(), prefix))
+		if err := r.Storer.SetReference(plumbing.NewHashReference(name, ref.Hash())); err != nil {
+			return err
+		}
+		if err := r.Storer.RemoveReference(ref.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gitGoFetch(cmd *gitGoCmd) (string, error) {
+	r, err := gitGoOpen()
+	if err != nil {
+		return "", err
+	}
+	remote := cmd.arg(0)
+	if remote == "" {
+		remote = "origin"
+	}
+	err = r.Fetch(&gogit.FetchOptions{RemoteName: remote})
+	if err != nil && err != gogit.NoErrAlreadyUpToDate {
+		return "", gitGoFatal("Unable to fetch '%s'. %s", remote, err)
+	}
+	return "", nil
+}
+
+func gitGoPush(cmd *gitGoCmd) (string, error) {
+	r, err := gitGoOpen()
+	if err != nil {
+		return "", err
+	}
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	branch, err := gitGoCurrentBranch(r)
+	if err != nil {
+		return "", err
+	}
+	remote, target := cmd.arg(0), ""
+	if len(cmd.args) > 1 {
+		branch, target = cmd.arg(1), cmd.arg(1)
+	}
+	if upstream, found := cfg.Branches[branch]; found && (remote == "" || remote == upstream.Remote) {
+		remote = upstream.Remote
+		if target == "" {
+			target = upstream.Merge.Short()
+		}
+	}
+	if remote == "" || branch == "" {
+		return "", gitGoFatal("The current branch has no upstream branch.")
+	}
+	if target == "" {
+		target = branch
+	}
+
+	refSpec := fmt.Sprintf("refs/heads/%s:refs/heads/%s", branch, target)
+	if _, force := cmd.flag("-f", "--force"); force {
+		refSpec = "+" + refSpec
+	}
+	err = r.Push(&gogit.PushOptions{RemoteName: remote, RefSpecs: []config.RefSpec{config.RefSpec(refSpec)}})
+	if err != nil && err != gogit.NoErrAlreadyUpToDate {
+		return "", gitGoExit{code: 1, err: fmt.Errorf("failed to push some refs to '%s'. %s", remote, err)}
+	}
+
+	if _, upstream := cmd.flag("-u", "--set-upstream"); upstream {
+		// The remote branch reference is updated by the push, like git does.
+		if hash, err := gitGoResolve(r, "refs/heads/"+branch); err == nil {
+			r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName(remote, target), hash))
+		}
+		return gitGoSetUpstream(r, branch, remote+"/"+target)
+	}
+	return "", nil
+}
+
+func gitGoReset(cmd *gitGoCmd) (string, error) {
+	r, w, err := gitGoWorktree()
+	if err != nil {
+		return "", err
+	}
+	mode, modeSet := gogit.MixedReset, false
+	for flag, resetMode := range map[string]gogit.ResetMode{"--hard": gogit.HardReset, "--soft": gogit.SoftReset, "--mixed": gogit.MixedReset} {
+		if _, found := cmd.flag(flag); found {
+			mode, modeSet = resetMode, true
+		}
+	}
+
+	rev, files := "HEAD", append([]string{}, cmd.paths...)
+	if len(cmd.args) > 0 {
+		if _, err := gitGoResolve(r, cmd.arg(0)); err == nil {
+			rev, files = cmd.arg(0), append(files, cmd.args[1:]...)
+		} else {
+			files = append(files, cmd.args...)
+		}
+	}
+
+	if len(files) > 0 {
+		if modeSet {
+			return "", gitGoFatal("Cannot do a reset with a mode and paths.")
+		}
+		return "", gitGoUnstage(r, w, rev, files)
+	}
+
+	hash, err := gitGoResolve(r, rev)
+	if err != nil {
+		return "", err
+	}
+	if err := w.Reset(&gogit.ResetOptions{Commit: hash, Mode: mode}); err != nil {
+		return "", gitGoFatal("Unable to reset to '%s'. %s", rev, err)
+	}
+	if mode == gogit.HardReset {
+		commit, err := r.CommitObject(hash)
+		if err == nil {
+			return fmt.Sprintf("HEAD is now at %s %s", hash.String()[:7], strings.SplitN(commit.Message, "\n", 2)[0]), nil
+		}
+	}
+	return "", nil
+}
+
+// gitGoUnstage reset the index entries of files to the revision given.
+func gitGoUnstage(r *gogit.Repository, w *gogit.Worktree, rev string, files []string) error {
+	var tree *object.Tree
+	if commit, err := gitGoCommitOf(r, rev); err == nil {
+		if tree, err = commit.Tree(); err != nil {
+			return err
+		}
+	}
+	idx, err := r.Storer.Index()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		rel, err := gitGoRelPath(w, file)
+		if err != nil {
+			return err
+		}
+		var treeFile *object.File
+		if tree != nil {
+			treeFile, _ = tree.File(rel)
+		}
+		if treeFile == nil {
+			idx.Remove(rel)
+			continue
+		}
+		entry, err := idx.Entry(rel)
+		if err != nil {
+			entry = idx.Add(rel)
+		}
+		entry.Hash = treeFile.Hash
+		entry.Mode = treeFile.Mode
+	}
+	return r.Storer.SetIndex(idx)
+}
+
+func gitGoConfig(cmd *gitGoCmd) (string, error) {
+	r, err := gitGoOpen()
+	if err != nil {
+		return "", err
+	}
+	keys := strings.Split(cmd.arg(0), ".")
+	if len(keys) < 2 {
+		return "", gitGoExit{code: 2, err: fmt.Errorf("key does not contain a section: %s", cmd.arg(0))}
+	}
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	section := cfg.Raw.Section(keys[0])
+	option := keys[len(keys)-1]
+	subsection := strings.Join(keys[1:len(keys)-1], ".")
+
+	if len(cmd.args) == 1 {
+		value := section.Option(option)
+		if subsection != "" {
+			value = section.Subsection(subsection).Option(option)
+		}
+		if value == "" {
+			return "", gitGoExit{code: 1, err: fmt.Errorf("'%s' not set", cmd.arg(0))}
+		}
+		return value, nil
+	}
+
+	if subsection != "" {
+		section.Subsection(subsection).SetOption(option, cmd.arg(1))
+	} else {
+		section.SetOption(option, cmd.arg(1))
+	}
+	return "", r.Storer.SetConfig(cfg)
+}
@@ -0,0 +1,350 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	gogit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// gitGoConflicts are the files in conflict found by the last merge or rebase.
+//
+// go-git can't store a merge in progress. So, on conflicts or on signature failure, the repository is restored
+// immediately and the conflicts are kept here, until 'merge --abort' or 'rebase --abort'.
+var gitGoConflicts []string
+
+// gitGoMerge merge a revision in the current branch.
+//
+// Files are merged as a whole: a file updated on both sides is a conflict.
+func gitGoMerge(cmd *gitGoCmd) (string, error) {
+	if _, abort := cmd.flag("--abort"); abort {
+		return gitGoAbort("merge")
+	}
+	gitGoConflicts = nil
+	r, w, err := gitGoWorktree()
+	if err != nil {
+		return "", err
+	}
+	head, other, err := gitGoMergeCommits(r, w, cmd.arg(0))
+	if err != nil {
+		return "", err
+	}
+
+	if upToDate, _ := other.IsAncestor(head); upToDate || head.Hash == other.Hash {
+		return "Already up to date.", nil
+	}
+	if fastForward, _ := head.IsAncestor(other); fastForward {
+		return gitGoFastForward(w, head, other)
+	}
+	if _, ffOnly := cmd.flag("--ff-only"); ffOnly {
+		return "", gitGoFatal("Not possible to fast-forward, aborting.")
+	}
+
+	bases, err := head.MergeBase(other)
+	if err != nil || len(bases) == 0 {
+		return "", gitGoUnsupported("git merge of unrelated histories")
+	}
+	ours, err := gitGoTreeChanges(bases[0], head)
+	if err != nil {
+		return "", err
+	}
+	theirs, err := gitGoTreeChanges(bases[0], other)
+	if err != nil {
+		return "", err
+	}
+
+	apply := make(map[string]object.TreeEntry)
+	for file, entry := range theirs {
+		if ourEntry, updated := ours[file]; updated {
+			if ourEntry.Hash != entry.Hash {
+				gitGoConflicts = append(gitGoConflicts, file)
+			}
+			continue
+		}
+		apply[file] = entry
+	}
+	if len(gitGoConflicts) > 0 {
+		sort.Strings(gitGoConflicts)
+		return "", gitGoExit{code: 1, err: fmt.Errorf("Merge conflict in '%s'. Automatic merge failed",
+			strings.Join(gitGoConflicts, "', '"))}
+	}
+
+	if err := gitGoApply(r, w, apply); err != nil {
+		return "", err
+	}
+	hash, err := w.Commit(fmt.Sprintf("Merge branch '%s'", cmd.arg(0)), &gogit.CommitOptions{
+		Author:  gitGoSignature(r),
+		Parents: []plumbing.Hash{head.Hash, other.Hash},
+	})
+	if err != nil {
+		return "", err
+	}
+	if _, sign := cmd.flag("-S", "--gpg-sign"); sign {
+		if _, err = gitGoSignCommit(r, hash, cmd.config); err != nil {
+			// Restore the branch as it was before the merge.
+			w.Reset(&gogit.ResetOptions{Commit: head.Hash, Mode: gogit.HardReset})
+			gitGoConflicts = []string{}
+			return "", gitGoFatal("%s", err)
+		}
+	}
+	return "Merge made by the 'go-git' strategy.", nil
+}
+
+// gitGoRebase replay the current branch commits on top of a revision.
+//
+// Files are replayed as a whole: a file updated on both sides is a conflict.
+func gitGoRebase(cmd *gitGoCmd) (string, error) {
+	if _, abort := cmd.flag("--abort"); abort {
+		return gitGoAbort("rebase")
+	}
+	gitGoConflicts = nil
+	r, w, err := gitGoWorktree()
+	if err != nil {
+		return "", err
+	}
+	head, other, err := gitGoMergeCommits(r, w, cmd.arg(0))
+	if err != nil {
+		return "", err
+	}
+
+	if upToDate, _ := other.IsAncestor(head); upToDate || head.Hash == other.Hash {
+		return "Current branch is up to date.", nil
+	}
+	if fastForward, _ := head.IsAncestor(other); fastForward {
+		return gitGoFastForward(w, head, other)
+	}
+
+	commits, err := gitGoCommitsToReplay(r, head, other)
+	if err != nil {
+		return "", err
+	}
+
+	// Restart from the revision, and replay commits one by one.
+	if err := w.Reset(&gogit.ResetOptions{Commit: other.Hash, Mode: gogit.HardReset}); err != nil {
+		return "", err
+	}
+	config := cmd.config
+	if _, sign := cmd.flag("-S", "--gpg-sign"); !sign {
+		config = nil
+	}
+	for _, commit := range commits {
+		if err := gitGoReplay(r, w, commit, config); err != nil {
+			// Restore the branch as it was before the rebase.
+			w.Reset(&gogit.ResetOptions{Commit: head.Hash, Mode: gogit.HardReset})
+			return "", err
+		}
+	}
+	branch, _ := gitGoCurrentBranch(r)
+	return fmt.Sprintf("Successfully rebased and updated refs/heads/%s.", branch), nil
+}
+
+// gitGoAbort ends a merge or a rebase with conflicts. The repository has already been restored.
+func gitGoAbort(command string) (string, error) {
+	if gitGoConflicts == nil {
+		return "", gitGoFatal("There is no %s to abort.", command)
+	}
+	gitGoConflicts = nil
+	return "", nil
+}
+
+// gitGoMergeCommits return HEAD and revision commits. The working tree must be clean.
+func gitGoMergeCommits(r *gogit.Repository, w *gogit.Worktree, rev string) (head, other *object.Commit, err error) {
+	if rev == "" {
+		return nil, nil, gitGoUnsupported("merge or rebase without revision")
+	}
+	status, err := w.Status()
+	if err != nil {
+		return
+	}
+	for _, file := range status {
+		if (file.Staging != gogit.Unmodified && file.Staging != gogit.Untracked) || file.Worktree == gogit.Modified || file.Worktree == gogit.Deleted {
+			return nil, nil, gitGoFatal("Your local changes would be overwritten. Please commit or stash them.")
+		}
+	}
+	if head, err = gitGoCommitOf(r, "HEAD"); err != nil {
+		return
+	}
+	other, err = gitGoCommitOf(r, rev)
+	return
+}
+
+func gitGoFastForward(w *gogit.Worktree, head, other *object.Commit) (string, error) {
+	if err := w.Reset(&gogit.ResetOptions{Commit: other.Hash, Mode: gogit.MergeReset}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Updating %s..%s\nFast-forward", head.Hash.String()[:7], other.Hash.String()[:7]), nil
+}
+
+// gitGoCommitsToReplay return the commits of head not in other, from the oldest.
+func gitGoCommitsToReplay(r *gogit.Repository, head, other *object.Commit) (commits []*object.Commit, err error) {
+	known := make(map[plumbing.Hash]bool)
+	iter, err := r.Log(&gogit.LogOptions{From: other.Hash})
+	if err != nil {
+		return
+	}
+	iter.ForEach(func(c *object.Commit) error {
+		known[c.Hash] = true
+		return nil
+	})
+
+	iter, err = r.Log(&gogit.LogOptions{From: head.Hash})
+	if err != nil {
+		return
+	}
+	err = iter.ForEach(func(c *object.Commit) error {
+		if known[c.Hash] {
+			return nil
+		}
+		if c.NumParents() != 1 {
+			return gitGoUnsupported("git rebase of merge commits")
+		}
+		commits = append([]*object.Commit{c}, commits...)
+		return nil
+	})
+	return
+}
+
+// gitGoReplay apply the commit changes on HEAD and commit them with the same message and author.
+//
+// The new commit is signed with the signing configuration given, if not nil.
+func gitGoReplay(r *gogit.Repository, w *gogit.Worktree, commit *object.Commit, signConfig map[string]string) error {
+	parent, err := commit.Parent(0)
+	if err != nil {
+		return err
+	}
+	changes, err := gitGoTreeChanges(parent, commit)
+	if err != nil {
+		return err
+	}
+	head, err := gitGoCommitOf(r, "HEAD")
+	if err != nil {
+		return err
+	}
+	headTree, err := head.Tree()
+	if err != nil {
+		return err
+	}
+	parentTree, err := parent.Tree()
+	if err != nil {
+		return err
+	}
+
+	apply := make(map[string]object.TreeEntry)
+	for file, entry := range changes {
+		current := gitGoTreeEntry(headTree, file)
+		if current.Hash == entry.Hash {
+			continue // Already applied.
+		}
+		if current.Hash != gitGoTreeEntry(parentTree, file).Hash {
+			gitGoConflicts = append(gitGoConflicts, file)
+			continue
+		}
+		apply[file] = entry
+	}
+	if len(gitGoConflicts) > 0 {
+		sort.Strings(gitGoConflicts)
+		return gitGoExit{code: 1, err: fmt.Errorf("could not apply %s... %s. Conflicts in '%s'",
+			commit.Hash.String()[:7], strings.SplitN(commit.Message, "\n", 2)[0], strings.Join(gitGoConflicts, "', '"))}
+	}
+	if len(apply) == 0 {
+		return nil // Commit already applied upstream. Dropped, like git does.
+	}
+
+	if err := gitGoApply(r, w, apply); err != nil {
+		return err
+	}
+	author := commit.Author
+	hash, err := w.Commit(commit.Message, &gogit.CommitOptions{Author: &author, Committer: gitGoSignature(r)})
+	if err != nil || signConfig == nil {
+		return err
+	}
+	if _, err = gitGoSignCommit(r, hash, signConfig); err != nil {
+		gitGoConflicts = []string{}
+		return gitGoFatal("%s", err)
+	}
+	return nil
+}
+
+// gitGoTreeChanges return files updated between 2 commits. Removed files have a zero hash.
+func gitGoTreeChanges(from, to *object.Commit) (ret map[string]object.TreeEntry, _ error) {
+	fromTree, err := from.Tree()
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := to.Tree()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, err
+	}
+	ret = make(map[string]object.TreeEntry)
+	for _, change := range changes {
+		if change.To.Name != "" {
+			ret[change.To.Name] = change.To.TreeEntry
+		}
+		if change.From.Name != "" && change.From.Name != change.To.Name {
+			ret[change.From.Name] = object.TreeEntry{}
+		}
+	}
+	return ret, nil
+}
+
+// gitGoTreeEntry return the file entry of the tree. The hash is zero if the file doesn't exist.
+func gitGoTreeEntry(tree *object.Tree, file string) object.TreeEntry {
+	if entry, err := tree.FindEntry(file); err == nil {
+		return *entry
+	}
+	return object.TreeEntry{}
+}
+
+// gitGoApply update the working tree and the index with file entries.
+func gitGoApply(r *gogit.Repository, w *gogit.Worktree, files map[string]object.TreeEntry) error {
+	for file, entry := range files {
+		if entry.Hash.IsZero() {
+			if _, err := w.Remove(file); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := gitGoWriteBlob(r, w, file, entry.Hash, entry.Mode == filemode.Executable); err != nil {
+			return err
+		}
+		if _, err := w.Add(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gitGoWriteBlob write a blob content in the working tree file.
+func gitGoWriteBlob(r *gogit.Repository, w *gogit.Worktree, file string, hash plumbing.Hash, executable bool) error {
+	blob, err := r.BlobObject(hash)
+	if err != nil {
+		return err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	mode := os.FileMode(0644)
+	if executable {
+		mode = 0755
+	}
+	fd, err := w.Filesystem.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	_, err = io.Copy(fd, reader)
+	return err
+}
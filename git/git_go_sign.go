@@ -0,0 +1,114 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+
+	gogit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// gitGoSignCommit sign a commit created on the current branch, with the signing program, like git does.
+//
+// config is the git configuration given on the command line. 'gpg.format', 'user.signingkey', 'gpg.program'
+// and 'gpg.ssh.program' are used.
+// If the signature fails, the branch is restored as it was before the commit. Changes are kept ready.
+func gitGoSignCommit(r *gogit.Repository, hash plumbing.Hash, config map[string]string) (_ plumbing.Hash, err error) {
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		return
+	}
+	head, err := r.Head()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if len(commit.ParentHashes) > 0 {
+			r.Storer.SetReference(plumbing.NewHashReference(head.Name(), commit.ParentHashes[0]))
+		} else {
+			r.Storer.RemoveReference(head.Name())
+		}
+	}()
+
+	data, err := gitGoCommitPayload(commit)
+	if err != nil {
+		return
+	}
+
+	if commit.PGPSignature, err = gitGoSign(data, config); err != nil {
+		return
+	}
+
+	signed := r.Storer.NewEncodedObject()
+	if err = commit.Encode(signed); err != nil {
+		return
+	}
+	if hash, err = r.Storer.SetEncodedObject(signed); err != nil {
+		return
+	}
+	err = r.Storer.SetReference(plumbing.NewHashReference(head.Name(), hash))
+	return hash, err
+}
+
+// gitGoCommitPayload return the commit content signed, ie the commit without his signature.
+func gitGoCommitPayload(commit *object.Commit) ([]byte, error) {
+	payload := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(payload); err != nil {
+		return nil, err
+	}
+	reader, err := payload.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// gitGoSign return the signature of data created by the signing program.
+func gitGoSign(data []byte, config map[string]string) (string, error) {
+	key := config["user.signingkey"]
+	var program string
+	var args []string
+	switch format := config["gpg.format"]; format {
+	case "", "openpgp":
+		program = config["gpg.program"]
+		if program == "" {
+			program = "gpg"
+		}
+		args = []string{"--batch", "-bsa"}
+		if key != "" {
+			args = append(args, "-u", key)
+		}
+	case "ssh":
+		program = config["gpg.ssh.program"]
+		if program == "" {
+			program = "ssh-keygen"
+		}
+		if key == "" || strings.HasPrefix(key, "key::") {
+			return "", gitGoUnsupported("SSH signing without a key file")
+		}
+		args = []string{"-Y", "sign", "-n", "git", "-f", key}
+	default:
+		return "", fmt.Errorf("unsupported value for gpg.format: %s", format)
+	}
+
+	var stdout, stderr bytes.Buffer
+	sign := exec.Command(program, args...)
+	sign.Stdin = bytes.NewReader(data)
+	sign.Stdout = &stdout
+	sign.Stderr = &stderr
+	if err := sign.Run(); err != nil {
+		return "", fmt.Errorf("%s failed to sign the data. %s %s", program, err, strings.TrimSpace(stderr.String()))
+	}
+	if stdout.Len() == 0 {
+		return "", fmt.Errorf("%s failed to sign the data. No signature returned", program)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,191 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	gogit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+)
+
+// gitGoStashRef is the reference of the stash entry.
+//
+// go-git doesn't write reflogs, so only one stash entry is supported.
+const gitGoStashRef = plumbing.ReferenceName("refs/stash")
+
+// gitGoStash save or restore tracked files updated, like 'git stash' and 'git stash pop'.
+func gitGoStash(cmd *gitGoCmd) (string, error) {
+	r, w, err := gitGoWorktree()
+	if err != nil {
+		return "", err
+	}
+	switch cmd.arg(0) {
+	case "", "push":
+		return gitGoStashPush(r, w)
+	case "pop":
+		return gitGoStashPop(r, w)
+	}
+	return "", gitGoUnsupported("git stash %s", cmd.arg(0))
+}
+
+// gitGoStashPush save tracked files updated in a stash commit, as git does, and reset the working tree to HEAD.
+//
+// The stash commit has 2 parents: HEAD and a commit of the index.
+func gitGoStashPush(r *gogit.Repository, w *gogit.Worktree) (_ string, err error) {
+	head, err := gitGoCommitOf(r, "HEAD")
+	if err != nil {
+		return "", gitGoExit{code: 1, err: fmt.Errorf("You do not have the initial commit yet")}
+	}
+	if _, err := r.Reference(gitGoStashRef, false); err == nil {
+		return "", gitGoUnsupported("git stash with an existing stash entry")
+	}
+	status, err := w.Status()
+	if err != nil {
+		return "", err
+	}
+	files := []string{}
+	for file, fileStatus := range status {
+		if fileStatus.Staging != gogit.Untracked &&
+			(fileStatus.Staging != gogit.Unmodified || fileStatus.Worktree != gogit.Unmodified) {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return "No local changes to save", nil
+	}
+	sort.Strings(files)
+
+	// Commits are created on the current branch, which is restored after.
+	branch, err := r.Head()
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			r.Storer.SetReference(plumbing.NewHashReference(branch.Name(), head.Hash))
+		}
+	}()
+
+	name, _ := gitGoCurrentBranch(r)
+	if name == "" {
+		name = "(no branch)"
+	}
+	subject := fmt.Sprintf("%s: %s %s", name, head.Hash.String()[:7], strings.SplitN(head.Message, "\n", 2)[0])
+	signature := gitGoSignature(r)
+
+	index, err := w.Commit("index on "+subject, &gogit.CommitOptions{Author: signature, Parents: []plumbing.Hash{head.Hash}})
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		switch status[file].Worktree {
+		case gogit.Deleted:
+			_, err = w.Remove(file)
+		case gogit.Modified:
+			_, err = w.Add(file)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	stash, err := w.Commit("WIP on "+subject, &gogit.CommitOptions{Author: signature, Parents: []plumbing.Hash{head.Hash, index}})
+	if err != nil {
+		return "", err
+	}
+
+	if err = r.Storer.SetReference(plumbing.NewHashReference(branch.Name(), head.Hash)); err != nil {
+		return "", err
+	}
+	if err = w.Reset(&gogit.ResetOptions{Commit: head.Hash, Mode: gogit.HardReset}); err != nil {
+		return "", err
+	}
+	// Files added are not in HEAD. They are removed, like git does.
+	for _, file := range files {
+		if status[file].Staging == gogit.Added {
+			w.Filesystem.Remove(file)
+		}
+	}
+	if err = r.Storer.SetReference(plumbing.NewHashReference(gitGoStashRef, stash)); err != nil {
+		return "", err
+	}
+	return "Saved working directory and index state WIP on " + subject, nil
+}
+
+// gitGoStashPop restore files saved in the stash commit in the working tree and drop the stash entry.
+//
+// Files are restored as a whole: a file updated in HEAD and in the stash entry is a conflict. On conflicts,
+// nothing is restored and the stash entry is kept.
+// Files added are restored in the index, other files are only restored in the working tree, like git does.
+func gitGoStashPop(r *gogit.Repository, w *gogit.Worktree) (string, error) {
+	ref, err := r.Reference(gitGoStashRef, false)
+	if err != nil {
+		return "", gitGoExit{code: 1, err: fmt.Errorf("No stash entries found.")}
+	}
+	stash, err := r.CommitObject(ref.Hash())
+	if err != nil {
+		return "", err
+	}
+	base, err := stash.Parent(0)
+	if err != nil {
+		return "", err
+	}
+	changes, err := gitGoTreeChanges(base, stash)
+	if err != nil {
+		return "", err
+	}
+	head, err := gitGoCommitOf(r, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	headTree, err := head.Tree()
+	if err != nil {
+		return "", err
+	}
+	baseTree, err := base.Tree()
+	if err != nil {
+		return "", err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return "", err
+	}
+
+	conflicts := []string{}
+	for file, entry := range changes {
+		if fileStatus, found := status[file]; found && fileStatus.Staging != gogit.Untracked &&
+			(fileStatus.Staging != gogit.Unmodified || fileStatus.Worktree != gogit.Unmodified) {
+			return "", gitGoExit{code: 1, err: fmt.Errorf("Your local changes to '%s' would be overwritten. Please commit or stash them.", file)}
+		}
+		if current := gitGoTreeEntry(headTree, file); current.Hash != gitGoTreeEntry(baseTree, file).Hash && current.Hash != entry.Hash {
+			conflicts = append(conflicts, file)
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return "", gitGoExit{code: 1, err: fmt.Errorf("Conflicts in '%s'. The stash entry is kept.", strings.Join(conflicts, "', '"))}
+	}
+
+	for file, entry := range changes {
+		if entry.Hash.IsZero() {
+			if err := w.Filesystem.Remove(file); err != nil && !os.IsNotExist(err) {
+				return "", err
+			}
+			continue
+		}
+		if err := gitGoWriteBlob(r, w, file, entry.Hash, entry.Mode == filemode.Executable); err != nil {
+			return "", err
+		}
+		if gitGoTreeEntry(baseTree, file).Hash.IsZero() {
+			if _, err := w.Add(file); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := r.Storer.RemoveReference(gitGoStashRef); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Dropped refs/stash (%s)", ref.Hash()), nil
+}
@@ -0,0 +1,193 @@
+package git
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	gogit "gopkg.in/src-d/go-git.v4"
+	formatConfig "gopkg.in/src-d/go-git.v4/plumbing/format/config"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// gitGoSignatureCheck is the result of a commit signature verification, given by %G log formats.
+type gitGoSignatureCheck struct {
+	status      string // %G? : G, U, B, X, Y, R, E or N
+	key         string // %GK
+	fingerprint string // %GF
+	signer      string // %GS
+	primaryKey  string // %GP
+}
+
+// gitGoSSHKey extract the key fingerprint from ssh-keygen verification messages.
+var gitGoSSHKey = regexp.MustCompile(`key (SHA256:[^ \n]+)`)
+
+// gitGoSignatureFormat replace %G log formats by the commit signature verification.
+func gitGoSignatureFormat(format string, check gitGoSignatureCheck) string {
+	return strings.NewReplacer(
+		"%%", "%%",
+		"%G?", check.status,
+		"%GK", check.key,
+		"%GF", check.fingerprint,
+		"%GS", check.signer,
+		"%GP", check.primaryKey,
+	).Replace(format)
+}
+
+// gitGoConfigValue return a git configuration value from the command line, the repository or the user
+// configuration, like git does.
+func gitGoConfigValue(r *gogit.Repository, cmdConfig map[string]string, name string) string {
+	if value, found := cmdConfig[strings.ToLower(name)]; found {
+		return value
+	}
+	keys := strings.Split(name, ".")
+	if len(keys) < 2 {
+		return ""
+	}
+	section, subsection, option := keys[0], strings.Join(keys[1:len(keys)-1], "."), keys[len(keys)-1]
+	if cfg, err := r.Config(); err == nil && cfg.Raw != nil {
+		if value := gitGoRawOption(cfg.Raw, section, subsection, option); value != "" {
+			return value
+		}
+	}
+	return gitGoRawOption(gitGoGlobalConfig(), section, subsection, option)
+}
+
+// gitGoRawOption return an option of a git configuration section or subsection.
+func gitGoRawOption(cfg *formatConfig.Config, section, subsection, option string) string {
+	if subsection == "" {
+		return cfg.Section(section).Option(option)
+	}
+	return cfg.Section(section).Subsection(subsection).Option(option)
+}
+
+// gitGoVerifyCommit verify the commit signature with the signing program, like git does.
+//
+// GPG signatures are verified by 'gpg.program' (gpg). SSH signatures are verified by 'gpg.ssh.program'
+// (ssh-keygen) with 'gpg.ssh.allowedSignersFile'. Without this file, SSH signatures are not verified (N).
+func gitGoVerifyCommit(r *gogit.Repository, cmdConfig map[string]string, c *object.Commit) (check gitGoSignatureCheck) {
+	check.status = "N"
+	if c.PGPSignature == "" {
+		return
+	}
+	payload, err := gitGoCommitPayload(c)
+	if err != nil {
+		check.status = "E"
+		return
+	}
+	signature, err := ioutil.TempFile("", "forjj-git-signature")
+	if err != nil {
+		check.status = "E"
+		return
+	}
+	defer os.Remove(signature.Name())
+	_, err = signature.WriteString(c.PGPSignature)
+	signature.Close()
+	if err != nil {
+		check.status = "E"
+		return
+	}
+
+	if strings.HasPrefix(c.PGPSignature, "-----BEGIN SSH SIGNATURE-----") {
+		return gitGoVerifySSH(r, cmdConfig, payload, signature.Name())
+	}
+	return gitGoVerifyGPG(r, cmdConfig, payload, signature.Name())
+}
+
+// gitGoVerifySSH verify a SSH signature. A good signature from a key not found in the allowed signers file is 'U'.
+func gitGoVerifySSH(r *gogit.Repository, cmdConfig map[string]string, payload []byte, signature string) (check gitGoSignatureCheck) {
+	check.status = "N"
+	allowedSigners := gitGoConfigValue(r, cmdConfig, "gpg.ssh.allowedSignersFile")
+	if allowedSigners == "" {
+		return
+	}
+	program := gitGoConfigValue(r, cmdConfig, "gpg.ssh.program")
+	if program == "" {
+		program = "ssh-keygen"
+	}
+
+	check.status = "B"
+	principals, _, err := gitGoRunVerify(nil, program, "-Y", "find-principals", "-f", allowedSigners, "-s", signature)
+	if principal := strings.SplitN(principals, "\n", 2)[0]; err == nil && principal != "" {
+		out, errOut, err := gitGoRunVerify(payload, program, "-Y", "verify", "-n", "git", "-f", allowedSigners, "-I", principal, "-s", signature)
+		if err == nil {
+			check.status, check.signer = "G", principal
+		}
+		check.key = gitGoSSHFingerprint(out + "\n" + errOut)
+	} else {
+		out, errOut, err := gitGoRunVerify(payload, program, "-Y", "check-novalidate", "-n", "git", "-s", signature)
+		if err == nil {
+			check.status = "U"
+		}
+		check.key = gitGoSSHFingerprint(out + "\n" + errOut)
+	}
+	check.fingerprint = check.key
+	return
+}
+
+// gitGoSSHFingerprint return the key fingerprint found in ssh-keygen messages.
+func gitGoSSHFingerprint(out string) string {
+	if match := gitGoSSHKey.FindStringSubmatch(out); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// gitGoVerifyGPG verify a GPG signature from the gpg status messages.
+//
+// A good signature from a key with a trust level lower than marginal is 'U'.
+func gitGoVerifyGPG(r *gogit.Repository, cmdConfig map[string]string, payload []byte, signature string) (check gitGoSignatureCheck) {
+	program := gitGoConfigValue(r, cmdConfig, "gpg.program")
+	if program == "" {
+		program = "gpg"
+	}
+	// gpg fails on bad signatures. The status is given by the messages.
+	out, _, _ := gitGoRunVerify(payload, program, "--status-fd=1", "--keyid-format=long", "--verify", signature, "-")
+
+	check.status = "E"
+	trusted := false
+	results := map[string]string{"GOODSIG": "G", "BADSIG": "B", "EXPSIG": "Y", "EXPKEYSIG": "X", "REVKEYSIG": "R", "ERRSIG": "E"}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(strings.TrimPrefix(line, "[GNUPG:] "))
+		if len(fields) < 2 || !strings.HasPrefix(line, "[GNUPG:] ") {
+			continue
+		}
+		if status, found := results[fields[0]]; found {
+			check.status, check.key = status, fields[1]
+			check.signer = strings.Join(fields[2:], " ")
+			continue
+		}
+		switch fields[0] {
+		case "VALIDSIG":
+			check.fingerprint = fields[1]
+			if len(fields) > 10 {
+				check.primaryKey = fields[10]
+			}
+		case "TRUST_MARGINAL", "TRUST_FULLY", "TRUST_ULTIMATE":
+			trusted = true
+		}
+	}
+	if check.status == "E" {
+		check.signer = ""
+	}
+	if check.status == "G" && !trusted {
+		check.status = "U"
+	}
+	return
+}
+
+// gitGoRunVerify run a verification program with data given on stdin.
+func gitGoRunVerify(stdin []byte, program string, args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	verify := exec.Command(program, args...)
+	if stdin != nil {
+		verify.Stdin = bytes.NewReader(stdin)
+	}
+	verify.Stdout = &stdout
+	verify.Stderr = &stderr
+	err := verify.Run()
+	return strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String()), err
+}
@@ -30,3 +30,79 @@ func TestSetLogFunc(t *testing.T) {
 	assert.Equal(sflogFunc, sflogOutTest, "Expected new function to be registered.")
 
 }
+
+func TestBranchExist(t *testing.T) {
+	assert := assert.New(t)
+
+	saved := defaultCmd
+	defer func() { defaultCmd = saved }()
+	defaultCmd = gitCmdMock{combined: "  feature-x\n* master"}
+
+	/*********************************/
+	testCase := "when the branch is not the current one"
+
+	// ------------ Run function to test
+	found, err := BranchExist("feature-x")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Truef(found, "Expect branch to be found %s", testCase)
+
+	/*********************************/
+	testCase = "when the branch is the current one"
+
+	// ------------ Run function to test
+	found, err = BranchExist("master")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Truef(found, "Expect branch to be found %s", testCase)
+
+	/*********************************/
+	testCase = "when the branch doesn't exist"
+
+	// ------------ Run function to test
+	found, err = BranchExist("feature")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Falsef(found, "Expect branch to not be found %s", testCase)
+}
+
+func TestRemoteBranchExist(t *testing.T) {
+	assert := assert.New(t)
+
+	saved := defaultCmd
+	defer func() { defaultCmd = saved }()
+	defaultCmd = gitCmdMock{combined: "  origin/HEAD -> origin/master\n  origin/feature-x\n  origin/master"}
+
+	/*********************************/
+	testCase := "when the remote branches are listed"
+
+	// ------------ Run function to test
+	branches, err := RemoteBranches()
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf([]string{"origin/feature-x", "origin/master"}, branches, "Expect symbolic references to be ignored %s", testCase)
+
+	/*********************************/
+	testCase = "when the remote branch exists"
+
+	// ------------ Run function to test
+	found, err := RemoteBranchExist("origin/master")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Truef(found, "Expect remote branch to be found %s", testCase)
+
+	/*********************************/
+	testCase = "when the remote branch is a symbolic reference"
+
+	// ------------ Run function to test
+	found, err = RemoteBranchExist("origin/HEAD")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Falsef(found, "Expect remote branch to not be found %s", testCase)
+}
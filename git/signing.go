@@ -0,0 +1,92 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// SigningGPG signs commits with a GPG key.
+	SigningGPG = "gpg"
+	// SigningSSH signs commits with a SSH key.
+	SigningSSH = "ssh"
+)
+
+// signingConfig describes how commits created by forjj are signed.
+type signingConfig struct {
+	format  string // SigningGPG or SigningSSH. Commits are not signed if empty.
+	key     string // GPG key ID or SSH key file. The GPG default key is used if empty.
+	program string // Signing program. 'gpg' or 'ssh-keygen' if empty.
+}
+
+var signing signingConfig
+
+// SetSigning configure the signature of every commit created by forjj.
+//
+// format is SigningGPG, SigningSSH, or empty to not sign commits.
+// A key is required to sign with SSH.
+func SetSigning(format, key, program string) error {
+	switch format {
+	case "":
+		signing = signingConfig{}
+		return nil
+	case SigningGPG:
+	case SigningSSH:
+		if key == "" {
+			return fmt.Errorf("A SSH key file is required to sign commits with SSH")
+		}
+	default:
+		return fmt.Errorf("Unknown commit signing format '%s'. Supported: '%s' and '%s'", format, SigningGPG, SigningSSH)
+	}
+	if program != "" {
+		if _, err := exec.LookPath(program); err != nil {
+			return fmt.Errorf("Commit signing program '%s' not found. %s", program, err)
+		}
+	}
+	signing = signingConfig{format: format, key: key, program: program}
+	return nil
+}
+
+// commitArgs return the git command line to create a commit with options given.
+//
+// If signing is configured, the commit is signed, whatever the git configuration is.
+func commitArgs(opts ...string) []string {
+	return signedArgs("commit", opts...)
+}
+
+// signedArgs return the git command line of a command creating commits (commit, merge or rebase) with
+// options given.
+//
+// If signing is configured, commits created are signed, whatever the git configuration is.
+func signedArgs(command string, opts ...string) (ret []string) {
+	if signing.format == "" {
+		return append([]string{command}, opts...)
+	}
+	programConfig := "gpg.program"
+	format := "openpgp"
+	if signing.format == SigningSSH {
+		programConfig = "gpg.ssh.program"
+		format = "ssh"
+	}
+	ret = []string{"-c", "gpg.format=" + format}
+	if signing.key != "" {
+		ret = append(ret, "-c", "user.signingkey="+signing.key)
+	}
+	if signing.program != "" {
+		ret = append(ret, "-c", programConfig+"="+signing.program)
+	}
+	ret = append(ret, command, "-S")
+	return append(ret, opts...)
+}
+
+// signingError return the error reported when a signed commit fails.
+func signingError() error {
+	key := signing.key
+	if key == "" {
+		key = "default"
+	}
+	return fmt.Errorf("Unable to sign the commit with the %s key '%s'. "+
+		"Please check the workspace settings 'git-signing-format', 'git-signing-key' and 'git-signing-program'",
+		strings.ToUpper(signing.format), key)
+}
@@ -0,0 +1,330 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetSigning(t *testing.T) {
+	assert := assert.New(t)
+
+	defer func() { signing = signingConfig{} }()
+
+	/*********************************/
+	testCase := "when GPG signing is set with a key"
+
+	// ------------ Run function to test
+	err := SetSigning(SigningGPG, "ABCD1234", "")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf(signingConfig{format: SigningGPG, key: "ABCD1234"}, signing, "Expect signing to be configured %s", testCase)
+
+	/*********************************/
+	testCase = "when SSH signing is set without key"
+
+	// ------------ Run function to test
+	err = SetSigning(SigningSSH, "", "")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+	assert.Equalf(SigningGPG, signing.format, "Expect signing to be unchanged %s", testCase)
+
+	/*********************************/
+	testCase = "when the signing program doesn't exist"
+
+	// ------------ Run function to test
+	err = SetSigning(SigningGPG, "", "/not/existing/gpg")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when the signing format is unknown"
+
+	// ------------ Run function to test
+	err = SetSigning("x509", "key", "")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when signing is unset"
+
+	// ------------ Run function to test
+	err = SetSigning("", "", "")
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf(signingConfig{}, signing, "Expect signing to be disabled %s", testCase)
+}
+
+func TestCommitArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	defer func() { signing = signingConfig{} }()
+
+	tests := []struct {
+		testCase string
+		signing  signingConfig
+		expected []string
+	}{
+		{
+			testCase: "when commits are not signed",
+			expected: []string{"commit", "-m", "msg"},
+		},
+		{
+			testCase: "when commits are signed with the GPG default key",
+			signing:  signingConfig{format: SigningGPG},
+			expected: []string{"-c", "gpg.format=openpgp", "commit", "-S", "-m", "msg"},
+		},
+		{
+			testCase: "when commits are signed with a GPG key and program",
+			signing:  signingConfig{format: SigningGPG, key: "ABCD1234", program: "gpg2"},
+			expected: []string{"-c", "gpg.format=openpgp", "-c", "user.signingkey=ABCD1234", "-c", "gpg.program=gpg2",
+				"commit", "-S", "-m", "msg"},
+		},
+		{
+			testCase: "when commits are signed with a SSH key and program",
+			signing:  signingConfig{format: SigningSSH, key: "/home/me/.ssh/id_ed25519", program: "ssh-keygen"},
+			expected: []string{"-c", "gpg.format=ssh", "-c", "user.signingkey=/home/me/.ssh/id_ed25519",
+				"-c", "gpg.ssh.program=ssh-keygen", "commit", "-S", "-m", "msg"},
+		},
+	}
+
+	for _, test := range tests {
+		signing = test.signing
+
+		// ------------ Run function to test
+		args := commitArgs("-m", "msg")
+
+		// ------------ Test result
+		assert.Equalf(test.expected, args, "Expect the commit command line %s", test.testCase)
+	}
+}
+
+func TestSignedCommit(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is required to sign commits with SSH")
+	}
+	defer func() { signing = signingConfig{} }()
+
+	runOnBackends(t, func(t *testing.T) {
+		assert := assert.New(t)
+
+		cur, _ := os.Getwd()
+		defer os.Chdir(cur)
+		repo, _ := ioutil.TempDir("", "forjj-git-signed")
+		defer os.RemoveAll(repo)
+		keys, _ := ioutil.TempDir("", "forjj-git-keys")
+		defer os.RemoveAll(keys)
+		os.Chdir(repo)
+
+		key := path.Join(keys, "id_ed25519")
+		if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "test", "-f", key).CombinedOutput(); err != nil {
+			t.Fatalf("Unable to create a SSH key. %s: %s", err, out)
+		}
+		Do("init", "-q")
+		Do("config", "user.email", "test@forjj.io")
+		Do("config", "user.name", "test")
+		Do("checkout", "-q", "-b", "master")
+		rawCommit := func() string {
+			out, _ := exec.Command("git", "cat-file", "commit", "HEAD").Output()
+			return string(out)
+		}
+
+		/*********************************/
+		testCase := "when commits are signed with a SSH key"
+
+		ioutil.WriteFile(path.Join(repo, "a"), []byte("a\n"), 0644)
+		Add([]string{"a"})
+		SetSigning(SigningSSH, key, "")
+
+		// ------------ Run function to test
+		err := Commit("signed commit", true)
+
+		// ------------ Test result
+		assert.NoErrorf(err, "Expect commit to succeed %s", testCase)
+		assert.Containsf(rawCommit(), "gpgsig -----BEGIN SSH SIGNATURE-----", "Expect the commit to be signed %s", testCase)
+		subject, _ := Get("log", "-1", "--format=%s")
+		assert.Equalf("signed commit", subject, "Expect the commit subject %s", testCase)
+		first, _ := Get("rev-parse", "HEAD")
+
+		/*********************************/
+		testCase = "when the signature fails"
+
+		ioutil.WriteFile(path.Join(repo, "b"), []byte("b\n"), 0644)
+		Add([]string{"b"})
+		SetSigning(SigningSSH, path.Join(keys, "unknown"), "")
+
+		// ------------ Run function to test
+		err = Commit("not signed", true)
+
+		// ------------ Test result
+		assert.Errorf(err, "Expect an error %s", testCase)
+		head, _ := Get("rev-parse", "HEAD")
+		assert.Equalf(first, head, "Expect no commit %s", testCase)
+		status, _ := Get("status", "--porcelain")
+		assert.Equalf("A  b", status, "Expect changes to be kept ready %s", testCase)
+
+		SetSigning(SigningSSH, key, "")
+		Commit("signed commit", true)
+		diverge := func(file string) {
+			Do("checkout", "-q", "upstream")
+			ioutil.WriteFile(path.Join(repo, "upstream-"+file), []byte(file+"\n"), 0644)
+			Add([]string{"upstream-" + file})
+			Commit("upstream "+file, true)
+			Do("checkout", "-q", "master")
+			ioutil.WriteFile(path.Join(repo, file), []byte(file+"\n"), 0644)
+			Add([]string{file})
+			Commit("local "+file, true)
+		}
+		Do("checkout", "-q", "-b", "upstream")
+		Do("checkout", "-q", "master")
+
+		for _, strategy := range []string{SyncRebase, SyncMerge} {
+			/*********************************/
+			testCase = "when branches are reconciled with strategy " + strategy
+			diverge(strategy)
+
+			// ------------ Run function to test
+			_, err = Reconcile("upstream", strategy)
+
+			// ------------ Test result
+			assert.NoErrorf(err, "Expect reconcile to succeed %s", testCase)
+			assert.Containsf(rawCommit(), "gpgsig -----BEGIN SSH SIGNATURE-----", "Expect the new commit to be signed %s", testCase)
+			status, _ := RemoteStatus("upstream")
+			assert.Equalf("+1", status, "Expect master to be ahead of upstream %s", testCase)
+		}
+	})
+}
+
+func TestSignatureLogFormats(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is required to sign commits with SSH")
+	}
+	defer func() { signing = signingConfig{} }()
+
+	runOnBackends(t, func(t *testing.T) {
+		assert := assert.New(t)
+
+		cur, _ := os.Getwd()
+		defer os.Chdir(cur)
+		repo, _ := ioutil.TempDir("", "forjj-git-verify")
+		defer os.RemoveAll(repo)
+		keys, _ := ioutil.TempDir("", "forjj-git-keys")
+		defer os.RemoveAll(keys)
+		os.Chdir(repo)
+
+		key := path.Join(keys, "id_ed25519")
+		if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "test", "-f", key).CombinedOutput(); err != nil {
+			t.Fatalf("Unable to create a SSH key. %s: %s", err, out)
+		}
+		out, _ := exec.Command("ssh-keygen", "-lf", key+".pub").Output()
+		fingerprint := strings.Fields(string(out))[1]
+		publicKey, _ := ioutil.ReadFile(key + ".pub")
+		allowedSigners := path.Join(keys, "allowed_signers")
+		ioutil.WriteFile(allowedSigners, append([]byte("test@forjj.io "), publicKey...), 0644)
+		noSigners := path.Join(keys, "no_signers")
+		ioutil.WriteFile(noSigners, []byte{}, 0644)
+
+		Do("init", "-q")
+		Do("config", "user.email", "test@forjj.io")
+		Do("config", "user.name", "test")
+		Do("checkout", "-q", "-b", "master")
+		signatureOf := func(rev string) string {
+			v, _ := Get("log", rev, "-1", "--format=%G?%x09%GK%x09%GF%x09%GS")
+			return v
+		}
+
+		ioutil.WriteFile(path.Join(repo, "a"), []byte("a\n"), 0644)
+		Add([]string{"a"})
+		SetSigning("", "", "")
+		Commit("not signed", true)
+		ioutil.WriteFile(path.Join(repo, "b"), []byte("b\n"), 0644)
+		Add([]string{"b"})
+		SetSigning(SigningSSH, key, "")
+		Commit("signed commit", true)
+
+		/*********************************/
+		testCase := "when the commit is not signed"
+
+		// ------------ Run function to test
+		signature := signatureOf("HEAD^")
+
+		// ------------ Test result
+		assert.Equalf("N", strings.Split(signature, "\t")[0], "Expect no signature %s", testCase)
+
+		/*********************************/
+		testCase = "when the allowed signers file is not set"
+
+		// ------------ Run function to test
+		signature = signatureOf("HEAD")
+
+		// ------------ Test result
+		assert.Equalf("N", strings.Split(signature, "\t")[0], "Expect the SSH signature to not be verified %s", testCase)
+
+		/*********************************/
+		testCase = "when the SSH key is an allowed signer"
+
+		Do("config", "gpg.ssh.allowedSignersFile", allowedSigners)
+
+		// ------------ Run function to test
+		signature = signatureOf("HEAD")
+
+		// ------------ Test result
+		assert.Equalf("G\t"+fingerprint+"\t"+fingerprint+"\ttest@forjj.io", signature, "Expect a good signature %s", testCase)
+
+		/*********************************/
+		testCase = "when the SSH key is not an allowed signer"
+
+		Do("config", "gpg.ssh.allowedSignersFile", noSigners)
+
+		// ------------ Run function to test
+		signature = signatureOf("HEAD")
+
+		// ------------ Test result
+		assert.Equalf("U\t"+fingerprint+"\t"+fingerprint, strings.TrimRight(signature, "\t"),
+			"Expect a good signature from an unknown key %s", testCase)
+
+		if _, err := exec.LookPath("gpg"); err != nil {
+			return
+		}
+
+		/*********************************/
+		testCase = "when the commit is signed with a GPG key"
+
+		savedHome := os.Getenv("GNUPGHOME")
+		defer os.Setenv("GNUPGHOME", savedHome)
+		os.Setenv("GNUPGHOME", keys)
+		defer exec.Command("gpgconf", "--kill", "gpg-agent").Run()
+		if out, err := exec.Command("gpg", "--batch", "--passphrase", "", "--quick-gen-key", "test <test@forjj.io>",
+			"ed25519", "sign", "never").CombinedOutput(); err != nil {
+			t.Fatalf("Unable to create a GPG key. %s: %s", err, out)
+		}
+		out, _ = exec.Command("gpg", "--with-colons", "--list-keys", "test@forjj.io").Output()
+		gpgFingerprint := ""
+		for _, line := range strings.Split(string(out), "\n") {
+			if fields := strings.Split(line, ":"); fields[0] == "fpr" && gpgFingerprint == "" {
+				gpgFingerprint = fields[9]
+			}
+		}
+		ioutil.WriteFile(path.Join(repo, "c"), []byte("c\n"), 0644)
+		Add([]string{"c"})
+		SetSigning(SigningGPG, "test@forjj.io", "")
+		Commit("gpg signed commit", true)
+
+		// ------------ Run function to test
+		signature = signatureOf("HEAD")
+
+		// ------------ Test result
+		assert.Equalf("G\t"+gpgFingerprint[24:]+"\t"+gpgFingerprint+"\ttest <test@forjj.io>", signature,
+			"Expect a good GPG signature %s", testCase)
+	})
+}
@@ -8,22 +8,36 @@ import (
 type Status struct {
 	Ready    gitFiles
 	NotReady gitFiles
+	Unmerged []string // Files with merge conflicts.
 	files    gitFilesStatus
 	Err      error
 }
 
+// newStatus return an empty status.
+func newStatus() (gs *Status) {
+	gs = new(Status)
+
+	gs.Ready = make(map[string][]string)
+	gs.Ready.init(false)
+	gs.NotReady = make(map[string][]string)
+	gs.NotReady.init(true)
+	gs.files = make(gitFilesStatus)
+	return
+}
+
 // Files return all files updated identified by git status
 func (gs *Status) Files() (files []string) {
 	files = make([]string, gs.CountFiles())
 
 	files = append(files, gs.Ready.Files()...)
 	files = append(files, gs.NotReady.Files()...)
+	files = append(files, gs.Unmerged...)
 	return
 }
 
-// CountFiles returns the number of files updated tracked or not.
+// CountFiles returns the number of files updated tracked or not, including unmerged files.
 func (gs *Status) CountFiles() int {
-	return gs.Ready.CountFiles() + gs.NotReady.CountFiles()
+	return gs.Ready.CountFiles() + gs.NotReady.CountFiles() + len(gs.Unmerged)
 }
 
 // Tracked return Tracked files
@@ -32,12 +46,13 @@ func (gs *Status) Tracked() (files []string) {
 
 	files = append(files, gs.Ready.Tracked()...)
 	files = append(files, gs.NotReady.Tracked()...)
+	files = append(files, gs.Unmerged...)
 	return
 }
 
-// CountTracked returns the number of tracked files updated in ready or not ready area.
+// CountTracked returns the number of tracked files updated in ready, not ready or unmerged area.
 func (gs *Status) CountTracked() int {
-	return gs.Ready.CountTracked() + gs.NotReady.CountTracked()
+	return gs.Ready.CountTracked() + gs.NotReady.CountTracked() + len(gs.Unmerged)
 }
 
 // Untracked return Tracked files
@@ -0,0 +1,88 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseStatus load the output of 'git status --porcelain=v2 -z'.
+//
+// Records are separated by NUL. Paths are never quoted. Supported records are:
+//
+//	1 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <path>                    : changed file
+//	2 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <X><score> <path>NUL<orig> : renamed or copied file
+//	u <XY> <sub> <m1> <m2> <m3> <mW> <h1> <h2> <h3> <path>           : unmerged file
+//	? <path>                                                         : untracked file
+//
+// Headers (#) and ignored files (!) are skipped.
+func (gs *Status) parseStatus(out string) error {
+	records := strings.Split(strings.TrimRight(out, "\x00"), "\x00")
+	for index := 0; index < len(records); index++ {
+		record := records[index]
+		if record == "" {
+			continue
+		}
+
+		var status FileStatus
+		var file string
+		switch record[0] {
+		case '#', '!':
+			continue
+		case '?':
+			if len(record) < 3 {
+				return fmt.Errorf("Invalid git status untracked record '%s'", record)
+			}
+			status.set("??")
+			file = record[2:]
+		case '1':
+			fields := strings.SplitN(record, " ", 9)
+			if len(fields) != 9 || len(fields[1]) != 2 {
+				return fmt.Errorf("Invalid git status changed record '%s'", record)
+			}
+			status.set(fields[1])
+			status.setSubmodule(fields[2])
+			file = fields[8]
+		case '2':
+			fields := strings.SplitN(record, " ", 10)
+			if len(fields) != 10 || len(fields[1]) != 2 || index+1 >= len(records) {
+				return fmt.Errorf("Invalid git status renamed or copied record '%s'", record)
+			}
+			status.set(fields[1])
+			status.setSubmodule(fields[2])
+			file = fields[9]
+			index++
+			status.origPath = records[index]
+		case 'u':
+			fields := strings.SplitN(record, " ", 11)
+			if len(fields) != 11 || len(fields[1]) != 2 {
+				return fmt.Errorf("Invalid git status unmerged record '%s'", record)
+			}
+			status.set(fields[1])
+			status.setSubmodule(fields[2])
+			status.unmerged = true
+			file = fields[10]
+		default:
+			return fmt.Errorf("Unknown git status record '%s'", record)
+		}
+		gs.add(file, status)
+	}
+	return nil
+}
+
+// add register a file status in ready, not ready or unmerged areas.
+//
+// A file updated in the index and in the work tree is in both ready and not ready areas.
+func (gs *Status) add(file string, status FileStatus) {
+	gs.files[file] = status
+
+	if status.unmerged {
+		gs.Unmerged = append(gs.Unmerged, file)
+		return
+	}
+	if v := status.index; v != ' ' && v != '?' {
+		gs.Ready.add(string(v), file)
+	}
+	if v := status.workTree; v != ' ' {
+		gs.NotReady.add(string(v), file)
+	}
+}
@@ -0,0 +1,243 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testHash = "ce013625030ba8dba906f756967f9e9ca394464a"
+
+func TestParseStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	changed := func(xy, sub, file string) string {
+		return "1 " + xy + " " + sub + " 100644 100644 100644 " + testHash + " " + testHash + " " + file + "\x00"
+	}
+
+	tests := []struct {
+		testCase string
+		output   string
+		files    map[string]FileStatus
+		ready    map[string][]string
+		notReady map[string][]string
+		unmerged []string
+		fails    bool
+	}{
+		{
+			testCase: "when a file is updated in the work tree",
+			output:   changed(".M", "N...", "a"),
+			files:    map[string]FileStatus{"a": {index: ' ', workTree: 'M'}},
+			notReady: map[string][]string{"M": {"a"}},
+		},
+		{
+			testCase: "when a file is updated in the index and in the work tree",
+			output:   changed("MM", "N...", "a"),
+			files:    map[string]FileStatus{"a": {index: 'M', workTree: 'M'}},
+			ready:    map[string][]string{"M": {"a"}},
+			notReady: map[string][]string{"M": {"a"}},
+		},
+		{
+			testCase: "when paths have spaces and quotes",
+			output:   changed("A.", "N...", `my "dir"/a file`) + "? new file\x00",
+			files: map[string]FileStatus{
+				`my "dir"/a file`: {index: 'A', workTree: ' '},
+				"new file":        {index: '?', workTree: '?'},
+			},
+			ready:    map[string][]string{"A": {`my "dir"/a file`}},
+			notReady: map[string][]string{"?": {"new file"}},
+		},
+		{
+			testCase: "when files are renamed and copied",
+			output: "2 R. N... 100644 100644 100644 " + testHash + " " + testHash + " R100 new name\x00old name\x00" +
+				"2 C. N... 100644 100644 100644 " + testHash + " " + testHash + " C75 b\x00a\x00",
+			files: map[string]FileStatus{
+				"new name": {index: 'R', workTree: ' ', origPath: "old name"},
+				"b":        {index: 'C', workTree: ' ', origPath: "a"},
+			},
+			ready: map[string][]string{"R": {"new name"}, "C": {"b"}},
+		},
+		{
+			testCase: "when a submodule commit changed",
+			output:   "1 .M SC.U 160000 160000 160000 " + testHash + " " + testHash + " modules/sub\x00",
+			files:    map[string]FileStatus{"modules/sub": {index: ' ', workTree: 'M', submodule: "SC.U"}},
+			notReady: map[string][]string{"M": {"modules/sub"}},
+		},
+		{
+			testCase: "when a file has a merge conflict",
+			output: "u UU N... 100644 100644 100644 100644 " + testHash + " " + testHash + " " + testHash + " conflict\x00" +
+				changed("M.", "N...", "merged"),
+			files: map[string]FileStatus{
+				"conflict": {index: 'U', workTree: 'U', unmerged: true},
+				"merged":   {index: 'M', workTree: ' '},
+			},
+			ready:    map[string][]string{"M": {"merged"}},
+			unmerged: []string{"conflict"},
+		},
+		{
+			testCase: "when headers and ignored files are given",
+			output:   "# branch.oid " + testHash + "\x00# branch.head master\x00! ignored\x00",
+			files:    map[string]FileStatus{},
+		},
+		{
+			testCase: "when a rename has no source path",
+			output:   "2 R. N... 100644 100644 100644 " + testHash + " " + testHash + " R100 new name\x00",
+			fails:    true,
+		},
+		{
+			testCase: "when a record is truncated",
+			output:   "1 .M N... 100644 a\x00",
+			fails:    true,
+		},
+		{
+			testCase: "when the output is porcelain v1",
+			output:   " M a\x00",
+			fails:    true,
+		},
+	}
+
+	for _, test := range tests {
+		status := newStatus()
+
+		// ------------ Run function to test
+		err := status.parseStatus(test.output)
+
+		// ------------ Test result
+		if test.fails {
+			assert.Errorf(err, "Expect an error %s", test.testCase)
+			continue
+		}
+		assert.NoErrorf(err, "Expect no error %s", test.testCase)
+		assert.Equalf(gitFilesStatus(test.files), status.files, "Expect files status %s", test.testCase)
+		for fileStatus, files := range test.ready {
+			assert.Equalf(files, status.Ready[fileStatus], "Expect '%s' files to be ready %s", fileStatus, test.testCase)
+		}
+		for fileStatus, files := range test.notReady {
+			assert.Equalf(files, status.NotReady[fileStatus], "Expect '%s' files to be not ready %s", fileStatus, test.testCase)
+		}
+		assert.Equalf(test.unmerged, status.Unmerged, "Expect unmerged files %s", test.testCase)
+		assert.Equalf(countTestFiles(test.ready)+countTestFiles(test.notReady)+len(test.unmerged), status.CountFiles(),
+			"Expect files to be counted %s", test.testCase)
+	}
+}
+
+// countTestFiles return the number of files expected in a status area.
+func countTestFiles(area map[string][]string) (count int) {
+	for _, files := range area {
+		count += len(files)
+	}
+	return
+}
+
+// statusTestRepo create a repository with committed files and return a function to write files.
+func statusTestRepo(t *testing.T, repo string, files ...string) func(file, content string) {
+	write := func(file, content string) {
+		if err := ioutil.WriteFile(path.Join(repo, file), []byte(content), 0644); err != nil {
+			t.Fatalf("Unable to write '%s'. %s", file, err)
+		}
+	}
+	os.Chdir(repo)
+	Do("init", "-q")
+	Do("config", "user.email", "test@forjj.io")
+	Do("config", "user.name", "test")
+	Do("config", "commit.gpgsign", "false")
+	Do("checkout", "-q", "-b", "master")
+	for _, file := range files {
+		write(file, file+"\n")
+	}
+	Add(files)
+	if err := Commit("first commit", true); err != nil {
+		t.Fatalf("Unable to create the test repository. %s", err)
+	}
+	return write
+}
+
+func TestGetStatusOnBackends(t *testing.T) {
+	runOnBackends(t, func(t *testing.T) {
+		assert := assert.New(t)
+
+		cur, _ := os.Getwd()
+		defer os.Chdir(cur)
+		repo, _ := ioutil.TempDir("", "forjj-git-status")
+		defer os.RemoveAll(repo)
+		write := statusTestRepo(t, repo, "a file", "b")
+
+		/*********************************/
+		testCase := "when files are updated, added and untracked"
+
+		write("a file", "updated\n")
+		Add([]string{"a file"})
+		write("b", "updated\n")
+		write("new file", "new\n")
+
+		// ------------ Run function to test
+		status := GetStatus()
+
+		// ------------ Test result
+		assert.NoErrorf(status.Err, "Expect no error %s", testCase)
+		assert.Equalf([]string{"a file"}, status.Ready["M"], "Expect the file to be ready %s", testCase)
+		assert.Equalf([]string{"b"}, status.NotReady["M"], "Expect the file to be not ready %s", testCase)
+		assert.Equalf([]string{"new file"}, status.NotReady["?"], "Expect the file to be untracked %s", testCase)
+		assert.Equalf(3, status.CountFiles(), "Expect 3 files %s", testCase)
+		file, found, _ := status.GetFile("a file")
+		assert.Truef(found, "Expect the file to be found %s", testCase)
+		assert.Equalf('M', file.Index(), "Expect the file to be updated in the index %s", testCase)
+		assert.Equalf(' ', file.WorkTree(), "Expect the file to be unchanged in the work tree %s", testCase)
+	})
+}
+
+func TestGetStatusRenamedAndUnmerged(t *testing.T) {
+	assert := assert.New(t)
+
+	saved := defaultCmd
+	defer func() { defaultCmd = saved }()
+	defaultCmd = gitCmd{}
+
+	cur, _ := os.Getwd()
+	defer os.Chdir(cur)
+	repo, _ := ioutil.TempDir("", "forjj-git-status")
+	defer os.RemoveAll(repo)
+	write := statusTestRepo(t, repo, "old name", "conflict")
+
+	/*********************************/
+	testCase := "when a file is renamed"
+
+	exec.Command("git", "mv", "old name", "new name").Run()
+
+	// ------------ Run function to test
+	status := GetStatus()
+
+	// ------------ Test result
+	assert.NoErrorf(status.Err, "Expect no error %s", testCase)
+	assert.Equalf([]string{"new name"}, status.Ready["R"], "Expect the file to be renamed %s", testCase)
+	file, _, _ := status.GetFile("new name")
+	assert.Equalf("old name", file.OrigPath(), "Expect the rename source %s", testCase)
+
+	/*********************************/
+	testCase = "when a file has a merge conflict"
+
+	Commit("rename", true)
+	Do("checkout", "-q", "-b", "feature")
+	write("conflict", "feature\n")
+	Add([]string{"conflict"})
+	Commit("feature update", true)
+	Do("checkout", "-q", "master")
+	write("conflict", "master\n")
+	Add([]string{"conflict"})
+	Commit("master update", true)
+	Do("merge", "feature")
+
+	// ------------ Run function to test
+	status = GetStatus()
+
+	// ------------ Test result
+	assert.NoErrorf(status.Err, "Expect no error %s", testCase)
+	assert.Equalf([]string{"conflict"}, status.Unmerged, "Expect the file to be unmerged %s", testCase)
+	file, _, _ = status.GetFile("conflict")
+	assert.Truef(file.IsUnmerged(), "Expect the file to be unmerged %s", testCase)
+	assert.Equalf(1, status.CountFiles(), "Expect 1 file %s", testCase)
+}
@@ -77,7 +77,7 @@ func TestGetStatus(t *testing.T) {
 	assert := assert.New(t)
 
 	cmdMock := gitCmdMock{
-		combined: " M git/git_cmd_test.go\n",
+		combined: "1 .M N... 100644 100644 100644 " + testHash + " " + testHash + " git/git_cmd_test.go\x00",
 	}
 	defaultCmd = &cmdMock
 	var statusFile FileStatus
@@ -91,7 +91,7 @@ func TestGetStatus(t *testing.T) {
 	assert.Len(status.Ready["M"], 0, "Expected no file identified as ready.")
 
 	// --------------------
-	cmdMock.combined = "M  git/git_cmd_test.go"
+	cmdMock.combined = "1 M. N... 100644 100644 100644 " + testHash + " " + testHash + " git/git_cmd_test.go\x00"
 
 	status = GetStatus()
 
@@ -100,7 +100,7 @@ func TestGetStatus(t *testing.T) {
 	assert.Len(status.Ready["M"], 1, "Expected no file identified as ready.")
 
 	// --------------------
-	cmdMock.combined = "?? git/git_cmd_test.go"
+	cmdMock.combined = "? git/git_cmd_test.go\x00"
 
 	status = GetStatus()
 
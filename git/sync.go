@@ -0,0 +1,98 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// SyncRebase rebase local commits on top of the remote branch.
+	SyncRebase = "rebase"
+	// SyncMerge merge the remote branch in the local branch.
+	SyncMerge = "merge"
+	// SyncFail do not reconcile. The caller reports the sync issue.
+	SyncFail = "fail"
+)
+
+// IsSyncStrategy return true if the strategy is supported by Reconcile.
+func IsSyncStrategy(strategy string) bool {
+	switch strategy {
+	case SyncRebase, SyncMerge, SyncFail:
+		return true
+	}
+	return false
+}
+
+// Reconcile update the current branch with the remote branch (<remoteName>/<branchName>) with the strategy given.
+//
+// The remote must have been fetched before. The working tree must be clean.
+// Commits created are signed if signing is configured. See SetSigning.
+// If the current branch is only behind the remote, a fast forward is done, whatever the strategy.
+// On conflicts, the rebase or merge is aborted to leave the repository as it was, and the list of
+// conflicting files is returned with an error.
+func Reconcile(remote, strategy string) (conflicts []string, err error) {
+	if !IsSyncStrategy(strategy) {
+		return nil, fmt.Errorf("Invalid sync strategy '%s'. Supported: '%s', '%s', '%s'", strategy, SyncRebase, SyncMerge, SyncFail)
+	}
+	if strategy == SyncFail {
+		return nil, fmt.Errorf("Automatic synchronization with '%s' disabled", remote)
+	}
+
+	status := GetStatus()
+	if status.Err != nil {
+		return nil, fmt.Errorf("Issue to check git status. %s", status.Err)
+	}
+	if status.CountTracked() > 0 {
+		return nil, fmt.Errorf("Unable to synchronize with '%s'. Local files are updated. Commit or stash them", remote)
+	}
+
+	syncStatus, err := RemoteStatus(remote)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to compare with '%s'. %s", remote, err)
+	}
+	switch syncStatus {
+	case "=", "+1":
+		return
+	case "-1":
+		if Do("merge", "--ff-only", remote) != 0 {
+			return nil, fmt.Errorf("Unable to fast forward to '%s'", remote)
+		}
+		return
+	}
+
+	// Commits created by the rebase or the merge are signed as any forjj commit.
+	if strategy == SyncRebase {
+		if Do(signedArgs("rebase", remote)...) == 0 {
+			return
+		}
+	} else if Do(signedArgs("merge", "--no-edit", remote)...) == 0 {
+		return
+	}
+
+	conflicts = unmergedFiles()
+	if Do(strategy, "--abort") != 0 {
+		return conflicts, fmt.Errorf("Unable to %s with '%s' and to abort it. Fix the repository manually (git %s --continue or --abort)",
+			strategy, remote, strategy)
+	}
+	if len(conflicts) == 0 {
+		if signing.format != "" {
+			return nil, fmt.Errorf("Unable to %s with '%s'. Aborted. %s", strategy, remote, signingError())
+		}
+		return nil, fmt.Errorf("Unable to %s with '%s'. Aborted", strategy, remote)
+	}
+	pull := "git pull --rebase"
+	if strategy == SyncMerge {
+		pull = "git pull --no-rebase"
+	}
+	return conflicts, fmt.Errorf("Unable to %s with '%s'. Conflicts found in '%s'. Aborted. Resolve them manually with '%s'",
+		strategy, remote, strings.Join(conflicts, "', '"), pull)
+}
+
+// unmergedFiles return the list of files in conflict.
+func unmergedFiles() (files []string) {
+	out, err := Get("diff", "--name-only", "--diff-filter=U")
+	if err != nil || out == "" {
+		return
+	}
+	return strings.Split(out, "\n")
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncTestRepo create a git repository where 'upstream' is a branch ahead of master.
+// master and upstream have diverged on file 'local' or 'conflict' depending on the conflict flag.
+func syncTestRepo(t *testing.T, conflict bool) (repo string) {
+	repo, err := ioutil.TempDir("", "forjj-git-sync")
+	if err != nil {
+		t.Fatalf("Unable to create the test repository. %s", err)
+	}
+	commit := func(file, content string) {
+		if err := ioutil.WriteFile(path.Join(repo, file), []byte(content), 0644); err != nil {
+			t.Fatalf("Unable to write '%s'. %s", file, err)
+		}
+		Do("add", file)
+		Do("commit", "-q", "-m", "update "+file)
+	}
+
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Unable to move to the test repository. %s", err)
+	}
+	Do("init", "-q")
+	Do("config", "user.email", "test@forjj.io")
+	Do("config", "user.name", "test")
+	Do("config", "commit.gpgsign", "false")
+	Do("checkout", "-q", "-b", "master")
+	commit("conflict", "base\n")
+	Do("branch", "upstream")
+	if conflict {
+		commit("conflict", "local\n")
+	} else {
+		commit("local", "local\n")
+	}
+	Do("checkout", "-q", "upstream")
+	commit("conflict", "remote\n")
+	Do("checkout", "-q", "master")
+	return
+}
+
+func TestReconcile(t *testing.T) {
+	runOnBackends(t, testReconcile)
+}
+
+func testReconcile(t *testing.T) {
+	assert := assert.New(t)
+
+	cur, _ := os.Getwd()
+	defer os.Chdir(cur)
+
+	for _, strategy := range []string{SyncRebase, SyncMerge} {
+		/*********************************/
+		testCase := "when branches have diverged without conflicts with strategy " + strategy
+
+		repo := syncTestRepo(t, false)
+
+		// ------------ Run function to test
+		conflicts, err := Reconcile("upstream", strategy)
+
+		// ------------ Test result
+		assert.NoErrorf(err, "Expect no error %s", testCase)
+		assert.Emptyf(conflicts, "Expect no conflicts %s", testCase)
+		status, _ := RemoteStatus("upstream")
+		assert.Equalf("+1", status, "Expect master to be ahead of upstream %s", testCase)
+		os.RemoveAll(repo)
+
+		/*********************************/
+		testCase = "when branches have diverged with conflicts with strategy " + strategy
+
+		repo = syncTestRepo(t, true)
+		head, _ := Get("rev-parse", "HEAD")
+
+		// ------------ Run function to test
+		conflicts, err = Reconcile("upstream", strategy)
+
+		// ------------ Test result
+		assert.Errorf(err, "Expect an error %s", testCase)
+		assert.Equalf([]string{"conflict"}, conflicts, "Expect conflicting files to be listed %s", testCase)
+		newHead, _ := Get("rev-parse", "HEAD")
+		assert.Equalf(head, newHead, "Expect HEAD to be restored %s", testCase)
+		branch, _ := Get("rev-parse", "--abbrev-ref", "HEAD")
+		assert.Equalf("master", branch, "Expect to stay on master %s", testCase)
+		status, _ = Get("status", "--porcelain")
+		assert.Equalf("", status, "Expect a clean repository %s", testCase)
+		os.RemoveAll(repo)
+	}
+
+	/*********************************/
+	testCase := "when the strategy is fail"
+
+	// ------------ Run function to test
+	_, err := Reconcile("upstream", SyncFail)
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+}
@@ -37,11 +37,15 @@ But FORJJ is not DevOps if you do not help your teams to do DevOps (DevOps Cultu
 	update_action_help = `Update the infra. Used to create/update/remove projects and infrastructure migration
 (for example from local jenkins to a mesos jenkins solution)
 `
-	update_orga_help        = "organization workspace used to store repositories locally or in docker volume."
-	updateDeployToHelp      = "Deploy environment to update."
-	updateDeployPublishHelp = "Publish deployment generated source code to the deployment repository (commit/push)."
-	maintainDeployToHelp    = "Deploy environment to maintain."
-	flow_help               = "Define the default flow to apply to new repositories."
+	update_orga_help         = "organization workspace used to store repositories locally or in docker volume."
+	updateDeployToHelp       = "Deploy environment to update."
+	updateDeployPublishHelp  = "Publish deployment generated source code to the deployment repository (commit/push)."
+	updateBranchHelp         = "Infra repository feature branch to create or switch to before updating. Plugins generated files are committed in this branch. The infra repository must be clean."
+	updatePublishModeHelp    = "How --deploy-publish publishes the deployment source code. 'push' (default) pushes to master. 'branch' pushes a 'forjj/<run-id>' review branch and records a merge request description in the workspace. This description is a local record to open the merge request: upstream plugins don't read it."
+	maintainDeployToHelp     = "Deploy environment to maintain."
+	maintainSyncStrategyHelp = "How to reconcile the infra repository when the remote branch is ahead or has diverged. 'rebase' or 'merge' do it automatically before loading the Forjfile and stop only on conflicts. A diverged branch is pushed only once the Forjfile is validated. 'fail' (default) stops."
+	maintainDeployResetHelp  = "Reset the deployment repository 'master' branch to its upstream branch, so only merged review branches are applied. Local commits not pushed are lost."
+	flow_help                = "Define the default flow to apply to new repositories."
 
 	add_action_help    = "Add a component to your Software factory."
 	remove_action_help = "Remove a component from your Software factory."
@@ -67,4 +71,5 @@ But FORJJ is not DevOps if you do not help your teams to do DevOps (DevOps Cultu
 	app_list_help   = "List of application separated by comma. Syntax : category:driver[:instance]"
 
 	val_act_help = "Verify your Forjfile definition."
+	val_fix_help = "Move plugins secure flags values found in the Forjfile to encrypted secrets."
 )
@@ -6,6 +6,8 @@ import (
 	"forjj/creds"
 	"forjj/git"
 	"os"
+	"path"
+	"strings"
 
 	"github.com/forj-oss/forjj-modules/trace"
 )
@@ -28,11 +30,19 @@ func (a *Forj) Maintain() error {
 		return fmt.Errorf("Invalid workspace. %s. Please create it with 'forjj create'", err)
 	}
 
+	if strategy, _, _, _ := a.cli.GetStringValue("_app", "forjj", "sync-strategy"); strategy != "" && !git.IsSyncStrategy(strategy) {
+		return fmt.Errorf("Invalid --sync-strategy '%s'. Supported: '%s', '%s' or '%s'", strategy, git.SyncRebase, git.SyncMerge, git.SyncFail)
+	}
+
 	// Validate from source
 	if err := a.ValidateForjfile(); err != nil {
 		return fmt.Errorf("Your Forjfile is having issues. %s Maintain aborted", err)
 	}
 
+	if err := a.pushInfraSync(); err != nil {
+		return fmt.Errorf("Unable to push the synchronized infra repository. %s", err)
+	}
+
 	if err := a.f.BuildForjfileInMem(); err != nil {
 		return err
 	}
@@ -72,12 +82,58 @@ func (a *Forj) Maintain() error {
 
 	// Now, we are in the infra repo root directory and at least, the 1st commit exist.
 
+	// Review branches may have been removed already, or never fetched on a fresh checkout.
+	// So, the deployment repository is always checked, and --deploy-reset always applied.
+	if a.d != nil {
+		if err := a.maintainReviewBranches(); err != nil {
+			return err
+		}
+	}
+
 	// Load drivers from forjj-options.yml
 	// loop from options/Repos and keep them in a.drivers
 
 	return a.do_maintain()
 }
 
+// maintainReviewBranches remove deployment review branches merged in the upstream branch.
+//
+// Deploy code published in review branches should be applied only when merged. With --deploy-reset, the local
+// master branch is reset to the upstream branch. Otherwise, the local master branch is applied as is.
+// Without upstream branch, review branches can't be published, and --deploy-reset fails.
+func (a *Forj) maintainReviewBranches() error {
+	deployReset := false
+	if v := a.cli.GetAction(maint_act).GetBoolAddr(deployReset_f); v != nil {
+		deployReset = *v
+	}
+
+	remote, branch, err := a.d.GitUpstream()
+	if err != nil {
+		if deployReset {
+			return fmt.Errorf("Unable to apply '%s' code merged upstream with --%s. %s", a.d.Name(), deployReset_f, err)
+		}
+		gotrace.Trace("'%s' review branches not checked. %s", a.d.Name(), err)
+		return nil
+	}
+	removed, err := a.d.GitCleanReviewBranches(remote, branch)
+	if err != nil {
+		return fmt.Errorf("Unable to clean '%s' review branches. %s", a.d.Name(), err)
+	}
+	if len(removed) > 0 {
+		gotrace.Info("Merged review branches removed: '%s'.", strings.Join(removed, "', '"))
+	}
+
+	if deployReset {
+		gotrace.Info("Applying '%s' code merged in '%s/%s' only.", a.d.Name(), remote, branch)
+		return a.d.GitResetFromUpstream(remote, branch)
+	}
+	if a.d.HasReviewBranches() {
+		gotrace.Warning("'%s' review branches are not merged yet. The local 'master' branch is applied. "+
+			"Use --%s to apply the code merged in '%s/%s' only.", a.d.Name(), deployReset_f, remote, branch)
+	}
+	return nil
+}
+
 func (a *Forj) do_maintain() error {
 	// Loop on instances to maintain them
 	instances := a.define_drivers_execution_order()
@@ -127,13 +183,15 @@ func (a *Forj) doInstanceMaintain(instance string) error {
 					return err
 				}
 				switch status {
-				case "-1":
-					return fmt.Errorf("Warning! Remote branch is most recent than your local branch. " +
-						"Do a git pull and restart 'forjj maintain'")
+				case "-1", "-1+1":
+					if err := a.syncInfraBranch(remote, status); err != nil {
+						return err
+					}
+					// The Forjfile loaded and maintained is not the one pulled.
+					return fmt.Errorf("The infra repository has been synchronized with '%s' after loading the Forjfile. "+
+						"Restart 'forjj maintain' to apply it", remote)
 				case "+1":
 					git.Push()
-				case "-1+1":
-					return fmt.Errorf("Local and remote branch has diverged. You must fix it before going on")
 				}
 			}
 		}
@@ -151,7 +209,11 @@ func (a *Forj) doInstanceMaintain(instance string) error {
 				if _, err := git.Get("log", "-1", "--pretty=%H"); err != nil {
 					a.createInitialCommit()
 					git.Add([]string{"README.md"})
-					if err := git.Commit("Initial Source Deploy commit.", true); err != nil {
+					commitMsg, err := a.commitMessage("maintain", "Initial Source Deploy commit.")
+					if err != nil {
+						return err
+					}
+					if err := git.Commit(commitMsg, true); err != nil {
 						return err
 					}
 				}
@@ -167,6 +229,83 @@ func (a *Forj) doInstanceMaintain(instance string) error {
 	return nil
 }
 
+// syncInfraRepo reconcile the infra repository branch with its upstream branch, depending on --sync-strategy.
+//
+// It is called before loading the Forjfile, so the Forjfile pulled is the one validated and maintained.
+// A diverged branch reconciled is pushed by Maintain, only after the Forjfile has been validated.
+// Nothing is done with the 'fail' strategy or if the branch has no upstream branch yet. The sync status is
+// then checked when the infra upstream instance is maintained.
+func (a *Forj) syncInfraRepo() error {
+	strategy, _, _, _ := a.cli.GetStringValue("_app", "forjj", "sync-strategy")
+	if strategy == "" || strategy == git.SyncFail || !git.IsSyncStrategy(strategy) {
+		return nil
+	}
+	infraPath := a.f.InfraPath()
+	if _, err := os.Stat(path.Join(infraPath, ".git")); err != nil {
+		return nil
+	}
+
+	return git.RunInPath(infraPath, func() error {
+		branch := git.GetCurrentBranch()
+		remote, _ := git.Get("config", "branch."+branch+".remote")
+		merge, _ := git.Get("config", "branch."+branch+".merge")
+		if remote == "" || merge == "" {
+			return nil
+		}
+		if git.Do("fetch", remote) != 0 {
+			return fmt.Errorf("Unable to fetch '%s'", remote)
+		}
+		upstream := remote + "/" + strings.TrimPrefix(merge, "refs/heads/")
+		status, err := git.RemoteStatus(upstream)
+		if err != nil {
+			return fmt.Errorf("Unable to compare with '%s'. %s", upstream, err)
+		}
+		if status != "-1" && status != "-1+1" {
+			return nil
+		}
+		if err := a.syncInfraBranch(upstream, status); err != nil {
+			return err
+		}
+		if status == "-1+1" {
+			a.infraSynced = upstream
+		}
+		return nil
+	})
+}
+
+// pushInfraSync push the infra repository branch reconciled by syncInfraRepo, once the Forjfile is validated.
+func (a *Forj) pushInfraSync() error {
+	if a.infraSynced == "" {
+		return nil
+	}
+	return git.RunInPath(a.f.InfraPath(), func() error {
+		gotrace.Info("Pushing the infra repository synchronized with '%s'.", a.infraSynced)
+		return git.Push()
+	})
+}
+
+// syncInfraBranch reconcile the infra repository branch with the remote branch, depending on --sync-strategy.
+//
+// By default (fail), forjj stops and ask to fix it.
+// The result is not pushed. It is pushed only after the Forjfile has been validated.
+// Must be in the infra repo dir.
+func (a *Forj) syncInfraBranch(remote, status string) error {
+	strategy, _, _, _ := a.cli.GetStringValue("_app", "forjj", "sync-strategy")
+	if strategy == "" || strategy == git.SyncFail {
+		if status == "-1" {
+			return fmt.Errorf("Warning! Remote branch is most recent than your local branch. " +
+				"Do a git pull and restart 'forjj maintain' or use --sync-strategy")
+		}
+		return fmt.Errorf("Local and remote branch has diverged. You must fix it before going on or use --sync-strategy")
+	}
+
+	gotrace.Info("Infra repository is not in sync with '%s'. Applying '%s' sync strategy.", remote, strategy)
+	if _, err := git.Reconcile(remote, strategy); err != nil {
+		return fmt.Errorf("Unable to synchronize the infra repository. %s", err)
+	}
+	return nil
+}
+
 // Must be in the current repo dir
 func (a *Forj) createInitialCommit() error {
 	fd, err := os.Create("README.md")
@@ -0,0 +1,169 @@
+package promote
+
+import (
+	"fmt"
+	"forjj/forjfile"
+	"forjj/git"
+	"strings"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/forj-oss/forjj-modules/cli/clier"
+	"github.com/forj-oss/forjj-modules/trace"
+)
+
+// Promote represents the `forjj promote` cli command.
+//
+// It compares the merged Forjfile of 2 deployments and applies selected differences
+// to the target deployment Forjfile, in a new commit of the infra repository.
+type Promote struct {
+	cmd     *kingpin.CmdClause
+	context Context
+
+	from     *string
+	to       *string
+	selected *[]string
+	all      *bool
+	message  *string
+	dryRun   *bool
+
+	forjfile *forjfile.Forge
+}
+
+// Init configure the promote cli command.
+func (p *Promote) Init(app *kingpin.Application, forjfile *forjfile.Forge, isParsePhase func() bool, initCommon func(context *Context, cmd *kingpin.CmdClause)) {
+	if p == nil || app == nil {
+		return
+	}
+
+	p.cmd = app.Command("promote", "Promote Forjfile differences from a deployment to another one.")
+	p.context.init(isParsePhase)
+	p.from = p.cmd.Arg("from", "Deployment to promote.").Required().String()
+	p.to = p.cmd.Arg("to", "Deployment to update.").Required().String()
+	p.selected = p.cmd.Flag("select", "Difference to promote, as yaml path. ie 'repositories/myrepo' or 'repositories/myrepo/title'. Can be repeated.").Strings()
+	p.all = p.cmd.Flag("all", "Promote all differences.").Bool()
+	p.message = p.cmd.Flag("message", "Commit message. By default, the message lists the differences promoted.").Short('m').String()
+	p.dryRun = p.cmd.Flag("dry-run", "Display the deployment Forjfile updated, without writing nor committing it.").Bool()
+	initCommon(&p.context, p.cmd)
+
+	p.forjfile = forjfile
+}
+
+// Action executed for promote cli command
+func (p *Promote) Action(string) {
+	diffs, err := p.forjfile.DiffDeployments(*p.from, *p.to)
+	if err != nil {
+		gotrace.Error("Unable to compare deployments. %s", err)
+		return
+	}
+	if len(diffs) == 0 {
+		gotrace.Info("No differences found between '%s' and '%s'.", *p.from, *p.to)
+		return
+	}
+
+	selected := diffs
+	if !*p.all {
+		selected = diffs.Select(*p.selected...)
+	}
+
+	fmt.Printf("Differences between '%s' and '%s':\n", *p.from, *p.to)
+	for _, diff := range diffs {
+		mark := " "
+		if diff.Match(keys(selected)...) {
+			mark = "*"
+		}
+		fmt.Printf("%s %s\n", mark, diff)
+	}
+	fmt.Println()
+
+	if !*p.all && len(*p.selected) == 0 {
+		fmt.Println("Use --select <path> or --all to promote differences.")
+		return
+	}
+	if len(selected) == 0 {
+		gotrace.Error("No differences match the selection '%s'.", strings.Join(*p.selected, "', '"))
+		return
+	}
+
+	if *p.dryRun {
+		file, yamlData, err := p.forjfile.PromoteDryRun(*p.from, *p.to, selected)
+		if err != nil {
+			gotrace.Error("Unable to promote '%s' to '%s'. %s", *p.from, *p.to, err)
+			return
+		}
+		fmt.Printf("%s would be updated to:\n%s", file, yamlData)
+		return
+	}
+
+	if err := p.promote(selected); err != nil {
+		gotrace.Error("Unable to promote '%s' to '%s'. %s", *p.from, *p.to, err)
+		return
+	}
+	gotrace.Info("%d difference(s) promoted from '%s' to '%s'. Review the commit and push it.", len(selected), *p.from, *p.to)
+}
+
+// promote update the target deployment Forjfile and commit it in the infra repository.
+func (p *Promote) promote(diffs forjfile.DeployDiffs) error {
+	return git.RunInPath(p.forjfile.InfraPath(), func() error {
+		status := git.GetStatus()
+		if status.Err != nil {
+			return status.Err
+		}
+		if status.Ready.CountTracked() > 0 {
+			return fmt.Errorf("The infra repository has some files ready to be committed. Commit or reset them before promoting")
+		}
+
+		file, err := p.forjfile.Promote(*p.from, *p.to, diffs)
+		if err != nil {
+			return err
+		}
+
+		commitMsg, err := p.commitMessage(diffs)
+		if err != nil {
+			return err
+		}
+		if git.Add([]string{file}) > 0 {
+			return fmt.Errorf("Unable to add '%s'", file)
+		}
+		return git.Commit(commitMsg, true)
+	})
+}
+
+// commitMessage return the promote commit message, from the forj-settings/commit-message template.
+//
+// The message proposed is given by --message or lists the differences promoted. Run trailers are added.
+func (p *Promote) commitMessage(diffs forjfile.DeployDiffs) (string, error) {
+	message := *p.message
+	if message == "" {
+		message = fmt.Sprintf("Promote '%s' to '%s'.\n\n", *p.from, *p.to)
+		for _, diff := range diffs {
+			message += "- " + diff.Key() + "\n"
+		}
+	}
+	organization, _, _ := p.forjfile.GetString("settings", "", "organization")
+	return p.forjfile.CommitMessage(&forjfile.CommitMessageData{
+		Action:       "promote",
+		Message:      message,
+		Organization: organization,
+		Deployment:   *p.to,
+		RunID:        forjfile.NewRunID(),
+	})
+}
+
+// DefineContext define cli Context to permit ParseContext to retrieve
+// common variable set.
+func (p *Promote) DefineContext(context clier.ParseContexter) {
+	p.context.defineContext(context)
+}
+
+// GetStringValue Return a field value from the given context (parse time, or after)
+func (p *Promote) GetStringValue(field string) (value string, found, isDefault bool, _ error) {
+	return p.context.GetStringValue(field)
+}
+
+func keys(diffs forjfile.DeployDiffs) (ret []string) {
+	ret = make([]string, len(diffs))
+	for index, diff := range diffs {
+		ret[index] = diff.Key()
+	}
+	return
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"forjj/forjfile"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/forj-oss/forjj-modules/trace"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	publishModePush   = "push"   // Deployment source code is pushed to the deployment repository master branch.
+	publishModeBranch = "branch" // Deployment source code is pushed to a review branch.
+
+	mergeRequestsDir = "merge-requests" // Workspace directory storing merge request descriptions.
+)
+
+// mergeRequest describes a deployment review branch to merge.
+type mergeRequest struct {
+	Deployment   string
+	SourceBranch string `yaml:"source-branch"`
+	TargetBranch string `yaml:"target-branch"`
+	Title        string
+	Description  string
+	Commits      []string `yaml:",omitempty"`
+}
+
+// RunID return the identifier of the current forjj run.
+//
+// It is computed once, from the UTC start time of the run.
+func (a *Forj) RunID() string {
+	if a.runID == "" {
+		a.runID = forjfile.NewRunID()
+	}
+	return a.runID
+}
+
+// commitMessage return the message of commits done by the action, from the forj-settings/commit-message template.
+//
+// message is the commit message proposed by forjj. Run trailers are added.
+func (a *Forj) commitMessage(action, message string) (string, error) {
+	a.commitData.Action = action
+	a.commitData.Message = message
+	a.commitData.Organization = a.w.GetString("organization")
+	a.commitData.RunID = a.RunID()
+	if a.d != nil {
+		a.commitData.Deployment = a.d.Name()
+	}
+	return a.f.CommitMessage(&a.commitData)
+}
+
+// publishDeploy commit the deployment source code and publish it, depending on --publish-mode.
+//
+//   - push: commit and push to the deployment repository master branch.
+//   - branch: commit in a 'forjj/<run-id>' review branch, push it and record a merge request description
+//     in the workspace. 'forjj maintain --deploy-reset' applies the code only when the branch is merged.
+func (a *Forj) publishDeploy(commitMsg string) error {
+	mode, _, _, _ := a.cli.GetStringValue("_app", "forjj", "publish-mode")
+	switch mode {
+	case "", publishModePush:
+		if err := a.d.GitCommit(commitMsg); err != nil {
+			return fmt.Errorf("Failed to commit deploy files. %s", err)
+		}
+
+		if err := a.d.GitPush(false); err != nil {
+			return fmt.Errorf("Failed to push deploy commits. %s", err)
+		}
+	case publishModeBranch:
+		branch := forjfile.ReviewBranchPrefix + a.RunID()
+		commits, err := a.d.GitPublishBranch(branch, commitMsg)
+		if err != nil {
+			return fmt.Errorf("Failed to publish deploy review branch. %s", err)
+		}
+		if len(commits) == 0 {
+			gotrace.Info("No deploy changes to publish.")
+			return nil
+		}
+
+		file, err := a.saveMergeRequest(mergeRequest{
+			Deployment:   a.d.Name(),
+			SourceBranch: branch,
+			TargetBranch: "master",
+			Title:        fmt.Sprintf("Deployment '%s': %s", a.d.Name(), strings.SplitN(commitMsg, "\n", 2)[0]),
+			Description: fmt.Sprintf("Generated by 'forjj update' (run %s) from the infra repository.\n"+
+				"Once merged, 'forjj maintain %s --deploy-reset' will apply it.", a.RunID(), a.d.Name()),
+			Commits: commits,
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to record the merge request of '%s'. %s", branch, err)
+		}
+		gotrace.Info("Deploy changes pushed to the review branch '%s'. Open the merge request described in '%s'.", branch, file)
+	default:
+		return fmt.Errorf("Invalid --publish-mode '%s'. Supported modes are '%s' and '%s'", mode, publishModePush, publishModeBranch)
+	}
+	return nil
+}
+
+// saveMergeRequest write the merge request description in the workspace and return the file path.
+//
+// The description is a local record for the user who opens the merge request. Upstream plugins don't read it.
+func (a *Forj) saveMergeRequest(request mergeRequest) (file string, err error) {
+	dir := path.Join(a.w.Path(), mergeRequestsDir)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+
+	data, err := yaml.Marshal(request)
+	if err != nil {
+		return
+	}
+	file = path.Join(dir, request.Deployment+"-"+a.RunID()+".yaml")
+	err = ioutil.WriteFile(file, data, 0644)
+	return
+}
@@ -13,7 +13,8 @@ type GitRepoStruct struct {
 }
 
 // Create the infra repository. Used at forjj create time.
-func (i *GitRepoStruct) Create(repo_path string, initial_commit func() ([]string, error), force_create bool) error {
+// The initial commit is created with the commit message given.
+func (i *GitRepoStruct) Create(repo_path string, initial_commit func() ([]string, error), commitMsg string, force_create bool) error {
 	i.path = path.Clean(repo_path)
 
 	if creatable := i.isCreatable(); !creatable {
@@ -32,7 +33,7 @@ func (i *GitRepoStruct) Create(repo_path string, initial_commit func() ([]string
 	}
 
 	if !i.git1stCommitExist("master") {
-		return i.git1stCommit(initial_commit)
+		return i.git1stCommit(initial_commit, commitMsg)
 	}
 	return nil
 }
@@ -10,7 +10,6 @@ import (
 	"regexp"
 	//"strings"
 	"forjj/git"
-	"forjj/utils"
 	"strings"
 )
 
@@ -156,7 +155,7 @@ func (i *GitRepoStruct) git1stCommitExist(branch string) bool {
 }
 
 // Create initial commit
-func (i *GitRepoStruct) git1stCommit(initial_commit func() ([]string, error)) (err error) {
+func (i *GitRepoStruct) git1stCommit(initial_commit func() ([]string, error), commitMsg string) (err error) {
 	var files []string
 
 	if files, err = initial_commit(); err != nil {
@@ -164,7 +163,9 @@ func (i *GitRepoStruct) git1stCommit(initial_commit func() ([]string, error)) (e
 	} else {
 		git.Add(files)
 	}
-	git.Do("commit", "-m", "Initial commit")
+	if err = git.Commit(commitMsg, true); err != nil {
+		return
+	}
 
 	gotrace.Trace("Initial commit created.")
 	return nil
@@ -204,7 +205,7 @@ func (i *GitRepoStruct) isCreatable() (creatable bool) {
 		return
 	}
 
-	if utils.RunCmd("git", "rev-parse", "--show-toplevel") > 0 {
+	if git.Do("rev-parse", "--show-toplevel") > 0 {
 		i.err = fmt.Errorf("%s is not a valid git repository work tree.", i.path)
 		return
 	}
@@ -248,7 +249,7 @@ func (i *GitRepoStruct) isValid() (valid bool) {
 		return
 	}
 
-	if utils.RunCmd("git", "rev-parse", "--show-toplevel") > 0 {
+	if git.Do("rev-parse", "--show-toplevel") > 0 {
 		i.err = fmt.Errorf("%s is not a valid git repository work tree.", i.path)
 		return
 	}
@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"forjj/creds"
+	"os"
+	"sort"
+	"time"
+)
+
+// Audit issue severities.
+const (
+	auditError   = "error"
+	auditWarning = "warning"
+)
+
+// auditIssue is a secret issue found by 'forjj secrets audit'
+type auditIssue struct {
+	keyPath  string
+	env      string
+	severity string
+	issue    string
+	detail   string
+}
+
+// auditSecrets check secrets stored in the environments given.
+//
+// declared is the list of secrets declared by loaded plugins. warnBefore is the delay before the expiry date to
+// report a secret expiring soon.
+func auditSecrets(secrets *creds.Secure, envs []string, declared map[string]sInfo, now time.Time, warnBefore time.Duration) (issues []auditIssue) {
+	for _, env := range envs {
+		for keyPath, value := range secrets.EnvValues(env) {
+			add := func(severity, issue, detail string) {
+				issues = append(issues, auditIssue{keyPath: keyPath, env: env, severity: severity, issue: issue, detail: detail})
+			}
+
+			if _, found := declared[keyPath]; !found {
+				add(auditWarning, "undeclared", "Not declared by any loaded plugin")
+			}
+
+			if value.GetSource() == link {
+				file, _ := value.GetResource("linked-to")
+				if _, err := os.Stat(file); err != nil {
+					add(auditError, "missing file", "Linked file '"+file+"' not found")
+				}
+			}
+
+			meta, err := value.Metadata()
+			if err != nil {
+				add(auditWarning, "invalid metadata", err.Error())
+				continue
+			}
+			if !meta.ExpiresOn.IsZero() {
+				if now.After(meta.ExpiresOn) {
+					add(auditError, "expired", "Expired on "+meta.ExpiresOn.Format(time.RFC3339))
+				} else if now.Add(warnBefore).After(meta.ExpiresOn) {
+					add(auditWarning, "expires soon", "Expires on "+meta.ExpiresOn.Format(time.RFC3339))
+				}
+			}
+			if meta.RotateEvery > 0 {
+				if meta.SetOn.IsZero() {
+					add(auditWarning, "stale", "Rotation required but the date it was set is unknown")
+				} else if due := meta.SetOn.Add(meta.RotateEvery); now.After(due) {
+					add(auditError, "stale", "Set on "+meta.SetOn.Format(time.RFC3339)+". Rotation was due on "+
+						due.Format(time.RFC3339))
+				}
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].keyPath != issues[j].keyPath {
+			return issues[i].keyPath < issues[j].keyPath
+		}
+		if issues[i].env != issues[j].env {
+			return issues[i].env < issues[j].env
+		}
+		return issues[i].issue < issues[j].issue
+	})
+	return
+}
+
+// auditExitCode return 1 if an error was found, 2 if only warnings were found, 0 otherwise.
+func auditExitCode(issues []auditIssue) (code int) {
+	for _, issue := range issues {
+		if issue.severity == auditError {
+			return 1
+		}
+		code = 2
+	}
+	return
+}
@@ -0,0 +1,86 @@
+package secrets
+
+import (
+	"forjj/creds"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "forjj-secrets")
+	defer os.RemoveAll(dir)
+	s := newTestResolverSecure(dir)
+	linkedFile := path.Join(dir, "linked")
+	ioutil.WriteFile(linkedFile, []byte("secret"), 0600)
+
+	set := func(env, keyPath, source, value, expiresOn, rotateEvery string) {
+		v := creds.NewValue(source, goforjj.NewValueStruct(value))
+		v.SetExpiry(expiresOn, rotateEvery)
+		keys := []string{"app", "github", keyPath}
+		s.SetObjectValue(env, "", keys[0], keys[1], keys[2], v)
+	}
+	set("test", "token", creds.Internal, "secret", "", "")
+	set("test", "expired", creds.Internal, "secret", "2020-01-01", "")
+	set("test", "expiring", creds.Internal, "secret", time.Now().Add(72*time.Hour).Format(time.RFC3339), "")
+	set(creds.Global, "rotated", creds.Internal, "secret", "", "1d")
+	set("test", "linked", link, linkedFile, "", "")
+	set("test", "undeclared", creds.Internal, "secret", "", "")
+	os.Remove(linkedFile)
+
+	declared := map[string]sInfo{}
+	for _, key := range []string{"token", "expired", "expiring", "rotated", "linked"} {
+		declared["app/github/"+key] = sInfo{}
+	}
+
+	/*********************************/
+	testCase := "when secrets are audited"
+
+	// ------------ Run function to test
+	issues := auditSecrets(s, s.Envs(), declared, time.Now(), 7*24*time.Hour)
+
+	// ------------ Test result
+	expected := []auditIssue{
+		{keyPath: "app/github/expired", env: "test", severity: auditError, issue: "expired"},
+		{keyPath: "app/github/expiring", env: "test", severity: auditWarning, issue: "expires soon"},
+		{keyPath: "app/github/linked", env: "test", severity: auditError, issue: "missing file"},
+		{keyPath: "app/github/undeclared", env: "test", severity: auditWarning, issue: "undeclared"},
+	}
+	if assert.Lenf(issues, len(expected), "Expect issues %s", testCase) {
+		for index, issue := range issues {
+			issue.detail = ""
+			assert.Equalf(expected[index], issue, "Expect the issue %s", testCase)
+		}
+	}
+	assert.Equalf(1, auditExitCode(issues), "Expect errors exit code %s", testCase)
+
+	/*********************************/
+	testCase = "when the rotation interval is over"
+
+	// ------------ Run function to test
+	issues = auditSecrets(s, []string{creds.Global}, declared, time.Now().Add(48*time.Hour), 0)
+
+	// ------------ Test result
+	if assert.Lenf(issues, 1, "Expect an issue %s", testCase) {
+		assert.Equalf("app/github/rotated", issues[0].keyPath, "Expect the stale secret %s", testCase)
+		assert.Equalf("stale", issues[0].issue, "Expect the stale secret %s", testCase)
+	}
+
+	/*********************************/
+	testCase = "when only warnings or nothing are found"
+
+	// ------------ Run function to test
+	warningCode := auditExitCode([]auditIssue{{severity: auditWarning}})
+	okCode := auditExitCode(nil)
+
+	// ------------ Test result
+	assert.Equalf(2, warningCode, "Expect warnings exit code %s", testCase)
+	assert.Equalf(0, okCode, "Expect no issue exit code %s", testCase)
+}
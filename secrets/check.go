@@ -0,0 +1,64 @@
+package secrets
+
+import (
+	"forjj/creds"
+	"sort"
+	"strings"
+)
+
+// Secret check issues.
+const (
+	checkMissing    = "missing"
+	checkEmpty      = "empty"
+	checkUnreadable = "unreadable"
+)
+
+// checkIssue is a secret issue found by 'forjj secrets check'
+type checkIssue struct {
+	KeyPath  string `json:"key-path"`
+	Required bool   `json:"required"`
+	Env      string `json:"env,omitempty"`
+	Issue    string `json:"issue"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// checkReport is the 'forjj secrets check' report.
+type checkReport struct {
+	Deployment string       `json:"deployment"`
+	Checked    int          `json:"checked"`
+	Issues     []checkIssue `json:"issues"`
+}
+
+// checkSecrets check secrets declared by plugins are set in the current deployment environment, or in global
+// secrets. Only required secrets are checked, unless all is true.
+func checkSecrets(secrets *creds.Secure, deployment string, declared map[string]sInfo, all bool) (report checkReport) {
+	report.Deployment = deployment
+	report.Issues = []checkIssue{}
+
+	keyPaths := make([]string, 0, len(declared))
+	for keyPath, info := range declared {
+		if info.required || all {
+			keyPaths = append(keyPaths, keyPath)
+		}
+	}
+	sort.Strings(keyPaths)
+
+	for _, keyPath := range keyPaths {
+		report.Checked++
+		info := declared[keyPath]
+		keys := strings.Split(keyPath, "/")
+
+		value, found, _, env := secrets.Get(keys[0], keys[1], keys[2])
+		if !found {
+			report.Issues = append(report.Issues, checkIssue{KeyPath: keyPath, Required: info.required, Issue: checkMissing})
+			continue
+		}
+		secret, err := value.GetString()
+		if err != nil {
+			report.Issues = append(report.Issues, checkIssue{KeyPath: keyPath, Required: info.required, Env: env, Issue: checkUnreadable, Detail: err.Error()})
+		} else if secret == "" {
+			report.Issues = append(report.Issues, checkIssue{KeyPath: keyPath, Required: info.required, Env: env, Issue: checkEmpty})
+		}
+	}
+	return
+}
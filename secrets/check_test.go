@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"encoding/json"
+	"forjj/creds"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "forjj-secrets")
+	defer os.RemoveAll(dir)
+	s := newTestResolverSecure(dir)
+	linkedFile := path.Join(dir, "linked")
+	ioutil.WriteFile(linkedFile, []byte("secret"), 0600)
+
+	set := func(env, key, source, value string) {
+		s.SetObjectValue(env, "", "app", "github", key, creds.NewValue(source, goforjj.NewValueStruct(value)))
+	}
+	set("test", "token", creds.Internal, "secret")
+	set(creds.Global, "user", creds.Internal, "forjj")
+	set("test", "empty", creds.Internal, "")
+	set("test", "linked", link, linkedFile)
+	set("test", "optional", creds.Internal, "")
+	os.Remove(linkedFile)
+
+	declared := map[string]sInfo{
+		"app/github/token":    {required: true},
+		"app/github/user":     {required: true},
+		"app/github/empty":    {required: true},
+		"app/github/linked":   {required: true},
+		"app/github/missing":  {required: true},
+		"app/github/optional": {},
+	}
+
+	/*********************************/
+	testCase := "when required secrets are checked"
+
+	// ------------ Run function to test
+	report := checkSecrets(s, "test", declared, false)
+
+	// ------------ Test result
+	assert.Equalf(5, report.Checked, "Expect required secrets to be checked %s", testCase)
+	if assert.Lenf(report.Issues, 3, "Expect issues %s", testCase) {
+		assert.Equalf(checkIssue{KeyPath: "app/github/empty", Required: true, Env: "test", Issue: checkEmpty}, report.Issues[0],
+			"Expect an empty secret %s", testCase)
+		assert.Equalf(checkUnreadable, report.Issues[1].Issue, "Expect an unreadable link %s", testCase)
+		assert.Equalf(checkIssue{KeyPath: "app/github/missing", Required: true, Issue: checkMissing}, report.Issues[2],
+			"Expect a missing secret %s", testCase)
+	}
+
+	/*********************************/
+	testCase = "when all secrets are checked"
+
+	// ------------ Run function to test
+	report = checkSecrets(s, "test", declared, true)
+
+	// ------------ Test result
+	assert.Equalf(6, report.Checked, "Expect all secrets to be checked %s", testCase)
+	assert.Lenf(report.Issues, 4, "Expect the optional secret issue %s", testCase)
+
+	/*********************************/
+	testCase = "when no issue is found"
+
+	// ------------ Run function to test
+	report = checkSecrets(s, "test", map[string]sInfo{"app/github/token": {required: true}}, false)
+	data, _ := json.Marshal(report)
+
+	// ------------ Test result
+	assert.Equalf(`{"deployment":"test","checked":1,"issues":[]}`, string(data), "Expect an empty JSON report %s", testCase)
+}
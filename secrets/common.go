@@ -1,6 +1,8 @@
 package secrets
 
 import (
+	"forjj/creds"
+
 	"github.com/alecthomas/kingpin"
 )
 
@@ -14,7 +16,7 @@ type common struct {
 func (c *common) init(context *Context, cmd *kingpin.CmdClause, initCommon func(context *Context, cmd *kingpin.CmdClause)) {
 	c.env = context.Flag("deploy-to",
 		cmd.Flag("deploy-env", "forjj deployment environment used to query/manage secrets. You can set 'FORJJ_DEPLOY_ENV' as environment variable.").Envar("FORJJ_DEPLOY_ENV")).String()
-	c.secretKey = cmd.Flag("secrets-key", "Base64 secrets symetric key. Note that the key is not stored.").Envar("FORJJ_SECRETS_KEY").String()
+	c.secretKey = cmd.Flag("secrets-key", "Base64 secrets symetric key. Note that the key is not stored.").Envar(creds.KeyEnvVar).String()
 	c.common = cmd.Flag("common", "To manage global secrets used by all deployments. Global secrets doesn't overwrite specific deployment secrets.").Bool()
 	
 	initCommon(context, cmd)
@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"fmt"
+	"forjj/creds"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Secrets comparison status between 2 deployment environments.
+const (
+	diffSame      = "same"
+	diffDifferent = "different"
+	diffMissing   = "missing"
+)
+
+// envDiff is a secret compared between 2 deployment environments by 'forjj secrets diff'
+type envDiff struct {
+	keyPath string
+	inA     bool
+	inB     bool
+	status  string
+}
+
+// diffSecrets compare secrets stored in envA and envB, sorted by key path.
+// Secret values are compared, never returned.
+func diffSecrets(secrets *creds.Secure, envA, envB string) (diffs []envDiff) {
+	valuesA := secrets.EnvValues(envA)
+	valuesB := secrets.EnvValues(envB)
+
+	for keyPath, valueA := range valuesA {
+		diff := envDiff{keyPath: keyPath, inA: true, status: diffMissing}
+		if valueB, found := valuesB[keyPath]; found {
+			diff.inB = true
+			diff.status = diffDifferent
+			if sameSecret(valueA, valueB) {
+				diff.status = diffSame
+			}
+		}
+		diffs = append(diffs, diff)
+	}
+	for keyPath := range valuesB {
+		if _, found := valuesA[keyPath]; !found {
+			diffs = append(diffs, envDiff{keyPath: keyPath, inB: true, status: diffMissing})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].keyPath < diffs[j].keyPath })
+	return
+}
+
+// sameSecret return true if both values have the same source and value.
+// References (link, env, exec, vault) are compared without fetching the secret.
+func sameSecret(a, b *creds.Value) bool {
+	if a.GetSource() != b.GetSource() {
+		return false
+	}
+	if isReference(a.GetSource()) {
+		return reference(a) == reference(b)
+	}
+	valueA, errA := a.GetString()
+	valueB, errB := b.GetString()
+	return errA == nil && errB == nil && valueA == valueB
+}
+
+// copySecrets copy secrets matching one of the key path patterns from an environment to another.
+// With no pattern, all secrets are copied.
+//
+// A secret already set with a different value in the destination environment is skipped, unless force is true.
+func copySecrets(secrets *creds.Secure, from, to string, patterns []string, force bool) (copied, skipped []string, _ error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, nil, fmt.Errorf("Invalid key pattern '%s'. %s", pattern, err)
+		}
+	}
+
+	for _, diff := range diffSecrets(secrets, from, to) {
+		if !diff.inA || diff.status == diffSame || !matchKeyPath(diff.keyPath, patterns) {
+			continue
+		}
+		if diff.status == diffDifferent && !force {
+			skipped = append(skipped, diff.keyPath)
+			continue
+		}
+		keyPath := strings.SplitN(diff.keyPath, "/", 3)
+		if _, err := secrets.CopyObjectValue(from, to, keyPath[0], keyPath[1], keyPath[2]); err != nil {
+			return copied, skipped, err
+		}
+		copied = append(copied, diff.keyPath)
+	}
+	return
+}
+
+// matchKeyPath return true if the key path matches one of the patterns, or if there is no pattern.
+func matchKeyPath(keyPath string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, keyPath); matched {
+			return true
+		}
+	}
+	return false
+}
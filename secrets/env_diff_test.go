@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"forjj/creds"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "forjj-secrets")
+	defer os.RemoveAll(dir)
+	s := newTestResolverSecure(dir)
+
+	set := func(env, key, source, value string) {
+		s.SetObjectValue(env, "", "app", "github", key, creds.NewValue(source, goforjj.NewValueStruct(value)))
+	}
+	set(creds.Global, "same", creds.Internal, "secret")
+	set("test", "same", creds.Internal, "secret")
+	set(creds.Global, "different", creds.Internal, "secret")
+	set("test", "different", creds.Internal, "other")
+	set(creds.Global, "env", envVar, "GITHUB_TOKEN")
+	set("test", "env", envVar, "GITHUB_TOKEN")
+	set(creds.Global, "global-only", creds.Internal, "secret")
+	set("test", "test-only", creds.Internal, "secret")
+
+	/*********************************/
+	testCase := "when secrets are compared"
+
+	// ------------ Run function to test
+	diffs := diffSecrets(s, creds.Global, "test")
+
+	// ------------ Test result
+	expected := []envDiff{
+		{keyPath: "app/github/different", inA: true, inB: true, status: diffDifferent},
+		{keyPath: "app/github/env", inA: true, inB: true, status: diffSame},
+		{keyPath: "app/github/global-only", inA: true, status: diffMissing},
+		{keyPath: "app/github/same", inA: true, inB: true, status: diffSame},
+		{keyPath: "app/github/test-only", inB: true, status: diffMissing},
+	}
+	assert.Equalf(expected, diffs, "Expect the comparison %s", testCase)
+
+	/*********************************/
+	testCase = "when secrets are copied without force"
+
+	// ------------ Run function to test
+	copied, skipped, err := copySecrets(s, creds.Global, "test", nil, false)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf([]string{"app/github/global-only"}, copied, "Expect missing secrets copied %s", testCase)
+	assert.Equalf([]string{"app/github/different"}, skipped, "Expect different secrets skipped %s", testCase)
+
+	/*********************************/
+	testCase = "when secrets matching a pattern are copied with force"
+
+	// ------------ Run function to test
+	copied, skipped, err = copySecrets(s, creds.Global, "test", []string{"app/*/diff*"}, true)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf([]string{"app/github/different"}, copied, "Expect the different secret copied %s", testCase)
+	assert.Emptyf(skipped, "Expect no secret skipped %s", testCase)
+	value, _ := s.EnvValues("test")["app/github/different"].GetString()
+	assert.Equalf("secret", value, "Expect the secret replaced %s", testCase)
+
+	/*********************************/
+	testCase = "when the pattern is invalid"
+
+	// ------------ Run function to test
+	_, _, err = copySecrets(s, creds.Global, "test", []string{"app/[github"}, false)
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+}
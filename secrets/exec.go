@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"fmt"
+	"forjj/creds"
+	"os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// execMapping maps a secret key path to an environment variable of 'forjj secrets exec'
+type execMapping struct {
+	keyPath []string
+	envVar  string
+}
+
+// parseExecMaps parse mappings given as <objectType>/<objectInstance>/<key>=<ENV_VAR>
+func parseExecMaps(maps []string) (mappings []execMapping, _ error) {
+	for _, m := range maps {
+		fields := strings.SplitN(m, "=", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("'%s' is not a valid mapping. Format is <objectType>/<objectInstance>/<key>=<ENV_VAR>", m)
+		}
+		keyPath := strings.Split(fields[0], "/")
+		if len(keyPath) != 3 || keyPath[0] == "" || keyPath[1] == "" || keyPath[2] == "" {
+			return nil, fmt.Errorf("'%s' is not a valid key path. Format is <objectType>/<objectInstance>/<key>", fields[0])
+		}
+		if !envVarName.MatchString(fields[1]) {
+			return nil, fmt.Errorf("'%s' is not a valid environment variable name", fields[1])
+		}
+		mappings = append(mappings, execMapping{keyPath: keyPath, envVar: fields[1]})
+	}
+	return
+}
+
+// execForjjEnvVars are forjj secrets variables never given to the command executed.
+var execForjjEnvVars = map[string]bool{
+	creds.KeyEnvVar:        true,
+	creds.PassphraseEnvVar: true,
+}
+
+// execEnv return the environment given, with mapped secrets added.
+//
+// Secrets are read from the current deployment environment, or from global secrets. Resolvers are executed.
+// The secrets key and passphrase variables are removed, so the command gets only the secrets mapped.
+func execEnv(secrets *creds.Secure, environ []string, mappings []execMapping) ([]string, error) {
+	vars := make(map[string]string)
+	for _, m := range mappings {
+		value, found, _, _ := secrets.Get(m.keyPath[0], m.keyPath[1], m.keyPath[2])
+		if !found {
+			return nil, fmt.Errorf("'%s' secret not found", strings.Join(m.keyPath, "/"))
+		}
+		secret, err := value.GetString()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to get '%s' secret. %s", strings.Join(m.keyPath, "/"), err)
+		}
+		vars[m.envVar] = secret
+	}
+
+	env := make([]string, 0, len(environ)+len(vars))
+	for _, v := range environ {
+		name := strings.SplitN(v, "=", 2)[0]
+		if _, mapped := vars[name]; !mapped && !execForjjEnvVars[name] {
+			env = append(env, v)
+		}
+	}
+	for _, m := range mappings {
+		if secret, found := vars[m.envVar]; found {
+			env = append(env, m.envVar+"="+secret)
+			delete(vars, m.envVar)
+		}
+	}
+	return env, nil
+}
+
+// execExitCode return the exit code of the command executed.
+func execExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			if status.Signaled() {
+				return 128 + int(status.Signal())
+			}
+			return status.ExitStatus()
+		}
+	}
+	return 1
+}
@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"forjj/creds"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExecMaps(t *testing.T) {
+	assert := assert.New(t)
+
+	/*********************************/
+	testCase := "when mappings are valid"
+
+	// ------------ Run function to test
+	mappings, err := parseExecMaps([]string{"app/github/token=GITHUB_TOKEN"})
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf([]execMapping{{keyPath: []string{"app", "github", "token"}, envVar: "GITHUB_TOKEN"}}, mappings,
+		"Expect the mapping %s", testCase)
+
+	/*********************************/
+	testCase = "when mappings are invalid"
+
+	for _, m := range []string{"app/github/token", "app/github=TOKEN", "app/github/token=1TOKEN", "app//token=TOKEN"} {
+		// ------------ Run function to test
+		_, err = parseExecMaps([]string{m})
+
+		// ------------ Test result
+		assert.Errorf(err, "Expect an error %s: '%s'", testCase, m)
+	}
+}
+
+func TestExecEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "forjj-secrets")
+	defer os.RemoveAll(dir)
+	s := newTestResolverSecure(dir)
+	s.SetObjectValue(creds.Global, "", "app", "github", "token", creds.NewValue(creds.Internal, goforjj.NewValueStruct("global-token")))
+	s.SetObjectValue(creds.Global, "", "app", "github", "user", creds.NewValue(creds.Internal, goforjj.NewValueStruct("global-user")))
+	s.SetObjectValue("test", "", "app", "github", "token", creds.NewValue(creds.Internal, goforjj.NewValueStruct("test-token")))
+	s.SetObjectValue("test", "", "app", "github", "password", creds.NewValue(envVar, goforjj.NewValueStruct("FORJJ_TEST_PASSWORD")))
+	os.Setenv("FORJJ_TEST_PASSWORD", "resolved")
+	defer os.Unsetenv("FORJJ_TEST_PASSWORD")
+
+	/*********************************/
+	testCase := "when secrets are mapped to environment variables"
+	mappings, _ := parseExecMaps([]string{"app/github/token=GITHUB_TOKEN", "app/github/user=GITHUB_USER", "app/github/password=PASSWORD"})
+
+	// ------------ Run function to test
+	env, err := execEnv(s, []string{"PATH=/bin", "GITHUB_TOKEN=old"}, mappings)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf([]string{"PATH=/bin", "GITHUB_TOKEN=test-token", "GITHUB_USER=global-user", "PASSWORD=resolved"}, env,
+		"Expect deployment secrets, global fallback and resolved secrets %s", testCase)
+
+	/*********************************/
+	testCase = "when forjj secrets key and passphrase are set"
+	mappings, _ = parseExecMaps([]string{"app/github/token=GITHUB_TOKEN"})
+
+	// ------------ Run function to test
+	env, err = execEnv(s, []string{"PATH=/bin", creds.KeyEnvVar + "=a2V5", creds.PassphraseEnvVar + "=passphrase"}, mappings)
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf([]string{"PATH=/bin", "GITHUB_TOKEN=test-token"}, env,
+		"Expect forjj secrets key and passphrase removed %s", testCase)
+
+	/*********************************/
+	testCase = "when a secret is not found"
+	mappings, _ = parseExecMaps([]string{"app/github/unknown=UNKNOWN"})
+
+	// ------------ Run function to test
+	_, err = execEnv(s, nil, mappings)
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+
+	/*********************************/
+	testCase = "when the command exits"
+
+	// ------------ Run function to test
+	code := execExitCode(exec.Command("/bin/sh", "-c", "exit 3").Run())
+
+	// ------------ Test result
+	assert.Equalf(3, code, "Expect the command exit code %s", testCase)
+}
@@ -0,0 +1,36 @@
+package secrets
+
+import (
+	"forjj/creds"
+
+	"github.com/alecthomas/kingpin"
+)
+
+// expiryFlags are flags defining secret expiry metadata, reported by 'forjj secrets audit'
+type expiryFlags struct {
+	expiresOn   *string
+	rotateEvery *string
+}
+
+func (e *expiryFlags) init(cmd *kingpin.CmdClause) {
+	e.expiresOn = cmd.Flag("expires-on", "Secret expiry date. Format is YYYY-MM-DD or RFC3339.").String()
+	e.rotateEvery = cmd.Flag("rotate-every", "Secret rotation interval (ex: 90d, 2w).").String()
+}
+
+// check validate the flags values.
+func (e *expiryFlags) check() (err error) {
+	if *e.expiresOn != "" {
+		if _, err = creds.ParseExpiresOn(*e.expiresOn); err != nil {
+			return
+		}
+	}
+	if *e.rotateEvery != "" {
+		_, err = creds.ParseInterval(*e.rotateEvery)
+	}
+	return
+}
+
+// set store the expiry metadata in the value.
+func (e *expiryFlags) set(v *creds.Value) error {
+	return v.SetExpiry(*e.expiresOn, *e.rotateEvery)
+}
@@ -0,0 +1,182 @@
+package secrets
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Secret generators used by 'forjj secrets generate'
+const (
+	passwordGenerator = "password"
+	tokenGenerator    = "token"
+	sshKeyGenerator   = "ssh-key"
+)
+
+const (
+	lowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars  = "0123456789"
+	symbolChars = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+
+	minRSABits = 2048
+)
+
+// charsets are the named password character sets. Other charset values are used as the list of characters.
+var charsets = map[string]string{
+	"alnum":   lowerChars + upperChars + digitChars,
+	"alpha":   lowerChars + upperChars,
+	"lower":   lowerChars,
+	"upper":   upperChars,
+	"digits":  digitChars,
+	"hex":     digitChars + "abcdef",
+	"symbols": lowerChars + upperChars + digitChars + symbolChars,
+}
+
+// generated is a secret created by a generator.
+type generated struct {
+	secret    string
+	publicKey string            // Public part of a keypair, if any.
+	resources map[string]string // Generator metadata.
+}
+
+// generatePassword return a random password of length characters from the charset.
+func generatePassword(length int, charset string) (*generated, error) {
+	chars, found := charsets[charset]
+	if !found {
+		chars = uniqueChars(charset)
+	}
+	if len(chars) < 2 {
+		return nil, fmt.Errorf("Invalid charset '%s'. Use one of %s or at least 2 characters", charset, charsetNames())
+	}
+	if length < 1 {
+		return nil, fmt.Errorf("Invalid password length %d", length)
+	}
+
+	max := big.NewInt(int64(len(chars)))
+	password := make([]byte, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return nil, err
+		}
+		password[i] = chars[n.Int64()]
+	}
+	return &generated{
+		secret: string(password),
+		resources: map[string]string{
+			"generator": passwordGenerator,
+			"length":    strconv.Itoa(length),
+			"charset":   charset,
+		},
+	}, nil
+}
+
+// generateToken return a random token of length bytes, hex or base64 encoded.
+func generateToken(length int, encoding string) (*generated, error) {
+	if length < 1 {
+		return nil, fmt.Errorf("Invalid token length %d", length)
+	}
+	data := make([]byte, length)
+	if _, err := rand.Read(data); err != nil {
+		return nil, err
+	}
+
+	var token string
+	switch encoding {
+	case "hex":
+		token = hex.EncodeToString(data)
+	case "base64":
+		token = base64.StdEncoding.EncodeToString(data)
+	case "base64url":
+		token = base64.RawURLEncoding.EncodeToString(data)
+	default:
+		return nil, fmt.Errorf("Invalid token encoding '%s'. Use hex, base64 or base64url", encoding)
+	}
+	return &generated{
+		secret: token,
+		resources: map[string]string{
+			"generator": tokenGenerator,
+			"length":    strconv.Itoa(length),
+			"encoding":  encoding,
+		},
+	}, nil
+}
+
+// generateSSHKey return a new SSH private key (OpenSSH format) and its public key.
+func generateSSHKey(keyType string, bits int, comment string) (*generated, error) {
+	var (
+		privateKey interface{}
+		publicKey  interface{}
+	)
+	resources := map[string]string{
+		"generator": sshKeyGenerator,
+		"key-type":  keyType,
+	}
+	switch keyType {
+	case "ed25519":
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, publicKey = private, public
+	case "rsa":
+		if bits < minRSABits {
+			return nil, fmt.Errorf("Invalid RSA key size %d. Minimum is %d", bits, minRSABits)
+		}
+		private, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, publicKey = private, &private.PublicKey
+		resources["bits"] = strconv.Itoa(bits)
+	default:
+		return nil, fmt.Errorf("Invalid SSH key type '%s'. Use ed25519 or rsa", keyType)
+	}
+
+	block, err := ssh.MarshalPrivateKey(privateKey, comment)
+	if err != nil {
+		return nil, err
+	}
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	public := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublicKey)))
+	if comment != "" {
+		public += " " + comment
+	}
+	resources["fingerprint"] = ssh.FingerprintSHA256(sshPublicKey)
+	return &generated{
+		secret:    string(pem.EncodeToMemory(block)),
+		publicKey: public,
+		resources: resources,
+	}, nil
+}
+
+// uniqueChars return characters of the string given, without duplicates.
+func uniqueChars(chars string) string {
+	found := make(map[rune]bool)
+	ret := []rune{}
+	for _, c := range chars {
+		if !found[c] && c < 128 {
+			found[c] = true
+			ret = append(ret, c)
+		}
+	}
+	return string(ret)
+}
+
+// charsetNames return the list of named charsets.
+func charsetNames() string {
+	return "alnum, alpha, lower, upper, digits, hex, symbols"
+}
@@ -0,0 +1,124 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		testCase string
+		length   int
+		charset  string
+		chars    string
+		fails    bool
+	}{
+		{testCase: "when the charset is digits", length: 20, charset: "digits", chars: digitChars},
+		{testCase: "when the charset is a list of characters", length: 16, charset: "abab", chars: "ab"},
+		{testCase: "when the charset has symbols", length: 64, charset: "symbols", chars: charsets["symbols"]},
+		{testCase: "when the charset has a single character", length: 8, charset: "a", fails: true},
+		{testCase: "when the length is invalid", length: 0, charset: "alnum", fails: true},
+	}
+
+	for _, test := range tests {
+		// ------------ Run function to test
+		secret, err := generatePassword(test.length, test.charset)
+
+		// ------------ Test result
+		if test.fails {
+			assert.Errorf(err, "Expect an error %s", test.testCase)
+			continue
+		}
+		if !assert.NoErrorf(err, "Expect no error %s", test.testCase) {
+			continue
+		}
+		assert.Lenf(secret.secret, test.length, "Expect the password length %s", test.testCase)
+		assert.Emptyf(strings.Trim(secret.secret, test.chars), "Expect only charset characters %s", test.testCase)
+		assert.Equalf(passwordGenerator, secret.resources["generator"], "Expect the generator metadata %s", test.testCase)
+		assert.Equalf(test.charset, secret.resources["charset"], "Expect the charset metadata %s", test.testCase)
+	}
+}
+
+func TestGenerateToken(t *testing.T) {
+	assert := assert.New(t)
+
+	decoders := map[string]func(string) ([]byte, error){
+		"hex":       hex.DecodeString,
+		"base64":    base64.StdEncoding.DecodeString,
+		"base64url": base64.RawURLEncoding.DecodeString,
+	}
+
+	for encoding, decode := range decoders {
+		testCase := "when the token is " + encoding + " encoded"
+
+		// ------------ Run function to test
+		secret, err := generateToken(24, encoding)
+
+		// ------------ Test result
+		if !assert.NoErrorf(err, "Expect no error %s", testCase) {
+			continue
+		}
+		data, err := decode(secret.secret)
+		assert.NoErrorf(err, "Expect the token to be decoded %s", testCase)
+		assert.Lenf(data, 24, "Expect the token length in bytes %s", testCase)
+		assert.Equalf(encoding, secret.resources["encoding"], "Expect the encoding metadata %s", testCase)
+	}
+
+	/*********************************/
+	testCase := "when the encoding is unknown"
+
+	// ------------ Run function to test
+	_, err := generateToken(24, "base32")
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+}
+
+func TestGenerateSSHKey(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		testCase string
+		keyType  string
+		bits     int
+		sshType  string
+		fails    bool
+	}{
+		{testCase: "when an ed25519 key is generated", keyType: "ed25519", sshType: ssh.KeyAlgoED25519},
+		{testCase: "when a RSA key is generated", keyType: "rsa", bits: 2048, sshType: ssh.KeyAlgoRSA},
+		{testCase: "when the RSA key is too small", keyType: "rsa", bits: 1024, fails: true},
+		{testCase: "when the key type is unknown", keyType: "dsa", fails: true},
+	}
+
+	for _, test := range tests {
+		// ------------ Run function to test
+		secret, err := generateSSHKey(test.keyType, test.bits, "forjj:repo/infra/deploy-key")
+
+		// ------------ Test result
+		if test.fails {
+			assert.Errorf(err, "Expect an error %s", test.testCase)
+			continue
+		}
+		if !assert.NoErrorf(err, "Expect no error %s", test.testCase) {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey([]byte(secret.secret))
+		if !assert.NoErrorf(err, "Expect a valid private key %s", test.testCase) {
+			continue
+		}
+		publicKey, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(secret.publicKey))
+		if assert.NoErrorf(err, "Expect a valid public key %s", test.testCase) {
+			assert.Equalf(test.sshType, publicKey.Type(), "Expect the key type %s", test.testCase)
+			assert.Equalf(signer.PublicKey().Marshal(), publicKey.Marshal(), "Expect the public key to match %s", test.testCase)
+			assert.Equalf("forjj:repo/infra/deploy-key", comment, "Expect the key comment %s", test.testCase)
+		}
+		assert.Equalf(ssh.FingerprintSHA256(publicKey), secret.resources["fingerprint"], "Expect the fingerprint metadata %s", test.testCase)
+	}
+}
@@ -0,0 +1,230 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"forjj/creds"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/forj-oss/goforjj"
+)
+
+// Resolvers are secret sources fetched at use time. Only the reference is stored in forjj secrets.
+const (
+	envVar  = "env"   // env:<VAR> - Environment variable
+	execCmd = "exec"  // exec:<command> - stdout of a shell command
+	vaultKV = "vault" // vault:<path>#<field> - Vault KV secret (v1 or v2). VAULT_ADDR and VAULT_TOKEN are used.
+
+	defaultVaultAddr  = "https://127.0.0.1:8200" // A Vault server without TLS must be set explicitly with VAULT_ADDR.
+	defaultVaultField = "value"
+)
+
+// resolverTypes is the list of resolvers accepted by 'forjj secrets set --resolver'
+var resolverTypes = []string{envVar, execCmd, vaultKV}
+
+// vaultClient is the http client used to query Vault.
+var vaultClient = &http.Client{Timeout: 30 * time.Second}
+
+// parseResolver split a '<type>:<reference>' resolver.
+func parseResolver(resolver string) (resolverType, reference string, _ error) {
+	parts := strings.SplitN(resolver, ":", 2)
+	if len(parts) == 2 && parts[1] != "" {
+		for _, resolverType := range resolverTypes {
+			if parts[0] == resolverType {
+				return parts[0], parts[1], nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("Invalid resolver '%s'. Format is <type>:<reference> where type is one of '%s'",
+		resolver, strings.Join(resolverTypes, "', '"))
+}
+
+// isReference return true if the secret source stores a reference instead of the secret.
+func isReference(source string) bool {
+	switch source {
+	case link, envVar, execCmd, vaultKV:
+		return true
+	}
+	return false
+}
+
+// reference return a printable reference of a secret source, without fetching the secret.
+func reference(v *creds.Value) (ref string) {
+	switch v.GetSource() {
+	case link:
+		ref, _ = v.GetResource("linked-to")
+	case envVar:
+		ref, _ = v.GetResource("env-var")
+		ref = envVar + ":" + ref
+	case execCmd:
+		ref, _ = v.GetResource("command")
+		ref = execCmd + ":" + ref
+	case vaultKV:
+		vaultPath, _ := v.GetResource("vault-path")
+		field, _ := v.GetResource("vault-field")
+		ref = vaultKV + ":" + vaultPath + "#" + field
+	}
+	return
+}
+
+// defineResolverSetters define setters of env, exec and vault resolvers.
+func defineResolverSetters(s *creds.Secure) {
+	s.SetSetterHandler(envVar, func(v *creds.Value, value *goforjj.ValueStruct) error {
+		name := value.GetString()
+		if name == "" || strings.ContainsAny(name, "= ") {
+			return fmt.Errorf("Invalid environment variable name '%s'", name)
+		}
+		v.SetValue(goforjj.NewValueStruct(""))
+		v.AddResource("env-var", name)
+		return nil
+	})
+
+	s.SetSetterHandler(execCmd, func(v *creds.Value, value *goforjj.ValueStruct) error {
+		command := value.GetString()
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("The command is empty")
+		}
+		v.SetValue(goforjj.NewValueStruct(""))
+		v.AddResource("command", command)
+		return nil
+	})
+
+	s.SetSetterHandler(vaultKV, func(v *creds.Value, value *goforjj.ValueStruct) error {
+		parts := strings.SplitN(value.GetString(), "#", 2)
+		vaultPath := strings.Trim(parts[0], "/")
+		if vaultPath == "" {
+			return fmt.Errorf("The vault secret path is empty")
+		}
+		field := defaultVaultField
+		if len(parts) == 2 && parts[1] != "" {
+			field = parts[1]
+		}
+		v.SetValue(goforjj.NewValueStruct(""))
+		v.AddResource("vault-path", vaultPath)
+		v.AddResource("vault-field", field)
+		return nil
+	})
+}
+
+// defineResolverGetters define getters of env, exec and vault resolvers.
+func defineResolverGetters(s *creds.Secure) {
+	s.SetGetterHandler(envVar, getFromEnv)
+	s.SetGetterHandler(execCmd, getFromExec)
+	s.SetGetterHandler(vaultKV, getFromVault)
+}
+
+// getFromEnv return the value of the environment variable.
+func getFromEnv(v *creds.YamlValue) (string, error) {
+	name, found := v.Resource["env-var"]
+	if !found {
+		return "", fmt.Errorf("Invalid Secret type. 'env-var' resource is empty")
+	}
+	value, found := os.LookupEnv(name)
+	if !found {
+		return "", fmt.Errorf("Environment variable '%s' is not set", name)
+	}
+	return value, nil
+}
+
+// getFromExec run the command with the shell and return its output, without the last end of line.
+func getFromExec(v *creds.YamlValue) (string, error) {
+	command, found := v.Resource["command"]
+	if !found {
+		return "", fmt.Errorf("Invalid Secret type. 'command' resource is empty")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("/bin/sh", "-c", command)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("Unable to get the secret from command '%s'. %s. %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(out), "\n"), "\r"), nil
+}
+
+// vaultAddr return the Vault server address from VAULT_ADDR, or the local server with TLS.
+//
+// The token is sent to this server. So, only an explicit VAULT_ADDR can use 'http://'.
+func vaultAddr() (string, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return defaultVaultAddr, nil
+	}
+	if u, err := url.Parse(addr); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", fmt.Errorf("Invalid VAULT_ADDR '%s'. Format is https://<host>:<port>", addr)
+	}
+	return addr, nil
+}
+
+// getFromVault read the secret field from a Vault KV secret engine, version 1 or 2.
+//
+// The server is given by VAULT_ADDR and the token by VAULT_TOKEN or the token helper file ~/.vault-token
+func getFromVault(v *creds.YamlValue) (string, error) {
+	vaultPath, found := v.Resource["vault-path"]
+	if !found {
+		return "", fmt.Errorf("Invalid Secret type. 'vault-path' resource is empty")
+	}
+	field := v.Resource["vault-field"]
+	if field == "" {
+		field = defaultVaultField
+	}
+
+	addr, err := vaultAddr()
+	if err != nil {
+		return "", err
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if data, err := ioutil.ReadFile(path.Join(os.Getenv("HOME"), ".vault-token")); err == nil {
+			token = strings.TrimSpace(string(data))
+		}
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(addr, "/")+"/v1/"+vaultPath, nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	resp, err := vaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Unable to read vault secret '%s'. %s", vaultPath, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unable to read vault secret '%s'. %s", vaultPath, resp.Status)
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("Unable to decode vault secret '%s'. %s", vaultPath, err)
+	}
+	data := secret.Data
+	if kv2, isKV2 := data["data"].(map[string]interface{}); isKV2 {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = kv2
+		}
+	}
+	value, found := data[field]
+	if !found {
+		return "", fmt.Errorf("Field '%s' not found in vault secret '%s'", field, vaultPath)
+	}
+	if text, isString := value.(string); isString {
+		return text, nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
@@ -0,0 +1,202 @@
+package secrets
+
+import (
+	"fmt"
+	"forjj/creds"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestResolverSecure return secrets with setters and getters defined.
+func newTestResolverSecure(dir string) *creds.Secure {
+	s := new(creds.Secure)
+	s.InitEnvDefaults(dir, "test")
+	DefineSetters(s)
+	DefineGetters(s)
+	return s
+}
+
+func TestParseResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		resolver      string
+		resolverType  string
+		resolverValue string
+		fails         bool
+	}{
+		{resolver: "env:GITHUB_TOKEN", resolverType: envVar, resolverValue: "GITHUB_TOKEN"},
+		{resolver: "exec:pass show forjj:github", resolverType: execCmd, resolverValue: "pass show forjj:github"},
+		{resolver: "vault:secret/data/forjj#token", resolverType: vaultKV, resolverValue: "secret/data/forjj#token"},
+		{resolver: "env:", fails: true},
+		{resolver: "file:/tmp/secret", fails: true},
+		{resolver: "GITHUB_TOKEN", fails: true},
+	}
+
+	for _, test := range tests {
+		testCase := "when resolver is '" + test.resolver + "'"
+
+		// ------------ Run function to test
+		resolverType, resolverValue, err := parseResolver(test.resolver)
+
+		// ------------ Test result
+		if test.fails {
+			assert.Errorf(err, "Expect an error %s", testCase)
+			continue
+		}
+		assert.NoErrorf(err, "Expect no error %s", testCase)
+		assert.Equalf(test.resolverType, resolverType, "Expect the resolver type %s", testCase)
+		assert.Equalf(test.resolverValue, resolverValue, "Expect the resolver reference %s", testCase)
+	}
+}
+
+func TestResolvers(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "forjj-secrets")
+	defer os.RemoveAll(dir)
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/forjj":
+			fmt.Fprint(w, `{"data": {"data": {"token": "vault-kv2-secret"}, "metadata": {"version": 1}}}`)
+		case "/v1/kv/forjj":
+			fmt.Fprint(w, `{"data": {"value": "vault-kv1-secret"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer vault.Close()
+
+	for env, value := range map[string]string{"FORJJ_TEST_SECRET": "env-secret", "VAULT_ADDR": vault.URL, "VAULT_TOKEN": "test-token"} {
+		os.Setenv(env, value)
+		defer os.Unsetenv(env)
+	}
+
+	tests := []struct {
+		testCase  string
+		resolver  string
+		value     string
+		reference string
+		fails     bool
+	}{
+		{
+			testCase:  "when the secret is an environment variable",
+			resolver:  "env:FORJJ_TEST_SECRET",
+			value:     "env-secret",
+			reference: "env:FORJJ_TEST_SECRET",
+		},
+		{
+			testCase: "when the environment variable is not set",
+			resolver: "env:FORJJ_TEST_MISSING",
+			fails:    true,
+		},
+		{
+			testCase:  "when the secret is a command output",
+			resolver:  "exec:echo exec-secret",
+			value:     "exec-secret",
+			reference: "exec:echo exec-secret",
+		},
+		{
+			testCase: "when the command fails",
+			resolver: "exec:exit 1",
+			fails:    true,
+		},
+		{
+			testCase:  "when the secret is in a vault KV v2 engine",
+			resolver:  "vault:/secret/data/forjj#token",
+			value:     "vault-kv2-secret",
+			reference: "vault:secret/data/forjj#token",
+		},
+		{
+			testCase:  "when the secret is in a vault KV v1 engine with the default field",
+			resolver:  "vault:kv/forjj",
+			value:     "vault-kv1-secret",
+			reference: "vault:kv/forjj#value",
+		},
+		{
+			testCase: "when the vault field is missing",
+			resolver: "vault:kv/forjj#token",
+			fails:    true,
+		},
+		{
+			testCase: "when the vault secret is missing",
+			resolver: "vault:kv/missing",
+			fails:    true,
+		},
+	}
+
+	for _, test := range tests {
+		s := newTestResolverSecure(dir)
+		resolverType, ref, _ := parseResolver(test.resolver)
+
+		// ------------ Run function to test
+		s.SetObjectValue("test", "", "app", "github", "token", creds.NewValue(resolverType, goforjj.NewValueStruct(ref)))
+		v, found, _, _ := s.Get("app", "github", "token")
+		value, err := v.GetString()
+
+		// ------------ Test result
+		if !assert.Truef(found, "Expect the secret to be found %s", test.testCase) {
+			continue
+		}
+		assert.Equalf(resolverType, v.GetSource(), "Expect the resolver source %s", test.testCase)
+		assert.Truef(isReference(v.GetSource()), "Expect a reference %s", test.testCase)
+		if test.fails {
+			assert.Errorf(err, "Expect an error %s", test.testCase)
+			continue
+		}
+		assert.NoErrorf(err, "Expect no error %s", test.testCase)
+		assert.Equalf(test.value, value, "Expect the secret value %s", test.testCase)
+		assert.Equalf(test.reference, reference(v), "Expect the secret reference %s", test.testCase)
+		stored, _, _, _ := s.GetString("app", "github", "token")
+		assert.Emptyf(stored, "Expect the secret to not be stored %s", test.testCase)
+	}
+}
+
+func TestVaultAddr(t *testing.T) {
+	assert := assert.New(t)
+
+	defer os.Setenv("VAULT_ADDR", os.Getenv("VAULT_ADDR"))
+
+	/*********************************/
+	testCase := "when VAULT_ADDR is not set"
+	os.Unsetenv("VAULT_ADDR")
+
+	// ------------ Run function to test
+	addr, err := vaultAddr()
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf("https://127.0.0.1:8200", addr, "Expect the local server with TLS %s", testCase)
+
+	/*********************************/
+	testCase = "when VAULT_ADDR is set"
+	os.Setenv("VAULT_ADDR", "http://vault.local:8200")
+
+	// ------------ Run function to test
+	addr, err = vaultAddr()
+
+	// ------------ Test result
+	assert.NoErrorf(err, "Expect no error %s", testCase)
+	assert.Equalf("http://vault.local:8200", addr, "Expect VAULT_ADDR %s", testCase)
+
+	/*********************************/
+	testCase = "when VAULT_ADDR is invalid"
+	os.Setenv("VAULT_ADDR", "vault.local:8200")
+
+	// ------------ Run function to test
+	_, err = vaultAddr()
+
+	// ------------ Test result
+	assert.Errorf(err, "Expect an error %s", testCase)
+}
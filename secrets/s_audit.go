@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"fmt"
+	"forjj/creds"
+	"forjj/drivers"
+	"forjj/forjfile"
+	"forjj/utils"
+	"time"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/forj-oss/forjj-modules/trace"
+)
+
+type sAudit struct {
+	cmd        *kingpin.CmdClause
+	warnBefore *string
+	common     *common
+
+	forjfile *forjfile.Forge
+	drivers  *drivers.Drivers
+	secrets  *creds.Secure
+}
+
+func (s *sAudit) init(parent *kingpin.CmdClause, common *common, forjfile *forjfile.Forge, drivers *drivers.Drivers, secrets *creds.Secure) {
+	s.cmd = parent.Command("audit", "Report expired, stale, broken and undeclared secrets. "+
+		"Exit code is 1 if errors are found, 2 if only warnings are found.")
+	s.warnBefore = s.cmd.Flag("warn-before", "Report secrets expiring in this interval (ex: 14d, 2w, 48h).").Default("14d").String()
+	s.common = common
+
+	s.forjfile = forjfile
+	s.drivers = drivers
+	s.secrets = secrets
+}
+
+// doAudit display secrets issues and return the command exit code.
+func (s *sAudit) doAudit() int {
+	warnBefore, err := creds.ParseInterval(*s.warnBefore)
+	if err != nil {
+		gotrace.Error("%s", err)
+		return 1
+	}
+
+	declared, _ := secretsInfo(s.forjfile, s.drivers, s.secrets, false)
+	issues := auditSecrets(s.secrets, s.secrets.Envs(), declared, time.Now(), warnBefore)
+
+	if len(issues) == 0 {
+		gotrace.Info("No secret issue found. (Deployment environment = '%s')", s.forjfile.GetDeployment())
+		return 0
+	}
+
+	array := utils.NewTerminalArray(len(issues), 5)
+	array.SetCol(0, "Path")
+	array.SetCol(1, "Environment")
+	array.SetCol(2, "Severity")
+	array.SetCol(3, "Issue")
+	array.SetCol(4, "Detail")
+
+	lines := make(map[string]auditIssue)
+	errors := 0
+	for index, issue := range issues {
+		key := fmt.Sprintf("%06d", index)
+		lines[key] = issue
+		array.EvalLine(key, len(issue.keyPath), len(issue.env), len(issue.severity), len(issue.issue), len(issue.detail))
+		if issue.severity == auditError {
+			errors++
+		}
+	}
+
+	fmt.Printf("Secrets audit: (Deployment environment = '%s')\n\n", s.forjfile.GetDeployment())
+	array.Print(
+		func(key string, compressedMax int) []interface{} {
+			issue, found := lines[key]
+			if !found {
+				return nil
+			}
+			return []interface{}{
+				issue.keyPath,
+				issue.env,
+				issue.severity,
+				issue.issue,
+				utils.StringCompress(issue.detail, 0, compressedMax),
+			}
+		},
+	)
+
+	gotrace.Info("%d issues found. %d errors, %d warnings.", len(issues), errors, len(issues)-errors)
+	return auditExitCode(issues)
+}
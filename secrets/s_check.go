@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"forjj/creds"
+	"forjj/drivers"
+	"forjj/forjfile"
+	"forjj/utils"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/forj-oss/forjj-modules/trace"
+)
+
+type sCheck struct {
+	cmd    *kingpin.CmdClause
+	all    *bool
+	json   *bool
+	common *common
+
+	forjfile *forjfile.Forge
+	drivers  *drivers.Drivers
+	secrets  *creds.Secure
+}
+
+func (s *sCheck) init(parent *kingpin.CmdClause, common *common, forjfile *forjfile.Forge, drivers *drivers.Drivers, secrets *creds.Secure) {
+	s.cmd = parent.Command("check", "Check required plugins secrets are set and readable in the current deployment environment. "+
+		"Exit code is 1 if a secret is missing, empty or unreadable.")
+	s.all = s.cmd.Flag("all", "Check all plugins secrets, not only required ones.").Bool()
+	s.json = s.cmd.Flag("json", "Print the report in JSON.").Bool()
+	s.common = common
+
+	s.forjfile = forjfile
+	s.drivers = drivers
+	s.secrets = secrets
+}
+
+// doCheck display the secrets check report and return the command exit code.
+func (s *sCheck) doCheck() int {
+	declared, _ := secretsInfo(s.forjfile, s.drivers, s.secrets, false)
+	report := checkSecrets(s.secrets, s.forjfile.GetDeployment(), declared, *s.all)
+
+	exitCode := 0
+	if len(report.Issues) > 0 {
+		exitCode = 1
+	}
+
+	if *s.json {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			gotrace.Error("Unable to encode the report. %s", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return exitCode
+	}
+
+	if len(report.Issues) == 0 {
+		gotrace.Info("%d secrets checked. No issue found. (Deployment environment = '%s')", report.Checked, report.Deployment)
+		return exitCode
+	}
+
+	array := utils.NewTerminalArray(len(report.Issues), 5)
+	array.SetCol(0, "Path")
+	array.SetCol(1, "Required")
+	array.SetCol(2, "Environment")
+	array.SetCol(3, "Issue")
+	array.SetCol(4, "Detail")
+
+	lines := make(map[string]checkIssue)
+	for index, issue := range report.Issues {
+		key := fmt.Sprintf("%06d", index)
+		lines[key] = issue
+		array.EvalLine(key, len(issue.KeyPath), len(fmt.Sprint(issue.Required)), len(issue.Env), len(issue.Issue), len(issue.Detail))
+	}
+
+	fmt.Printf("Secrets check: (Deployment environment = '%s')\n\n", report.Deployment)
+	array.Print(
+		func(key string, compressedMax int) []interface{} {
+			issue, found := lines[key]
+			if !found {
+				return nil
+			}
+			return []interface{}{
+				issue.KeyPath,
+				issue.Required,
+				issue.Env,
+				issue.Issue,
+				utils.StringCompress(issue.Detail, 0, compressedMax),
+			}
+		},
+	)
+
+	gotrace.Info("%d secrets checked. %d issues found.", report.Checked, len(report.Issues))
+	return exitCode
+}
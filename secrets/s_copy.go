@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"forjj/creds"
+	"forjj/forjfile"
+	"strings"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/forj-oss/forjj-modules/trace"
+)
+
+type sCopy struct {
+	cmd    *kingpin.CmdClause
+	from   *string
+	to     *string
+	keys   *[]string
+	force  *bool
+	common *common
+
+	forjfile *forjfile.Forge
+	secrets  *creds.Secure
+}
+
+func (s *sCopy) init(parent *kingpin.CmdClause, common *common, forjfile *forjfile.Forge, secrets *creds.Secure) {
+	s.cmd = parent.Command("copy", "Copy secrets from a deployment environment to another.")
+	s.from = s.cmd.Flag("from", "Source deployment environment name, or '"+creds.Global+"'.").Required().String()
+	s.to = s.cmd.Flag("to", "Destination deployment environment name, or '"+creds.Global+"'.").Required().String()
+	s.keys = s.cmd.Flag("keys", "Key path pattern (<objectType>/<objectInstance>/<key>) of secrets to copy. "+
+		"'*' matches any part of a path element (ex: app/*/token). Can be repeated. By default, all secrets are copied.").Strings()
+	s.force = s.cmd.Flag("force", "Replace secrets already set with a different value in the destination environment.").Bool()
+	s.common = common
+
+	s.forjfile = forjfile
+	s.secrets = secrets
+}
+
+// doCopy copy secrets between environments and save the destination one.
+func (s *sCopy) doCopy() {
+	if *s.from == *s.to {
+		gotrace.Error("Source and destination deployment environments are identical.")
+		return
+	}
+	for _, env := range []string{*s.from, *s.to} {
+		if err := loadSecretsEnv(s.forjfile, s.secrets, env); err != nil {
+			gotrace.Error("%s", err)
+			return
+		}
+	}
+
+	copied, skipped, err := copySecrets(s.secrets, *s.from, *s.to, *s.keys, *s.force)
+	if err != nil {
+		gotrace.Error("%s", err)
+		return
+	}
+	if len(skipped) > 0 {
+		gotrace.Warning("Secrets not copied, as already set with a different value in '%s': %s. Use --force to replace them.",
+			*s.to, strings.Join(skipped, ", "))
+	}
+	if len(copied) == 0 {
+		gotrace.Info("No secret to copy from '%s' to '%s'.", *s.from, *s.to)
+		return
+	}
+
+	if err := s.secrets.SaveEnv(*s.to); err != nil {
+		gotrace.Error("Unable to save '%s' secrets. %s", *s.to, err)
+		return
+	}
+	gotrace.Info("%d secrets copied from '%s' to '%s': %s", len(copied), *s.from, *s.to, strings.Join(copied, ", "))
+}
@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"fmt"
+	"forjj/creds"
+	"forjj/forjfile"
+	"forjj/utils"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/forj-oss/forjj-modules/trace"
+)
+
+type sDiff struct {
+	cmd    *kingpin.CmdClause
+	envA   *string
+	envB   *string
+	common *common
+
+	forjfile *forjfile.Forge
+	secrets  *creds.Secure
+}
+
+func (s *sDiff) init(parent *kingpin.CmdClause, common *common, forjfile *forjfile.Forge, secrets *creds.Secure) {
+	s.cmd = parent.Command("diff", "Compare secrets between 2 deployment environments. Secret values are never displayed.")
+	s.envA = s.cmd.Arg("envA", "Deployment environment name, or '"+creds.Global+"'.").Required().String()
+	s.envB = s.cmd.Arg("envB", "Deployment environment name, or '"+creds.Global+"'.").Required().String()
+	s.common = common
+
+	s.forjfile = forjfile
+	s.secrets = secrets
+}
+
+// doDiff display secrets presence and value equality in both environments.
+func (s *sDiff) doDiff() {
+	for _, env := range []string{*s.envA, *s.envB} {
+		if err := loadSecretsEnv(s.forjfile, s.secrets, env); err != nil {
+			gotrace.Error("%s", err)
+			return
+		}
+	}
+
+	diffs := diffSecrets(s.secrets, *s.envA, *s.envB)
+	if len(diffs) == 0 {
+		gotrace.Info("No secret found in '%s' and '%s'.", *s.envA, *s.envB)
+		return
+	}
+
+	array := utils.NewTerminalArray(len(diffs), 4)
+	array.SetCol(0, "Path")
+	array.SetCol(1, *s.envA)
+	array.SetCol(2, *s.envB)
+	array.SetCol(3, "Status")
+
+	present := map[bool]string{true: "set", false: "-"}
+	lines := make(map[string]envDiff)
+	different := 0
+	for index, diff := range diffs {
+		key := fmt.Sprintf("%06d", index)
+		lines[key] = diff
+		array.EvalLine(key, len(diff.keyPath), len(present[diff.inA]), len(present[diff.inB]), len(diff.status))
+		if diff.status != diffSame {
+			different++
+		}
+	}
+
+	fmt.Printf("Secrets comparison between '%s' and '%s':\n\n", *s.envA, *s.envB)
+	array.Print(
+		func(key string, compressedMax int) []interface{} {
+			diff, found := lines[key]
+			if !found {
+				return nil
+			}
+			return []interface{}{
+				utils.StringCompress(diff.keyPath, 0, compressedMax),
+				present[diff.inA],
+				present[diff.inB],
+				diff.status,
+			}
+		},
+	)
+
+	gotrace.Info("%d secrets compared. %d differences.", len(diffs), different)
+}
+
+// loadSecretsEnv check the environment exists in the Forjfile and load its secrets.
+func loadSecretsEnv(forjfile *forjfile.Forge, secrets *creds.Secure, env string) error {
+	if env != creds.Global {
+		if _, found := forjfile.GetADeployment(env); !found {
+			return fmt.Errorf("'%s' is not a valid deployment environment. Use '%s' or one of the Forjfile deployments", env, creds.Global)
+		}
+	}
+	if err := secrets.LoadEnv(env); err != nil {
+		return fmt.Errorf("Unable to load '%s' secrets. %s", env, err)
+	}
+	return nil
+}
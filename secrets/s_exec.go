@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"forjj/creds"
+	"os"
+	"os/exec"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/forj-oss/forjj-modules/trace"
+)
+
+type sExec struct {
+	cmd     *kingpin.CmdClause
+	maps    *[]string
+	command *[]string
+	common  *common
+
+	secrets *creds.Secure
+}
+
+func (s *sExec) init(parent *kingpin.CmdClause, common *common, secrets *creds.Secure) {
+	s.cmd = parent.Command("exec", "Run a command with secrets given as environment variables. "+
+		"Secrets are never written to disk or displayed. The forjj exit code is the command one.")
+	s.maps = s.cmd.Flag("map", "Secret to give to the command. Format is <objectType>/<objectInstance>/<key>=<ENV_VAR>. Can be repeated.").Required().Strings()
+	s.command = s.cmd.Arg("command", "Command and arguments to run. Use '--' before the command to give it flags.").Required().Strings()
+	s.common = common
+
+	s.secrets = secrets
+}
+
+// doExec run the command with secrets of the current deployment environment and return its exit code.
+func (s *sExec) doExec() int {
+	mappings, err := parseExecMaps(*s.maps)
+	if err != nil {
+		gotrace.Error("%s", err)
+		return 1
+	}
+	env, err := execEnv(s.secrets, os.Environ(), mappings)
+	if err != nil {
+		gotrace.Error("%s", err)
+		return 1
+	}
+
+	command := *s.command
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		gotrace.Error("%s", err)
+		return 127
+	}
+	cmd := exec.Command(path, command[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			gotrace.Error("Unable to run '%s'. %s", command[0], err)
+		}
+		return execExitCode(err)
+	}
+	return 0
+}
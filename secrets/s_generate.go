@@ -0,0 +1,144 @@
+package secrets
+
+import (
+	"forjj/creds"
+	"forjj/drivers"
+	"forjj/forjfile"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/forj-oss/forjj-modules/trace"
+	"github.com/forj-oss/goforjj"
+)
+
+type sGenerate struct {
+	cmd         *kingpin.CmdClause
+	key         *string
+	generator   *string
+	length      *int
+	charset     *string
+	encoding    *string
+	keyType     *string
+	bits        *int
+	publicKeyTo *string
+	force       *bool
+	expiry      expiryFlags
+	common      *common
+
+	elements map[string]sInfo
+
+	forjfile *forjfile.Forge
+	drivers  *drivers.Drivers
+	secrets  *creds.Secure
+}
+
+func (s *sGenerate) init(parent *kingpin.CmdClause, common *common, forjfile *forjfile.Forge, drivers *drivers.Drivers, secrets *creds.Secure) {
+	s.cmd = parent.Command("generate", "Generate a random password, token or SSH keypair and store it in forjj secrets")
+	s.key = s.cmd.Arg("key", "Key path. Format is <objectType>/<objectInstance>/<key>.").Required().String()
+	s.generator = s.cmd.Flag("type", "Type of secret to generate.").Default(passwordGenerator).Enum(passwordGenerator, tokenGenerator, sshKeyGenerator)
+	s.length = s.cmd.Flag("length", "Password length in characters, or token length in bytes.").Default("32").Int()
+	s.charset = s.cmd.Flag("charset", "Password charset. One of "+charsetNames()+", or the list of characters to use.").Default("alnum").String()
+	s.encoding = s.cmd.Flag("encoding", "Token encoding. hex, base64 or base64url.").Default("hex").String()
+	s.keyType = s.cmd.Flag("key-type", "SSH key type. ed25519 or rsa.").Default("ed25519").String()
+	s.bits = s.cmd.Flag("bits", "RSA SSH key size.").Default("4096").Int()
+	s.publicKeyTo = s.cmd.Flag("public-key-to", "Forjfile key path (<objectType>/<objectInstance>/<key>) where the SSH public key is stored.").String()
+	s.force = s.cmd.Flag("force", "Replace an existing secret.").Bool()
+	s.expiry.init(s.cmd)
+	s.common = common
+
+	s.forjfile = forjfile
+	s.drivers = drivers
+	s.secrets = secrets
+}
+
+// doGenerate generate a secret and save it to the path given.
+// Only supported path are recognized.
+func (s *sGenerate) doGenerate() {
+	var env string
+	s.elements, env = secretsInfo(s.forjfile, s.drivers, s.secrets, *s.common.common)
+
+	info, found := s.elements[*s.key]
+	if !found {
+		gotrace.Error("'%s' is not a valid secret path. check with `forjj secrets`", *s.key)
+		return
+	}
+	if info.found && info.env == env && !*s.force {
+		gotrace.Error("'%s' secret already exists in '%s' deployment environment. Use --force to replace it.", *s.key, env)
+		return
+	}
+
+	if err := s.expiry.check(); err != nil {
+		gotrace.Error("%s", err)
+		return
+	}
+
+	publicKeyPath, ok := s.publicKeyPath()
+	if !ok {
+		return
+	}
+
+	var (
+		secret *generated
+		err    error
+	)
+	switch *s.generator {
+	case passwordGenerator:
+		secret, err = generatePassword(*s.length, *s.charset)
+	case tokenGenerator:
+		secret, err = generateToken(*s.length, *s.encoding)
+	case sshKeyGenerator:
+		secret, err = generateSSHKey(*s.keyType, *s.bits, "forjj:"+*s.key)
+	}
+	if err != nil {
+		gotrace.Error("Unable to generate '%s'. %s", *s.key, err)
+		return
+	}
+
+	keyPath := strings.Split(*s.key, "/")
+	v := creds.NewValue(creds.Internal, goforjj.NewValueStruct(secret.secret))
+	for key, value := range secret.resources {
+		v.AddResource(key, value)
+	}
+	v.AddResource("generated-on", time.Now().String())
+	s.expiry.set(v)
+
+	s.secrets.SetObjectValue(env, creds.Internal, keyPath[0], keyPath[1], keyPath[2], v)
+	if err := s.secrets.SaveEnv(env); err != nil {
+		gotrace.Error("Unable to save '%s' secret. %s", *s.key, err)
+		return
+	}
+	gotrace.Info("'%s' %s generated and saved in '%s' deployment environment.", *s.key, *s.generator, env)
+
+	if publicKeyPath == nil || secret.publicKey == "" {
+		return
+	}
+	s.forjfile.SetTo(env, "forjj", publicKeyPath[0], publicKeyPath[1], publicKeyPath[2], secret.publicKey)
+	if err := s.forjfile.Save(); err != nil {
+		gotrace.Error("Unable to save the public key in the Forjfile. %s", err)
+		return
+	}
+	gotrace.Info("Public key saved in Forjfile '%s'.", *s.publicKeyTo)
+}
+
+// publicKeyPath return the Forjfile key path where the public key is stored, if requested.
+// It returns false if the path is invalid.
+func (s *sGenerate) publicKeyPath() ([]string, bool) {
+	if *s.publicKeyTo == "" {
+		return nil, true
+	}
+	if *s.generator != sshKeyGenerator {
+		gotrace.Error("--public-key-to requires --type %s.", sshKeyGenerator)
+		return nil, false
+	}
+	keyPath := strings.Split(*s.publicKeyTo, "/")
+	if len(keyPath) != 3 || keyPath[0] == "" || keyPath[1] == "" || keyPath[2] == "" {
+		gotrace.Error("'%s' is not a valid Forjfile key path. Format is <objectType>/<objectInstance>/<key>.", *s.publicKeyTo)
+		return nil, false
+	}
+	if _, isSecret := s.elements[*s.publicKeyTo]; isSecret {
+		gotrace.Error("'%s' is a secure key. The public key must be stored in a non secure Forjfile key.", *s.publicKeyTo)
+		return nil, false
+	}
+	return keyPath, true
+}
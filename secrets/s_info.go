@@ -6,5 +6,6 @@ type sInfo struct {
 	source string
 	env string
 	found bool
+	required bool
 }
 
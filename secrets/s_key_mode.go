@@ -0,0 +1,71 @@
+package secrets
+
+import (
+	"fmt"
+	"forjj/creds"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/forj-oss/forjj-modules/trace"
+)
+
+// Secrets key modes
+const (
+	keyFileMode    = "key-file"
+	passphraseMode = "passphrase"
+)
+
+type sKeyMode struct {
+	cmd    *kingpin.CmdClause
+	mode   *string
+	common *common
+
+	secrets *creds.Secure
+}
+
+func (s *sKeyMode) init(parent *kingpin.CmdClause, common *common, secrets *creds.Secure) {
+	s.cmd = parent.Command("key-mode", "Re-encrypt all deployment environments with a key derived from a passphrase ("+
+		creds.PassphraseEnvVar+" or prompted), or with a key file.")
+	s.mode = s.cmd.Arg("mode", "Secrets key mode.").Required().Enum(passphraseMode, keyFileMode)
+	s.common = common
+
+	s.secrets = secrets
+}
+
+// doKeyMode migrate secrets between key file and passphrase modes.
+// If it fails, secrets files and key are not updated.
+func (s *sKeyMode) doKeyMode() {
+	switch *s.mode {
+	case passphraseMode:
+		if s.secrets.KeyFromPassphrase() {
+			gotrace.Info("Secrets are already encrypted with a passphrase.")
+			return
+		}
+		passphrase, err := creds.ReadPassphrase(true)
+		if err != nil {
+			gotrace.Error("%s", err)
+			return
+		}
+		if err := s.secrets.UsePassphrase(passphrase); err != nil {
+			gotrace.Error("%s", err)
+			return
+		}
+		gotrace.Info("Secrets of all deployment environments encrypted with a key derived from your passphrase.")
+		if s.secrets.KeyFromIdentity() {
+			gotrace.Info("The key is wrapped for all secrets recipients. Your private key is still used to unwrap it.")
+			return
+		}
+		gotrace.Info("The key file was removed. Set %s on your CI systems.", creds.PassphraseEnvVar)
+	case keyFileMode:
+		if !s.secrets.KeyFromPassphrase() {
+			gotrace.Info("Secrets are already encrypted with a key file.")
+			return
+		}
+		key64, err := s.secrets.UseKeyFile()
+		if err != nil {
+			gotrace.Error("%s", err)
+			return
+		}
+		gotrace.Info("Secrets of all deployment environments encrypted with a new key.")
+		fmt.Printf("New secrets key:\n%s\n\nDistribute it to your team and CI systems (FORJJ_SECRETS_KEY). The passphrase is no more valid.\n", key64)
+	}
+}
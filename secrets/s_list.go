@@ -60,14 +60,12 @@ func (l *sList) showList() {
 				value, info.found, _, info.env = l.secrets.Get(objectName, instanceName, keyName)
 			}
 
-			if v, err := value.GetString(); err != nil {
+			if !*l.show && isReference(value.GetSource()) {
+				info.value = reference(value)
+			} else if v, err := value.GetString(); err != nil {
 				info.value = fmt.Sprintf("Warning! %s", err)
 			} else {
-				if !*l.show && value.GetSource() == link {
-					info.value, _ = value.GetResource("linked-to")
-				} else {
-					info.value = v
-				}
+				info.value = v
 			}
 			info.source = value.GetSource()
 
@@ -91,7 +89,7 @@ func (l *sList) showList() {
 	for secretPath, secretValue := range l.elements {
 		if *l.show {
 			value = strings.Replace(secretValue.value, "\n", "\\n", -1)
-		} else if secretValue.source == link {
+		} else if isReference(secretValue.source) {
 			value = secretValue.value
 		}
 		array.EvalLine(secretPath,
@@ -119,7 +117,7 @@ func (l *sList) showList() {
 				value = "***"
 				if *l.show {
 					value = strings.Replace(secretValue.value, "\n", "\\n", -1)
-				} else if secretValue.source == link {
+				} else if isReference(secretValue.source) {
 					value = secretValue.value
 				}
 
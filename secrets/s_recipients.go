@@ -0,0 +1,111 @@
+package secrets
+
+import (
+	"fmt"
+	"forjj/creds"
+	"forjj/forjfile"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/forj-oss/forjj-modules/trace"
+)
+
+type sRecipients struct {
+	cmd    *kingpin.CmdClause
+	common *common
+
+	add struct {
+		cmd       *kingpin.CmdClause
+		user      *string
+		publicKey *string
+	}
+
+	remove struct {
+		cmd  *kingpin.CmdClause
+		user *string
+	}
+
+	forjfile *forjfile.Forge
+	secrets  *creds.Secure
+}
+
+func (s *sRecipients) init(parent *kingpin.CmdClause, common *common, forjfile *forjfile.Forge, secrets *creds.Secure) {
+	s.cmd = parent.Command("recipients", "Manage users who can unwrap the secrets key with their private key")
+	s.common = common
+
+	s.add.cmd = s.cmd.Command("add", "Wrap the secrets key for a user public key")
+	s.add.user = s.add.cmd.Arg("user", "User name. Without --public-key, the Forjfile user 'secrets-public-key' is used.").Required().String()
+	s.add.publicKey = s.add.cmd.Flag("public-key", "age recipient, SSH ed25519 or RSA public key, or a file containing it.").String()
+
+	s.remove.cmd = s.cmd.Command("remove", "Remove a user from secrets recipients")
+	s.remove.user = s.remove.cmd.Arg("user", "User name").Required().String()
+
+	s.forjfile = forjfile
+	s.secrets = secrets
+}
+
+// action execute the recipients sub command
+func (s *sRecipients) action(action string) {
+	switch action {
+	case "add":
+		s.doAdd()
+	case "remove":
+		s.doRemove()
+	}
+}
+
+// doAdd wrap the secrets key for the user public key and save it in the recipients file.
+func (s *sRecipients) doAdd() {
+	user := *s.add.user
+	publicKey, err := s.userPublicKey(user, *s.add.publicKey)
+	if err != nil {
+		gotrace.Error("%s", err)
+		return
+	}
+
+	if err := s.secrets.AddRecipient(user, publicKey); err != nil {
+		gotrace.Error("Unable to add recipient '%s'. %s", user, err)
+		return
+	}
+	gotrace.Info("'%s' added to secrets recipients. The user can now save a private key in '%s' instead of the secrets key.",
+		user, creds.DefaultSecretKeyFile)
+}
+
+// userPublicKey return the public key given (text or file), or the one declared in the Forjfile user.
+func (s *sRecipients) userPublicKey(user, publicKey string) (string, error) {
+	if publicKey == "" {
+		if v, found, _ := s.forjfile.Get("user", user, forjfile.UserSecretsPublicKey); found {
+			publicKey = v.GetString()
+		}
+	}
+	if publicKey == "" {
+		return "", fmt.Errorf("No public key given for '%s'. Use --public-key or set '%s' to user '%s' in your Forjfile",
+			user, forjfile.UserSecretsPublicKey, user)
+	}
+	if _, err := os.Stat(publicKey); err == nil {
+		data, err := ioutil.ReadFile(publicKey)
+		if err != nil {
+			return "", fmt.Errorf("Unable to read public key file '%s'. %s", publicKey, err)
+		}
+		publicKey = string(data)
+	}
+	return strings.TrimSpace(publicKey), nil
+}
+
+// doRemove remove a user from recipients.
+func (s *sRecipients) doRemove() {
+	user := *s.remove.user
+	found, err := s.secrets.RemoveRecipient(user)
+	if err != nil {
+		gotrace.Error("Unable to remove recipient '%s'. %s", user, err)
+		return
+	}
+	if !found {
+		gotrace.Warning("'%s' is not a secrets recipient.", user)
+		return
+	}
+	gotrace.Info("'%s' removed from secrets recipients.", user)
+	gotrace.Warning("'%s' still knows the current secrets key. Run 'forjj secrets rotate-key' to replace it.", user)
+}
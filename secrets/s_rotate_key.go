@@ -0,0 +1,56 @@
+package secrets
+
+import (
+	"fmt"
+	"forjj/creds"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/forj-oss/forjj-modules/trace"
+)
+
+type sRotateKey struct {
+	cmd    *kingpin.CmdClause
+	common *common
+
+	secrets *creds.Secure
+}
+
+func (s *sRotateKey) init(parent *kingpin.CmdClause, common *common, secrets *creds.Secure) {
+	s.cmd = parent.Command("rotate-key", "Replace the secrets key by a new one and re-encrypt all deployment environments")
+	s.common = common
+
+	s.secrets = secrets
+}
+
+// doRotateKey re-encrypt all environments secrets with a new key and display it.
+// If the key is derived from a passphrase, a new passphrase is prompted.
+// If it fails, secrets files and key are not updated.
+func (s *sRotateKey) doRotateKey() {
+	var newPassphrase []byte
+	if s.secrets.KeyFromPassphrase() {
+		passphrase, err := creds.PromptPassphrase("New secrets passphrase", true)
+		if err != nil {
+			gotrace.Error("%s", err)
+			return
+		}
+		newPassphrase = passphrase
+	}
+
+	key64, err := s.secrets.RotateKey(newPassphrase)
+	if err != nil {
+		gotrace.Error("%s", err)
+		return
+	}
+
+	gotrace.Info("Secrets of all deployment environments encrypted with a new key.")
+	if s.secrets.KeyFromPassphrase() {
+		gotrace.Info("The new key is derived from your new passphrase. Update %s on your CI systems. The old passphrase is no more valid.",
+			creds.PassphraseEnvVar)
+		return
+	}
+	if s.secrets.KeyFromIdentity() {
+		gotrace.Info("The new key is wrapped for all secrets recipients. Your private key is still used to unwrap it.")
+		return
+	}
+	fmt.Printf("New secrets key:\n%s\n\nDistribute it to your team and CI systems (FORJJ_SECRETS_KEY). The old key is no more valid.\n", key64)
+}
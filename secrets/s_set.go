@@ -23,6 +23,8 @@ type sSet struct {
 	common   *common
 	copyFile *string
 	linkFile *string
+	resolver *string
+	expiry   expiryFlags
 
 	elements map[string]sInfo
 
@@ -42,6 +44,9 @@ func (s *sSet) init(parent *kingpin.CmdClause, common *common, forjfile *forjfil
 	s.password = s.cmd.Flag("password", "Secret key value").Short('P').String()
 	s.copyFile = s.cmd.Flag("from-file", "Copy secret in forjj internal from the file name given. The file must exists.").String()
 	s.linkFile = s.cmd.Flag("use-file", "Link the secret to the file name given.").String()
+	s.resolver = s.cmd.Flag("resolver", "Fetch the secret at use time from 'env:<VAR>', 'exec:<command>' or 'vault:<path>#<field>'. "+
+		"The secret itself is not stored.").String()
+	s.expiry.init(s.cmd)
 	s.common = common
 
 	s.forjfile = forjfile
@@ -60,9 +65,24 @@ func (s *sSet) doSet() {
 		return
 	}
 
-	if (*s.copyFile != "" && *s.linkFile != "") || (*s.password != "" && (*s.copyFile != "" || *s.linkFile != "")) {
-		gotrace.Error("Incorrect flags combination. flags --copy-file, --link-file and --password are exclusive. Choose the proper one and retry.")
+	if err := s.expiry.check(); err != nil {
+		gotrace.Error("%s", err)
 		return
+	}
+
+	flags := 0
+	for _, flag := range []string{*s.password, *s.copyFile, *s.linkFile, *s.resolver} {
+		if flag != "" {
+			flags++
+		}
+	}
+	if flags > 1 {
+		gotrace.Error("Incorrect flags combination. flags --from-file, --use-file, --resolver and --password are exclusive. Choose the proper one and retry.")
+		return
+	} else if *s.resolver != "" {
+		if !s.setResolver(env) {
+			return
+		}
 	} else if *s.copyFile != "" {
 		s.copyFromFile(env)
 	} else if *s.linkFile != "" {
@@ -77,15 +97,21 @@ func (s *sSet) doSet() {
 
 // loadSecretsInfo load the list of secrets
 func (s *sSet) loadSecretsInfo() (env string) {
-	ffd := s.forjfile.InMemForjfile()
+	s.elements, env = secretsInfo(s.forjfile, s.drivers, s.secrets, *s.common.common)
+	return
+}
+
+// secretsInfo return secrets defined by plugins and the environment where secrets are managed.
+func secretsInfo(forjfile *forjfile.Forge, drivers *drivers.Drivers, secrets *creds.Secure, common bool) (elements map[string]sInfo, env string) {
+	ffd := forjfile.InMemForjfile()
 
-	scan := scandrivers.NewScanDrivers(ffd, s.drivers)
-	s.elements = make(map[string]sInfo)
+	scan := scandrivers.NewScanDrivers(ffd, drivers)
+	elements = make(map[string]sInfo)
 
 	// Retrieve secrets path
 	scan.SetScanObjFlag(func(objectName, instanceName, flagPrefix, name string, flag goforjj.YamlFlag) error {
 		if flag.Options.Secure {
-			info := sInfo{}
+			info := sInfo{required: flag.Options.Required}
 			info.keyPath = objectName + "/" + instanceName + "/"
 			keyName := name
 			if flagPrefix != "" {
@@ -93,20 +119,20 @@ func (s *sSet) loadSecretsInfo() (env string) {
 			}
 			info.keyPath += keyName
 
-			if *s.common.common {
-				info.value, info.found, info.source, info.env = s.secrets.GetGlobalString(objectName, instanceName, keyName)
+			if common {
+				info.value, info.found, info.source, info.env = secrets.GetGlobalString(objectName, instanceName, keyName)
 			} else {
-				info.value, info.found, info.source, info.env = s.secrets.GetString(objectName, instanceName, keyName)
+				info.value, info.found, info.source, info.env = secrets.GetString(objectName, instanceName, keyName)
 			}
 
-			s.elements[info.keyPath] = info
+			elements[info.keyPath] = info
 		}
 		return nil
 	})
 	scan.DoScanDriversObject()
 
-	env = s.forjfile.GetDeployment()
-	if *s.common.common {
+	env = forjfile.GetDeployment()
+	if common {
 		env = creds.Global
 	}
 	return
@@ -128,6 +154,7 @@ func (s *sSet) setInternal(env string) {
 	keyPath := strings.Split(*s.key, "/")
 
 	v := creds.NewValue("internal", goforjj.NewValueStruct(*s.password))
+	s.expiry.set(v)
 
 	if !s.secrets.SetObjectValue(env, "internal", keyPath[0], keyPath[1], keyPath[2], v) {
 		gotrace.Info("'%s' secret text not updated.", *s.key)
@@ -139,6 +166,7 @@ func (s *sSet) copyFromFile(env string) {
 	keyPath := strings.Split(*s.key, "/")
 
 	v := creds.NewValue(copy, goforjj.NewValueStruct(*s.copyFile))
+	s.expiry.set(v)
 
 	if !s.secrets.SetObjectValue(env, "", keyPath[0], keyPath[1], keyPath[2], v) {
 		gotrace.Info("'%s' secret text not updated.", *s.key)
@@ -151,6 +179,7 @@ func (s *sSet) linkToFile(env string) {
 	keyPath := strings.Split(*s.key, "/")
 
 	v := creds.NewValue(link, goforjj.NewValueStruct(*s.linkFile))
+	s.expiry.set(v)
 
 	if !s.secrets.SetObjectValue(env, "", keyPath[0], keyPath[1], keyPath[2], v) {
 		gotrace.Info("'%s' secret text not updated.", *s.key)
@@ -158,3 +187,22 @@ func (s *sSet) linkToFile(env string) {
 	}
 
 }
+
+// setResolver set a secret fetched at use time from a resolver. It returns false on error.
+func (s *sSet) setResolver(env string) bool {
+	resolverType, reference, err := parseResolver(*s.resolver)
+	if err != nil {
+		gotrace.Error("%s", err)
+		return false
+	}
+
+	keyPath := strings.Split(*s.key, "/")
+
+	v := creds.NewValue(resolverType, goforjj.NewValueStruct(reference))
+	s.expiry.set(v)
+
+	if !s.secrets.SetObjectValue(env, "", keyPath[0], keyPath[1], keyPath[2], v) {
+		gotrace.Info("'%s' secret text not updated.", *s.key)
+	}
+	return true
+}
@@ -32,6 +32,27 @@ type Secrets struct {
 	edit sEdit
 
 	unset sUnset
+
+	rotateKey sRotateKey
+
+	keyMode sKeyMode
+
+	recipients sRecipients
+
+	generate sGenerate
+
+	audit sAudit
+
+	check sCheck
+
+	diff sDiff
+
+	copy sCopy
+
+	exec sExec
+
+	// exitCode is the forjj exit code set by the secrets command executed.
+	exitCode int
 }
 
 // Init initialize the secrets cli commands
@@ -51,6 +72,15 @@ func (s *Secrets) Init(app *kingpin.Application, forjfile *forjfile.Forge, drive
 	s.get.key = s.get.cmd.Arg("key", "Full key path").Required().String()
 
 	s.unset.init(s.secrets, &s.common, forjfile, drivers, secrets)
+	s.rotateKey.init(s.secrets, &s.common, secrets)
+	s.keyMode.init(s.secrets, &s.common, secrets)
+	s.recipients.init(s.secrets, &s.common, forjfile, secrets)
+	s.generate.init(s.secrets, &s.common, forjfile, drivers, secrets)
+	s.audit.init(s.secrets, &s.common, forjfile, drivers, secrets)
+	s.check.init(s.secrets, &s.common, forjfile, drivers, secrets)
+	s.diff.init(s.secrets, &s.common, forjfile, secrets)
+	s.copy.init(s.secrets, &s.common, forjfile, secrets)
+	s.exec.init(s.secrets, &s.common, secrets)
 }
 
 func (s *Secrets) Action(action string) {
@@ -64,10 +94,35 @@ func (s *Secrets) Action(action string) {
 		s.edit.doEdit()
 	case "unset":
 		s.unset.doUnset()
+	case "rotate-key":
+		s.rotateKey.doRotateKey()
+	case "key-mode":
+		s.keyMode.doKeyMode()
+	case "generate":
+		s.generate.doGenerate()
+	case "audit":
+		s.exitCode = s.audit.doAudit()
+	case "check":
+		s.exitCode = s.check.doCheck()
+	case "diff":
+		s.diff.doDiff()
+	case "copy":
+		s.copy.doCopy()
+	case "exec":
+		s.exitCode = s.exec.doExec()
+	case "recipients":
+		if len(actions) > 2 {
+			s.recipients.action(actions[2])
+		}
 	case "show":
 	}
 }
 
+// ExitCode return the exit code of the secrets command executed.
+func (s *Secrets) ExitCode() int {
+	return s.exitCode
+}
+
 // DefineContext define cli Context to permit ParseContext to retrieve
 // common variable set.
 func (s *Secrets) DefineContext(context clier.ParseContexter) {
@@ -79,7 +134,7 @@ func (s *Secrets) GetStringValue(field string) (value string, found, isDefault b
 	return s.Context.GetStringValue(field)
 }
 
-// DefineSetters define the list of secrets value setters (ex: copied-from, link-to)
+// DefineSetters define the list of secrets value setters (ex: copied-from, link-to, env, exec, vault)
 func DefineSetters(s *creds.Secure) {
 
 	// Define how secret set a file link
@@ -122,9 +177,10 @@ func DefineSetters(s *creds.Secure) {
 		return
 	})
 
+	defineResolverSetters(s)
 }
 
-// DefineGetters define the list of secrets value setters (ex: copied-from, link-to)
+// DefineGetters define the list of secrets value getters (ex: copied-from, link-to, env, exec, vault)
 func DefineGetters(s *creds.Secure) {
 
 	// Define how secrets get a link
@@ -153,4 +209,5 @@ func DefineGetters(s *creds.Secure) {
 		return
 	})
 
+	defineResolverGetters(s)
 }
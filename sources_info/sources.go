@@ -1,16 +1,39 @@
 package sourcesinfo
 
+import "sort"
+
+// Sources keeps track of who set each key.
+//
+// keys store the last writer of a key (the winner)
+// chain store every writer of a key, in order of update.
 type Sources struct {
-	keys map[string]string
+	keys  map[string]string
+	chain map[string][]Source
+}
+
+// Source describes one writer of a key.
+// An empty Value means that the source has removed the key.
+type Source struct {
+	Source string
+	Value  string
 }
 
 func newSources() (ret *Sources) {
 	ret = new(Sources)
 	ret.keys = make(map[string]string)
+	ret.chain = make(map[string][]Source)
 	return
 }
 
 func (s *Sources)Set(source, key, value string) (ret *Sources) {
+	ret = s.SetUnchained(source, key, value)
+	ret.addToChain(source, key, value)
+	return
+}
+
+// SetUnchained set the key source, like Set, without adding the source to the key chain.
+// It is used for a default value not applied, which is not a source of the value.
+func (s *Sources) SetUnchained(source, key, value string) (ret *Sources) {
 	if s == nil {
 		ret = newSources()
 	} else {
@@ -33,4 +56,47 @@ func (s *Sources)Get(key string) (source string) {
 		return v
 	}
 	return
-}
\ No newline at end of file
+}
+
+// Chain return the list of sources which updated the key, from the first one to the last one.
+//
+// A source is listed only once, at the position of its last update.
+func (s *Sources) Chain(key string) (ret []Source) {
+	if s == nil {
+		return
+	}
+	if chain, found := s.chain[key]; found {
+		ret = make([]Source, len(chain))
+		copy(ret, chain)
+	}
+	return
+}
+
+// Keys return the sorted list of keys which have a chain of sources.
+func (s *Sources) Keys() (ret []string) {
+	if s == nil {
+		return
+	}
+	ret = make([]string, 0, len(s.chain))
+	for key := range s.chain {
+		ret = append(ret, key)
+	}
+	sort.Strings(ret)
+	return
+}
+
+// addToChain add the source at the end of the key chain. If the source was already in the chain
+// it is moved at the end with the new value.
+func (s *Sources) addToChain(source, key, value string) {
+	if s.chain == nil {
+		s.chain = make(map[string][]Source)
+	}
+	chain := s.chain[key]
+	for index, element := range chain {
+		if element.Source == source {
+			chain = append(chain[:index], chain[index+1:]...)
+			break
+		}
+	}
+	s.chain[key] = append(chain, Source{Source: source, Value: value})
+}
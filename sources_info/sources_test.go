@@ -115,3 +115,105 @@ func TestGet(t *testing.T) {
 		t.Errorf("Expect get to return ''. Got '%s'", ret3)
 	}
 }
+
+func TestChain(t *testing.T) {
+	t.Log("Expect chain to return every source which updated a key.")
+
+	var sources *Sources
+
+	const (
+		src1   = "src1"
+		src2   = "src2"
+		src3   = "src3"
+		key1   = "key1"
+		key2   = "key2"
+		value1 = "value1"
+		value2 = "value2"
+		value3 = "value3"
+	)
+	// ------------ Run function to test
+	// sources is nil
+	ret := sources.Chain(key1)
+
+	// ------------ Test result
+	if ret != nil {
+		t.Errorf("Expect chain to return nil. Got '%#v'", ret)
+	}
+
+	// ------------ update context
+	sources = sources.Set(src1, key1, value1)
+	sources = sources.Set(src2, key1, value2)
+	sources = sources.Set(src3, key2, value3)
+
+	// ------------ Run function to test
+	ret = sources.Chain(key1)
+
+	// ------------ Test result
+	if len(ret) != 2 {
+		t.Errorf("Expect chain to return 2 sources. Got %d", len(ret))
+	} else if ret[0].Source != src1 || ret[0].Value != value1 {
+		t.Errorf("Expect chain first element to be '%s=%s'. Got '%s=%s'", src1, value1, ret[0].Source, ret[0].Value)
+	} else if ret[1].Source != src2 || ret[1].Value != value2 {
+		t.Errorf("Expect chain last element to be '%s=%s'. Got '%s=%s'", src2, value2, ret[1].Source, ret[1].Value)
+	} else if v := sources.Get(key1); v != src2 {
+		t.Errorf("Expect get to return the last source '%s'. Got '%s'", src2, v)
+	}
+
+	// ------------ update context
+	// src1 updates again key1, and key1 is removed by src3
+	sources = sources.Set(src1, key1, value3)
+	sources = sources.Set(src3, key1, "")
+
+	// ------------ Run function to test
+	ret = sources.Chain(key1)
+
+	// ------------ Test result
+	if len(ret) != 3 {
+		t.Errorf("Expect chain to return 3 sources. Got %d", len(ret))
+	} else if ret[0].Source != src2 {
+		t.Errorf("Expect chain first element to be '%s'. Got '%s'", src2, ret[0].Source)
+	} else if ret[1].Source != src1 || ret[1].Value != value3 {
+		t.Errorf("Expect chain second element to be '%s=%s'. Got '%s=%s'", src1, value3, ret[1].Source, ret[1].Value)
+	} else if ret[2].Source != src3 || ret[2].Value != "" {
+		t.Errorf("Expect chain last element to be '%s' with no value. Got '%s=%s'", src3, ret[2].Source, ret[2].Value)
+	} else if v := sources.Get(key1); v != "" {
+		t.Errorf("Expect get to return no source. Got '%s'", v)
+	}
+
+	// ------------ Run function to test
+	keys := sources.Keys()
+
+	// ------------ Test result
+	if len(keys) != 2 {
+		t.Errorf("Expect keys to return 2 keys. Got %d", len(keys))
+	} else if keys[0] != key1 || keys[1] != key2 {
+		t.Errorf("Expect keys to return '%s' and '%s'. Got '%s'", key1, key2, keys)
+	}
+}
+
+func TestSetUnchained(t *testing.T) {
+	t.Log("Expect SetUnchained to set the key source without updating the chain.")
+
+	var sources *Sources
+
+	const (
+		src1   = "src1"
+		src2   = "src2"
+		key1   = "key1"
+		value1 = "value1"
+		value2 = "value2"
+	)
+	sources = sources.Set(src1, key1, value1)
+
+	// ------------ Run function to test
+	sources = sources.SetUnchained(src2, key1, value2)
+
+	// ------------ Test result
+	if v := sources.Get(key1); v != src2 {
+		t.Errorf("Expect get to return '%s'. Got '%s'", src2, v)
+	} else if ret := sources.Chain(key1); len(ret) != 1 {
+		t.Errorf("Expect chain to return 1 source. Got %d", len(ret))
+	} else if ret[0].Source != src1 || ret[0].Value != value1 {
+		t.Errorf("Expect chain element to be '%s=%s'. Got '%s=%s'", src1, value1, ret[0].Source, ret[0].Value)
+	}
+}
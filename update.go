@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"forjj/creds"
+	"forjj/git"
 	"log"
 	"regexp"
 
@@ -84,11 +85,8 @@ func (a *Forj) Update() error {
 	}
 
 	// Now, we are in the infra repo root directory and at least, the 1st commit exist.
-
-	// Disabled as not ready.
-	//if err := a.MoveToFixBranch(*a.Actions["update"].argsv["branch"]) ; err != nil {
-	//    return fmt.Errorf("Unable to move to your feature branch. %s", err)
-	//}
+	// With --branch, the infra repository has been moved to the feature branch before loading the Forjfile.
+	// See ParseContext.
 
 	instances := a.define_drivers_execution_order()
 
@@ -118,26 +116,108 @@ func (a *Forj) Update() error {
 		}
 	}
 
-	commitMsg := fmt.Sprintf("Forge '%s' updated.", a.w.GetString("organization"))
+	commitMsg, err := a.commitMessage("update", fmt.Sprintf("Forge '%s' updated.", a.w.GetString("organization")))
+	if err != nil {
+		return err
+	}
+
+	if err := a.commitFixBranch(commitMsg); err != nil {
+		return err
+	}
 
 	if deployPublish, found, _ := a.cli.GetBoolValue("_app", "forjj", "deploy-publish"); found && deployPublish {
-		if err := a.d.GitCommit(commitMsg); err != nil {
-			return fmt.Errorf("Failed to commit deploy files. %s", err)
+		if err := a.f.CheckPublishApprovals(a.d.Name()); err != nil {
+			return fmt.Errorf("Deploy publish refused. %s", err)
 		}
 
-		if err := a.d.GitPush(false); err != nil {
-			return fmt.Errorf("Failed to push deploy commits. %s", err)
+		if err := a.publishDeploy(commitMsg); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// MoveToFixBranch create or switch to the feature branch in the infra repository.
+//
+// It must be called before loading the Forjfile, so the Forjfile of the feature branch is used.
+// Plugins generated files are then committed in this branch. The infra repository must be clean.
+// Without branch or on master, nothing is done, as before.
+// The branch used before is restored by restoreFromFixBranch if the context can't be loaded.
 func (a *Forj) MoveToFixBranch(branch string) error {
 	a.Branch = branch
 
+	if branch == "" || branch == "master" {
+		return nil
+	}
+
 	if ok, _ := regexp.MatchString(`^[\w_-]+$`, branch); !ok {
 		return fmt.Errorf("Invalid git branch name '%s'. alphanumeric, '_' and '-' are supported.", branch)
 	}
-	return nil
+
+	return git.RunInPath(a.f.InfraPath(), func() error {
+		status := git.GetStatus()
+		if status.Err != nil {
+			return fmt.Errorf("Issue to check git status. %s", status.Err)
+		}
+		if status.CountFiles() > 0 {
+			return fmt.Errorf("The infra repository is not clean (%d file(s) updated). Commit or stash them before updating in branch '%s'",
+				status.CountFiles(), branch)
+		}
+
+		current := git.GetCurrentBranch()
+		if current == branch {
+			return nil
+		}
+		found, err := git.BranchExist(branch)
+		if err != nil {
+			return err
+		}
+		if found {
+			if git.Do("checkout", branch) != 0 {
+				return fmt.Errorf("Unable to switch to branch '%s'", branch)
+			}
+		} else if git.Do("checkout", "-b", branch) != 0 {
+			return fmt.Errorf("Unable to create branch '%s'", branch)
+		}
+		a.fixBranchFrom, a.fixBranchCreated = current, !found
+		gotrace.Info("Infra repository moved to branch '%s'.", branch)
+		return nil
+	})
+}
+
+// restoreFromFixBranch move back the infra repository to the branch used before MoveToFixBranch.
+//
+// It is called when the context can't be loaded, so a failing command doesn't leave the user on the
+// feature branch. A feature branch created by MoveToFixBranch is removed.
+func (a *Forj) restoreFromFixBranch() {
+	if a.fixBranchFrom == "" {
+		return
+	}
+	from := a.fixBranchFrom
+	a.fixBranchFrom = ""
+	git.RunInPath(a.f.InfraPath(), func() error {
+		if git.Do("checkout", from) != 0 {
+			gotrace.Warning("Unable to move back the infra repository to branch '%s'.", from)
+			return nil
+		}
+		if a.fixBranchCreated {
+			git.Do("branch", "-d", a.Branch)
+		}
+		gotrace.Info("Infra repository moved back to branch '%s'.", from)
+		return nil
+	})
+}
+
+// commitFixBranch commit plugins generated files in the infra repository feature branch.
+func (a *Forj) commitFixBranch(commitMsg string) error {
+	if a.Branch == "" || a.Branch == "master" {
+		return nil
+	}
+	return git.RunInPath(a.f.InfraPath(), func() error {
+		if err := git.Commit(commitMsg, false); err != nil {
+			return fmt.Errorf("Failed to commit infra files in branch '%s'. %s", a.Branch, err)
+		}
+		return nil
+	})
 }
@@ -81,14 +81,20 @@ func RunCmdOutput(command string, args ...string) (string, int) {
 		return "", 255
 	}
 
+	// Standard output must be read before waiting for the command end.
+	lines := []string{}
+	for scan.Scan() {
+		lines = append(lines, scan.Text())
+	}
+	output := strings.Join(lines, "\n")
+
 	if err := cmd.Wait(); err != nil {
+		if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.ExitStatus() > 0 {
+			return output, status.ExitStatus()
+		}
 		kingpin.Errorf("\nERROR: wait failure - %s: %s.", command, err)
-		return "", 1
+		return output, 1
 	}
 	gotrace.Trace("Command done")
-	if status := cmd.ProcessState.Sys().(syscall.WaitStatus); status.ExitStatus() != 0 {
-		kingpin.Errorf("\n%s ERROR: Unable to get process status - %s: %s", command, cmd.ProcessState.String())
-		return scan.Text(), status.ExitStatus()
-	}
-	return scan.Text(), 0
+	return output, 0
 }
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCmdOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	/*********************************/
+	testCase := "when the command succeeds"
+
+	// ------------ Run function to test
+	output, code := RunCmdOutput("sh", "-c", "echo line1; echo line2")
+
+	// ------------ Test result
+	assert.Equalf(0, code, "Expect the command to succeed %s", testCase)
+	assert.Equalf("line1\nline2", output, "Expect the standard output to be returned %s", testCase)
+
+	/*********************************/
+	testCase = "when the command fails"
+
+	// ------------ Run function to test
+	output, code = RunCmdOutput("sh", "-c", "echo failed; exit 3")
+
+	// ------------ Test result
+	assert.Equalf(3, code, "Expect the command exit status %s", testCase)
+	assert.Equalf("failed", output, "Expect the standard output to be returned %s", testCase)
+
+	/*********************************/
+	testCase = "when the command doesn't exist"
+
+	// ------------ Run function to test
+	output, code = RunCmdOutput("forjj-inexistent-command")
+
+	// ------------ Test result
+	assert.Equalf(255, code, "Expect the command to not be started %s", testCase)
+	assert.Emptyf(output, "Expect no output %s", testCase)
+}
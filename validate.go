@@ -2,12 +2,23 @@ package main
 
 import (
 	"fmt"
+	"forjj/creds"
+	"forjj/forjfile"
+	"forjj/scandrivers"
 	"log"
+	"sort"
+	"strings"
 
+	gotrace "github.com/forj-oss/forjj-modules/trace"
 	"github.com/forj-oss/goforjj"
 )
 
 func (a *Forj) validateAction(string) {
+	if v := a.cli.GetAction(val_act).GetBoolAddr(fix_f); v != nil && *v {
+		if err := a.fixSecureFlags(); err != nil {
+			log.Fatalf("Forjj validate issue. %s", err)
+		}
+	}
 	if err := a.Validate(); err != nil {
 		log.Fatalf("Forjj validate issue. %s", err)
 	}
@@ -42,13 +53,28 @@ func (a *Forj) FoundValidAppFlag(key, driver, object string, required bool) (_ b
 }
 
 // ValidateForjfile read all object fields and check if they are recognized by forjj or plugins.
+// Plugins secure flags must not be set with a plain text value.
 func (a *Forj) ValidateForjfile() (_ error) {
+	return a.validateForjfile(true)
+}
+
+// validateForjfile validate the Forjfile. Plugins secure flags plain text values are reported if checkSecure is true.
+func (a *Forj) validateForjfile(checkSecure bool) (_ error) {
 	f := a.f.DeployForjfile()
 
 	if err := a.f.Validate(); err != nil {
 		return fmt.Errorf("Validation error. %s", err)
 	}
 
+	if checkSecure {
+		if plaintexts, err := a.scanSecureFlags(nil); err != nil {
+			return err
+		} else if len(plaintexts) > 0 {
+			return fmt.Errorf("Secure flags set with a plain text value in the Forjfile: %s. "+
+				"Use `forjj validate --fix` to move them to encrypted secrets", strings.Join(plaintexts, ", "))
+		}
+	}
+
 	// AppYamlStruct.More
 	for _, app := range f.Apps {
 		for key := range app.More {
@@ -63,3 +89,83 @@ func (a *Forj) ValidateForjfile() (_ error) {
 	fmt.Print("Validated successfully.\n")
 	return
 }
+
+// forjfiles return the master Forjfile and the deployments Forjfiles, indexed by secrets environment.
+func (a *Forj) forjfiles() (ret map[string]*forjfile.DeployForgeYaml) {
+	ret = map[string]*forjfile.DeployForgeYaml{creds.Global: a.f.DeployForjfile()}
+	for name, deploy := range a.f.GetDeployments() {
+		if deploy.Details != nil {
+			ret[name] = deploy.Details
+		}
+	}
+	return
+}
+
+// scanSecureFlags return the plugins secure flags set with a plain text value in the Forjfiles.
+// A value entirely defined by a template, like '{{ .Current.Creds.<flag> }}', or referring to a forjj secret,
+// like '{{ secret "<objectType>/<objectInstance>/<key>" }}', is not a plain text value.
+//
+// found is called for each of them, if not nil.
+func (a *Forj) scanSecureFlags(found func(ffd *forjfile.DeployForgeYaml, env, objectName, instanceName, key string) error) (plaintexts []string, _ error) {
+	forjfiles := a.forjfiles()
+	envs := make([]string, 0, len(forjfiles))
+	for env := range forjfiles {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+
+	for _, env := range envs {
+		ffd := forjfiles[env]
+		s := scandrivers.NewScanDrivers(ffd, &a.drivers)
+		s.SetScanObjFlag(
+			func(objectName, instanceName, flagPrefix, flagName string, flag goforjj.YamlFlag) error {
+				if !flag.Options.Secure {
+					return nil
+				}
+				key := flagPrefix + flagName
+				v, isFound, _ := ffd.Get(objectName, instanceName, key)
+				if !isFound || v.GetString() == "" || forjfile.IsTemplateOnly(v.GetString()) || forjfile.HasSecretRef(v.GetString()) {
+					return nil
+				}
+				plaintexts = append(plaintexts, fmt.Sprintf("%s/%s/%s (%s)", objectName, instanceName, key, env))
+				if found == nil {
+					return nil
+				}
+				return found(ffd, env, objectName, instanceName, key)
+			})
+		if err := s.DoScanDriversObject(); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(plaintexts)
+	return
+}
+
+// fixSecureFlags move plugins secure flags plain text values from Forjfiles to encrypted secrets.
+func (a *Forj) fixSecureFlags() error {
+	envs := make(map[string]bool)
+	plaintexts, err := a.scanSecureFlags(func(ffd *forjfile.DeployForgeYaml, env, objectName, instanceName, key string) error {
+		if err := a.s.LoadEnv(env); err != nil {
+			return fmt.Errorf("Unable to load '%s' secrets. %s", env, err)
+		}
+		envs[env] = true
+		return a.moveSecureObjectData(ffd, env, objectName, instanceName, key, false)
+	})
+	if err != nil {
+		return err
+	}
+	if len(plaintexts) == 0 {
+		return nil
+	}
+
+	for env := range envs {
+		if err := a.s.SaveEnv(env); err != nil {
+			return fmt.Errorf("Unable to save '%s' secrets. %s", env, err)
+		}
+	}
+	if err := a.f.Save(); err != nil {
+		return fmt.Errorf("Unable to save the Forjfile. %s", err)
+	}
+	gotrace.Info("Secure flags moved from the Forjfile to encrypted secrets: %s", strings.Join(plaintexts, ", "))
+	return nil
+}